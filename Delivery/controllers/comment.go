@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	Domain "github.com/surafelbkassa/go-task-manager/Domain"
+	"github.com/surafelbkassa/go-task-manager/Usecases"
+)
+
+// CommentController serves the comments of a task.
+type CommentController struct {
+	uc Usecases.CommentUseCaseInterface
+}
+
+func NewCommentController(u Usecases.CommentUseCaseInterface) *CommentController {
+	return &CommentController{uc: u}
+}
+
+// GetComments handles GET /tasks/:id/comments.
+func (cc *CommentController) GetComments(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	limit, err := parseLimit(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	page, err := cc.uc.GetComments(c.Request.Context(), actor, c.Param("id"), Domain.CommentQuery{Cursor: c.Query("cursor"), Limit: limit})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, NewCommentListResponse(page))
+}
+
+// AddComment handles POST /tasks/:id/comments.
+func (cc *CommentController) AddComment(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	var req CommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(invalidBody(err))
+		return
+	}
+	comment, err := cc.uc.AddComment(c.Request.Context(), actor, c.Param("id"), req.Body)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, NewCommentResponse(comment))
+}
+
+// UpdateComment handles PUT /tasks/:id/comments/:comment_id.
+func (cc *CommentController) UpdateComment(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	var req CommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(invalidBody(err))
+		return
+	}
+	comment, err := cc.uc.UpdateComment(c.Request.Context(), actor, c.Param("id"), c.Param("comment_id"), req.Body)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, NewCommentResponse(comment))
+}
+
+// DeleteComment handles DELETE /tasks/:id/comments/:comment_id.
+func (cc *CommentController) DeleteComment(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	if err := cc.uc.DeleteComment(c.Request.Context(), actor, c.Param("id"), c.Param("comment_id")); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// NotificationController serves the caller's notifications.
+type NotificationController struct {
+	uc Usecases.NotificationUseCaseInterface
+}
+
+func NewNotificationController(u Usecases.NotificationUseCaseInterface) *NotificationController {
+	return &NotificationController{uc: u}
+}
+
+// GetNotifications handles GET /notifications.
+func (nc *NotificationController) GetNotifications(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	q := Domain.NotificationQuery{Cursor: c.Query("cursor")}
+	limit, err := parseLimit(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	q.Limit = limit
+	if unread := c.Query("unread"); unread != "" {
+		if q.Unread, err = strconv.ParseBool(unread); err != nil {
+			_ = c.Error(invalidQuery("unread must be true or false"))
+			return
+		}
+	}
+	page, err := nc.uc.GetNotifications(c.Request.Context(), actor, q)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, NewNotificationListResponse(page))
+}
+
+// MarkNotificationRead handles POST /notifications/:id/read.
+func (nc *NotificationController) MarkNotificationRead(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	n, err := nc.uc.MarkNotificationRead(c.Request.Context(), actor, c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, NewNotificationResponse(n))
+}
@@ -1,13 +1,17 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	Domain "github.com/surafelbkassa/go-task-manager/Domain"
-	"github.com/surafelbkassa/go-task-manager/Infrastructure"
 	"github.com/surafelbkassa/go-task-manager/Usecases"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // TaskController
@@ -19,58 +23,620 @@ func NewTaskController(u Usecases.TaskUseCaseInterface) *TaskController {
 	return &TaskController{uc: u}
 }
 
+var errUnauthenticated = Domain.NewError(Domain.ErrUnauthorized, "unauthenticated", "unauthenticated")
+
+// invalidBody reports a request body that could not be decoded.
+func invalidBody(err error) error {
+	return Domain.NewError(Domain.ErrValidation, "invalid_body", err.Error())
+}
+
+// invalidQuery reports a malformed query string parameter.
+func invalidQuery(format string, args ...interface{}) error {
+	return Domain.NewError(Domain.ErrValidation, "invalid_query", fmt.Sprintf(format, args...))
+}
+
+// parseLimit reads the optional page size; 0 means the default.
+func parseLimit(c *gin.Context) (int, error) {
+	limit := c.Query("limit")
+	if limit == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(limit)
+	if err != nil || n < 1 {
+		return 0, invalidQuery("limit must be a positive integer")
+	}
+	return n, nil
+}
+
+// currentActor builds the caller identity stored by AuthMiddleware.
+func currentActor(c *gin.Context) (Domain.Actor, bool) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		return Domain.Actor{}, false
+	}
+	id, ok := userID.(*Domain.ID)
+	if !ok || id == nil {
+		return Domain.Actor{}, false
+	}
+	org, _ := c.Get("org_id")
+	orgID, _ := org.(Domain.ID)
+	return Domain.Actor{UserID: *id, Role: c.GetString("user_role"), OrgID: orgID}, true
+}
+
 func (tc *TaskController) GetTasks(c *gin.Context) {
-	list, err := tc.uc.GetTasks()
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	q, err := parseTaskQuery(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
-	c.JSON(http.StatusOK, list)
+	page, err := tc.uc.GetTasks(c.Request.Context(), actor, q)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, NewTaskListResponse(page))
+}
+
+// GetAssignedTasks handles GET /me/tasks, the tasks assigned to the
+// caller. It takes the same parameters as GET /tasks.
+func (tc *TaskController) GetAssignedTasks(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	q, err := parseTaskQuery(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	page, err := tc.uc.GetAssignedTasks(c.Request.Context(), actor, q)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, NewTaskListResponse(page))
+}
+
+// GetProjectTasks handles GET /projects/:id/tasks. It takes the same
+// parameters as GET /tasks.
+func (tc *TaskController) GetProjectTasks(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	q, err := parseTaskQuery(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	page, err := tc.uc.GetProjectTasks(c.Request.Context(), actor, c.Param("id"), q)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, NewTaskListResponse(page))
+}
+
+// parseTaskQuery reads the GET /tasks filter, sort and paging parameters.
+// sort takes a field name, prefixed with "-" for descending order.
+func parseTaskQuery(c *gin.Context) (Domain.TaskQuery, error) {
+	q := Domain.TaskQuery{
+		TitleContains: c.Query("q"),
+		Cursor:        c.Query("cursor"),
+	}
+	for _, raw := range c.QueryArray("status") {
+		st, ok := Domain.ParseTaskStatus(raw)
+		if !ok {
+			return q, invalidQuery("unknown status %q", raw)
+		}
+		q.Statuses = append(q.Statuses, st)
+	}
+	q.Tags = c.QueryArray("tag")
+	switch match := Domain.TagMatch(c.Query("tag_match")); match {
+	case "", Domain.TagMatchAny, Domain.TagMatchAll:
+		q.TagMatch = match
+	default:
+		return q, invalidQuery("tag_match must be %q or %q", Domain.TagMatchAny, Domain.TagMatchAll)
+	}
+	if owner := c.Query("owner_id"); owner != "" {
+		id, err := Domain.ParseID(owner)
+		if err != nil {
+			return q, invalidQuery("invalid owner_id")
+		}
+		q.OwnerID = &id
+	}
+	if sort := c.Query("sort"); sort != "" {
+		q.SortDesc = strings.HasPrefix(sort, "-")
+		q.SortBy = Domain.TaskSortField(strings.TrimPrefix(sort, "-"))
+	}
+	limit, err := parseLimit(c)
+	if err != nil {
+		return q, err
+	}
+	q.Limit = limit
+	for param, dst := range map[string]**time.Time{
+		"due_from":     &q.DueFrom,
+		"due_to":       &q.DueTo,
+		"created_from": &q.CreatedFrom,
+		"created_to":   &q.CreatedTo,
+		"updated_from": &q.UpdatedFrom,
+		"updated_to":   &q.UpdatedTo,
+	} {
+		raw := c.Query(param)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return q, invalidQuery("%s must be an RFC 3339 timestamp", param)
+		}
+		*dst = &t
+	}
+	return q, nil
 }
 
 func (tc *TaskController) GetTaskById(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
 	id := c.Param("id")
-	task, err := tc.uc.GetTaskByID(id)
+	task, err := tc.uc.GetTaskByID(c.Request.Context(), actor, id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
-	c.JSON(http.StatusOK, task)
+	if notModified(c, task) {
+		return
+	}
+	respondTask(c, http.StatusOK, task)
 }
 
 func (tc *TaskController) CreateTask(c *gin.Context) {
-	var t Domain.Task
-	if err := c.ShouldBindJSON(&t); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	var req TaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(invalidBody(err))
 		return
 	}
-	created, err := tc.uc.CreateTask(t)
+	created, err := tc.uc.CreateTask(c.Request.Context(), actor, req.toDomain())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
-	c.JSON(http.StatusCreated, created)
+	respondTask(c, http.StatusCreated, created)
 }
 
 func (tc *TaskController) UpdatedTask(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
 	id := c.Param("id")
-	var t Domain.Task
-	if err := c.ShouldBindJSON(&t); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	var req TaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(invalidBody(err))
+		return
+	}
+	updated, err := tc.uc.UpdateTask(c.Request.Context(), actor, id, version, req.toDomain())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	respondTask(c, http.StatusOK, updated)
+}
+
+// mergePatchType is the RFC 7396 media type; plain JSON is accepted too.
+const mergePatchType = "application/merge-patch+json"
+
+var (
+	errUnsupportedPatch = Domain.NewError(Domain.ErrValidation, "unsupported_media_type",
+		"PATCH bodies must be "+mergePatchType+" or application/json")
+	errNotAnObject = errors.New("merge patch must be a JSON object")
+)
+
+// PatchTask handles PATCH /tasks/:id with a JSON merge patch: only the
+// members present in the body are changed.
+func (tc *TaskController) PatchTask(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	if ct := c.ContentType(); ct != mergePatchType && ct != gin.MIMEJSON {
+		_ = c.Error(errUnsupportedPatch)
+		return
+	}
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		_ = c.Error(invalidBody(err))
+		return
+	}
+	patch, err := decodeTaskMergePatch(body)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	task, err := tc.uc.PatchTask(c.Request.Context(), actor, c.Param("id"), version, patch)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	respondTask(c, http.StatusOK, task)
+}
+
+// TransitionTask handles POST /tasks/:id/transition.
+func (tc *TaskController) TransitionTask(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	var req TransitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(invalidBody(err))
+		return
+	}
+	task, err := tc.uc.TransitionTask(c.Request.Context(), actor, c.Param("id"), req.Status)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	respondTask(c, http.StatusOK, task)
+}
+
+// GetSubtasks handles GET /tasks/:id/subtasks. It takes the same
+// parameters as GET /tasks.
+func (tc *TaskController) GetSubtasks(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	q, err := parseTaskQuery(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	page, err := tc.uc.GetSubtasks(c.Request.Context(), actor, c.Param("id"), q)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, NewTaskListResponse(page))
+}
+
+// GetTaskTree handles GET /tasks/:id/tree?depth=N.
+func (tc *TaskController) GetTaskTree(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	depth := 0
+	if raw := c.Query("depth"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			_ = c.Error(invalidQuery("depth must be a positive integer"))
+			return
+		}
+		depth = n
+	}
+	tree, err := tc.uc.GetTaskTree(c.Request.Context(), actor, c.Param("id"), depth)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, NewTaskTreeResponse(tree))
+}
+
+// GetDependencies handles GET /tasks/:id/dependencies.
+func (tc *TaskController) GetDependencies(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	deps, err := tc.uc.GetDependencies(c.Request.Context(), actor, c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, NewDependenciesResponse(deps))
+}
+
+// AddDependency handles POST /tasks/:id/dependencies.
+func (tc *TaskController) AddDependency(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	var req DependencyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(invalidBody(err))
+		return
+	}
+	task, err := tc.uc.AddDependency(c.Request.Context(), actor, c.Param("id"), req.TaskID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	respondTask(c, http.StatusOK, task)
+}
+
+// RemoveDependency handles DELETE /tasks/:id/dependencies/:dependency_id.
+func (tc *TaskController) RemoveDependency(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	task, err := tc.uc.RemoveDependency(c.Request.Context(), actor, c.Param("id"), c.Param("dependency_id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	respondTask(c, http.StatusOK, task)
+}
+
+// AddTags handles POST /tasks/:id/tags.
+func (tc *TaskController) AddTags(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	var req TagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(invalidBody(err))
+		return
+	}
+	task, err := tc.uc.AddTags(c.Request.Context(), actor, c.Param("id"), req.Tags)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	respondTask(c, http.StatusOK, task)
+}
+
+// RemoveTag handles DELETE /tasks/:id/tags/:tag.
+func (tc *TaskController) RemoveTag(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	task, err := tc.uc.RemoveTag(c.Request.Context(), actor, c.Param("id"), c.Param("tag"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	respondTask(c, http.StatusOK, task)
+}
+
+// GetTags handles GET /tags, the caller's tags with their task counts.
+func (tc *TaskController) GetTags(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	counts, err := tc.uc.GetTags(c.Request.Context(), actor)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, NewTagListResponse(counts))
+}
+
+// GetPlan handles GET /plan?task_id=...: the given tasks, and whatever
+// they still wait for, in an order that respects their dependencies.
+func (tc *TaskController) GetPlan(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	ids := c.QueryArray("task_id")
+	if len(ids) == 0 {
+		_ = c.Error(invalidQuery("at least one task_id is required"))
+		return
+	}
+	if len(ids) > Usecases.MaxTaskLimit {
+		_ = c.Error(invalidQuery("at most %d task_id values are allowed", Usecases.MaxTaskLimit))
+		return
+	}
+	plan, err := tc.uc.PlanTasks(c.Request.Context(), actor, ids)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, PlanResponse{Tasks: newTaskResponses(plan)})
+}
+
+// GetOccurrences handles GET /tasks/:id/occurrences, previewing the due
+// dates of the next count occurrences of a recurring task.
+func (tc *TaskController) GetOccurrences(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	count := Usecases.DefaultOccurrencePreview
+	if raw := c.Query("count"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > Usecases.MaxOccurrencePreview {
+			_ = c.Error(invalidQuery("count must be between 1 and %d", Usecases.MaxOccurrencePreview))
+			return
+		}
+		count = n
+	}
+	dates, err := tc.uc.PreviewOccurrences(c.Request.Context(), actor, c.Param("id"), count)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, OccurrencesResponse{Occurrences: dates})
+}
+
+// GetTrash handles GET /trash. It takes the same parameters as GET /tasks.
+func (tc *TaskController) GetTrash(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	q, err := parseTaskQuery(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	page, err := tc.uc.GetTrash(c.Request.Context(), actor, q)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, NewTaskListResponse(page))
+}
+
+// RestoreTask handles POST /tasks/:id/restore.
+func (tc *TaskController) RestoreTask(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
 		return
 	}
-	updated, err := tc.uc.UpdateTask(id, t)
+	task, err := tc.uc.RestoreTask(c.Request.Context(), actor, c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		_ = c.Error(err)
+		return
+	}
+	respondTask(c, http.StatusOK, task)
+}
+
+// PurgeTask handles DELETE /trash/:id.
+func (tc *TaskController) PurgeTask(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	if err := tc.uc.PurgeTask(c.Request.Context(), actor, c.Param("id")); err != nil {
+		_ = c.Error(err)
 		return
 	}
-	c.JSON(http.StatusOK, updated)
+	c.JSON(http.StatusOK, gin.H{"message": "purged"})
+}
+
+// GetTaskHistory handles GET /tasks/:id/history.
+func (tc *TaskController) GetTaskHistory(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	q, err := parseHistoryQuery(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	page, err := tc.uc.TaskHistory(c.Request.Context(), actor, c.Param("id"), q)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, NewHistoryListResponse(page))
+}
+
+// GetAuditLog handles GET /audit, the history of every task.
+func (tc *TaskController) GetAuditLog(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	q, err := parseHistoryQuery(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if actorID := c.Query("actor_id"); actorID != "" {
+		id, err := Domain.ParseID(actorID)
+		if err != nil {
+			_ = c.Error(invalidQuery("invalid actor_id"))
+			return
+		}
+		q.ActorID = &id
+	}
+	page, err := tc.uc.AuditLog(c.Request.Context(), actor, q)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, NewHistoryListResponse(page))
+}
+
+// parseHistoryQuery reads the paging and time range parameters shared by
+// the history endpoints.
+func parseHistoryQuery(c *gin.Context) (Domain.HistoryQuery, error) {
+	q := Domain.HistoryQuery{Cursor: c.Query("cursor")}
+	limit, err := parseLimit(c)
+	if err != nil {
+		return q, err
+	}
+	q.Limit = limit
+	for param, dst := range map[string]**time.Time{"from": &q.From, "to": &q.To} {
+		raw := c.Query(param)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return q, invalidQuery("%s must be an RFC 3339 timestamp", param)
+		}
+		*dst = &t
+	}
+	return q, nil
 }
 
 func (tc *TaskController) DeleteTask(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
 	id := c.Param("id")
-	if err := tc.uc.DeleteTask(id); err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if err := tc.uc.DeleteTask(c.Request.Context(), actor, id, version); err != nil {
+		_ = c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
@@ -78,64 +644,109 @@ func (tc *TaskController) DeleteTask(c *gin.Context) {
 
 // UserController
 type UserController struct {
-	uc     Usecases.UserUseCaseInterface
-	jwtSvc Infrastructure.JWTServiceInterface
+	uc   Usecases.UserUseCaseInterface
+	auth Usecases.AuthUseCaseInterface
 }
 
-func NewUserController(u Usecases.UserUseCaseInterface, j Infrastructure.JWTServiceInterface) *UserController {
-	return &UserController{uc: u, jwtSvc: j}
+func NewUserController(u Usecases.UserUseCaseInterface, a Usecases.AuthUseCaseInterface) *UserController {
+	return &UserController{uc: u, auth: a}
 }
 
 func (uc *UserController) RegisterUser(c *gin.Context) {
-	var body struct {
-		Name     string `json:"name"`
-		Email    string `json:"email"`
-		Password string `json:"password"`
-	}
-	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var req RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(invalidBody(err))
 		return
 	}
 	// pass three primitives, not a Domain.User
-	if err := uc.uc.RegisterUser(body.Name, body.Email, body.Password); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := uc.uc.RegisterUser(c.Request.Context(), req.Name, req.Email, req.Password); err != nil {
+		_ = c.Error(err)
 		return
 	}
 	c.JSON(http.StatusCreated, gin.H{"message": "user registered"})
 }
 
 func (uc *UserController) LoginUser(c *gin.Context) {
-	var body struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(invalidBody(err))
+		return
 	}
-	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	user, err := uc.uc.LoginUser(c.Request.Context(), req.Email, req.Password)
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
-	user, err := uc.uc.LoginUser(body.Email, body.Password)
+	var org Domain.ID
+	if req.OrgID != "" {
+		if org, err = Domain.ParseID(req.OrgID); err != nil {
+			_ = c.Error(err)
+			return
+		}
+	}
+	pair, err := uc.auth.IssueTokens(c.Request.Context(), user, org)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, NewTokenResponse(pair))
+}
+
+// RefreshToken handles POST /token/refresh.
+func (uc *UserController) RefreshToken(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(invalidBody(err))
 		return
 	}
-	token, err := uc.jwtSvc.GenerateToken(user.UserID, user.Role)
+	pair, err := uc.auth.Refresh(c.Request.Context(), req.RefreshToken)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	c.JSON(http.StatusOK, NewTokenResponse(pair))
 }
 
+// Logout handles POST /logout. The access token used for the request is
+// always revoked; a refresh token in the body revokes its whole family.
+func (uc *UserController) Logout(c *gin.Context) {
+	claims, ok := c.Get("token_claims")
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	var req LogoutRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			_ = c.Error(invalidBody(err))
+			return
+		}
+	}
+	if err := uc.auth.Logout(c.Request.Context(), claims.(*Domain.AccessClaims), req.RefreshToken); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+// PromoteUser handles POST /promote/:id, making a member of the caller's
+// organization an admin of it.
 func (uc *UserController) PromoteUser(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
 	id := c.Param("id")
-	objID, err := primitive.ObjectIDFromHex(id)
+	objID, err := Domain.ParseID(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID format"})
+		_ = c.Error(err)
 		return
 	}
-	if _, err := uc.uc.PromoteUser(objID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	user, err := uc.uc.PromoteUser(c.Request.Context(), actor, objID)
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "promoted"})
+	c.JSON(http.StatusOK, gin.H{"message": "promoted", "user": NewUserResponse(user, actor.OrgID)})
 }
@@ -0,0 +1,1264 @@
+package controllers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/surafelbkassa/go-task-manager/Delivery/controllers"
+	routers "github.com/surafelbkassa/go-task-manager/Delivery/router"
+	Domain "github.com/surafelbkassa/go-task-manager/Domain"
+	"github.com/surafelbkassa/go-task-manager/Infrastructure"
+	"github.com/surafelbkassa/go-task-manager/Repositories"
+	"github.com/surafelbkassa/go-task-manager/Usecases"
+)
+
+type apiClient struct {
+	t      *testing.T
+	router *gin.Engine
+	// bodies holds every response body seen, for the leak check.
+	bodies [][]byte
+	header http.Header
+	health *controllers.HealthController
+}
+
+// newTestAPI wires the real router, use cases and JWT service over the
+// in-memory repositories.
+func newTestAPI(t *testing.T) (*apiClient, *Repositories.InMemoryUserRepository) {
+	gin.SetMode(gin.TestMode)
+	users := Repositories.NewInMemoryUserRepository()
+	tokens := Repositories.NewInMemoryRefreshTokenRepository()
+	denylist := Repositories.NewInMemoryTokenDenylist()
+	jwtSvc := Infrastructure.NewJWTService("test-secret", time.Minute, denylist)
+
+	tasks := Repositories.NewInMemoryTaskRepository()
+	history := Repositories.NewInMemoryHistoryRepository()
+	notifications := Repositories.NewInMemoryNotificationRepository()
+	projects := Repositories.NewInMemoryProjectRepository()
+	orgs := Repositories.NewInMemoryOrganizationRepository()
+	auth := Usecases.NewAuthUseCase(users, tokens, denylist, jwtSvc, time.Hour)
+	health := controllers.NewHealthController(map[string]Domain.Pinger{"tasks": tasks, "users": users})
+
+	r := gin.New()
+	routers.SetupRouter(r, jwtSvc, users,
+		controllers.NewTaskController(Usecases.NewTaskUseCase(tasks,
+			Usecases.WithHistory(history),
+			Usecases.WithUsers(users),
+			Usecases.WithNotifications(notifications),
+			Usecases.WithProjects(projects),
+		)),
+		controllers.NewUserController(
+			Usecases.NewUserUseCase(users, orgs, Infrastructure.NewPasswordService()),
+			auth,
+		),
+		controllers.NewCommentController(Usecases.NewCommentUseCase(Repositories.NewInMemoryCommentRepository(), tasks, projects, users, notifications)),
+		controllers.NewNotificationController(Usecases.NewNotificationUseCase(notifications)),
+		controllers.NewProjectController(Usecases.NewProjectUseCase(projects, tasks, users)),
+		controllers.NewOrganizationController(Usecases.NewOrganizationUseCase(orgs, users), auth),
+		health,
+	)
+	return &apiClient{t: t, router: r, health: health}, users
+}
+
+func (a *apiClient) do(method, path, token string, body interface{}) (int, map[string]interface{}) {
+	a.t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		require.NoError(a.t, json.NewEncoder(&buf).Encode(body))
+	}
+	return a.doRaw(method, path, token, "application/json", buf.String())
+}
+
+// doRaw sends body verbatim with the given content type.
+func (a *apiClient) doRaw(method, path, token, contentType, body string) (int, map[string]interface{}) {
+	a.t.Helper()
+	return a.doWithHeaders(method, path, token, body, http.Header{"Content-Type": {contentType}})
+}
+
+// doWithHeaders sends a JSON body with extra request headers. Responses
+// without a body, such as 304, decode to a nil map.
+func (a *apiClient) doWithHeaders(method, path, token, body string, header http.Header) (int, map[string]interface{}) {
+	a.t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	a.router.ServeHTTP(w, req)
+	a.bodies = append(a.bodies, w.Body.Bytes())
+	a.header = w.Header()
+
+	var out map[string]interface{}
+	if w.Body.Len() > 0 {
+		require.NoError(a.t, json.Unmarshal(w.Body.Bytes(), &out), w.Body.String())
+	}
+	return w.Code, out
+}
+
+// containsKey reports whether key appears at any depth of a decoded JSON value.
+func containsKey(v interface{}, key string) bool {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if k == key || containsKey(child, key) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if containsKey(child, key) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// unscoped reaches the users of every organization, for test setup.
+var unscoped = Domain.WithoutTenant(context.Background())
+
+// joinOrganization makes the user registered with email a member of org,
+// for tests where several users work on the same data.
+func joinOrganization(t *testing.T, users *Repositories.InMemoryUserRepository, email string, org Domain.ID) {
+	t.Helper()
+	user, err := users.GetByEmail(unscoped, email)
+	require.NoError(t, err)
+	_, err = users.AddOrganization(unscoped, user.UserID, org)
+	require.NoError(t, err)
+}
+
+func TestResponsesNeverExposePasswords(t *testing.T) {
+	api, users := newTestAPI(t)
+
+	creds := map[string]string{"name": "Ada", "email": "ada@example.com", "password": "s3cret-pass"}
+	code, _ := api.do(http.MethodPost, "/register", "", creds)
+	require.Equal(t, http.StatusCreated, code)
+	code, _ = api.do(http.MethodPost, "/register", "", map[string]string{
+		"name": "Bob", "email": "bob@example.com", "password": "other-pass",
+	})
+	require.Equal(t, http.StatusCreated, code)
+
+	ada, err := users.GetByEmail(unscoped, "ada@example.com")
+	require.NoError(t, err)
+	_, err = users.PromoteUser(unscoped, ada.UserID, ada.UserID)
+	require.NoError(t, err)
+	bob, err := users.GetByEmail(unscoped, "bob@example.com")
+	require.NoError(t, err)
+
+	code, login := api.do(http.MethodPost, "/login", "", creds)
+	require.Equal(t, http.StatusOK, code)
+	code, login = api.do(http.MethodPost, "/token/refresh", "", map[string]string{"refresh_token": login["refresh_token"].(string)})
+	require.Equal(t, http.StatusOK, code)
+	token := login["token"].(string)
+
+	code, task := api.do(http.MethodPost, "/tasks", token, map[string]string{"title": "write docs"})
+	require.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, ada.UserID.String(), task["owner_id"])
+	id := task["id"].(string)
+	require.NotEmpty(t, id)
+
+	code, _ = api.do(http.MethodGet, "/tasks", token, nil)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = api.do(http.MethodGet, "/tasks/"+id, token, nil)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = api.do(http.MethodPut, "/tasks/"+id, token, map[string]string{"title": "write more docs"})
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = api.do(http.MethodPatch, "/tasks/"+id, token, map[string]string{"description": "patched"})
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = api.do(http.MethodPost, "/tasks/"+id+"/transition", token, map[string]string{"status": "In Progress"})
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = api.do(http.MethodDelete, "/tasks/"+id, token, nil)
+	assert.Equal(t, http.StatusOK, code)
+
+	// admins only reach users of their active organization
+	code, _ = api.do(http.MethodPost, "/promote/"+bob.UserID.String(), token, nil)
+	assert.Equal(t, http.StatusNotFound, code)
+	org := "/organizations/" + ada.UserID.String()
+	code, _ = api.do(http.MethodPost, org+"/members", token, map[string]string{"email": "bob@example.com"})
+	require.Equal(t, http.StatusOK, code)
+	code, _ = api.do(http.MethodGet, org+"/members", token, nil)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = api.do(http.MethodGet, "/organizations", token, nil)
+	assert.Equal(t, http.StatusOK, code)
+
+	code, promoted := api.do(http.MethodPost, "/promote/"+bob.UserID.String(), token, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "admin", promoted["user"].(map[string]interface{})["role"])
+
+	code, _ = api.do(http.MethodPost, "/logout", token, nil)
+	assert.Equal(t, http.StatusOK, code)
+
+	for _, body := range api.bodies {
+		var decoded interface{}
+		require.NoError(t, json.Unmarshal(body, &decoded))
+		assert.False(t, containsKey(decoded, "password"), "response exposes a password field: %s", body)
+		assert.NotContains(t, string(body), ada.Password)
+		assert.NotContains(t, string(body), bob.Password)
+	}
+}
+
+func TestAdminRoleIsPerOrganization(t *testing.T) {
+	api, users := newTestAPI(t)
+	team := Domain.NewID()
+	login := func(email string, org Domain.ID) string {
+		code, tokens := api.do(http.MethodPost, "/login", "", map[string]string{"email": email, "password": "s3cret-pass", "org_id": org.String()})
+		require.Equal(t, http.StatusOK, code)
+		return tokens["token"].(string)
+	}
+	for _, name := range []string{"ada", "bob"} {
+		code, _ := api.do(http.MethodPost, "/register", "", map[string]string{"name": name, "email": name + "@example.com", "password": "s3cret-pass"})
+		require.Equal(t, http.StatusCreated, code)
+		joinOrganization(t, users, name+"@example.com", team)
+	}
+	ada, err := users.GetByEmail(unscoped, "ada@example.com")
+	require.NoError(t, err)
+	bob, err := users.GetByEmail(unscoped, "bob@example.com")
+	require.NoError(t, err)
+	inTeam, inPersonal := login("ada@example.com", team), login("ada@example.com", ada.UserID)
+
+	code, _ := api.do(http.MethodGet, "/audit", inTeam, nil)
+	assert.Equal(t, http.StatusForbidden, code)
+	_, err = users.PromoteUser(unscoped, ada.UserID, team)
+	require.NoError(t, err)
+	code, _ = api.do(http.MethodGet, "/audit", inTeam, nil)
+	assert.Equal(t, http.StatusOK, code, "the promotion applies to tokens already issued")
+	code, _ = api.do(http.MethodGet, "/audit", inPersonal, nil)
+	assert.Equal(t, http.StatusForbidden, code, "an admin of one organization is a user in the others")
+
+	code, promoted := api.do(http.MethodPost, "/promote/"+bob.UserID.String(), inTeam, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "admin", promoted["user"].(map[string]interface{})["role"])
+	code, _ = api.do(http.MethodGet, "/audit", login("bob@example.com", bob.UserID), nil)
+	assert.Equal(t, http.StatusForbidden, code)
+	code, members := api.do(http.MethodGet, "/organizations/"+bob.UserID.String()+"/members", login("bob@example.com", bob.UserID), nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "user", members["users"].([]interface{})[0].(map[string]interface{})["role"])
+}
+
+func TestErrorsAreProblemDetails(t *testing.T) {
+	api, _ := newTestAPI(t)
+	creds := map[string]string{"name": "Ada", "email": "ada@example.com", "password": "s3cret-pass"}
+	code, _ := api.do(http.MethodPost, "/register", "", creds)
+	require.Equal(t, http.StatusCreated, code)
+	_, login := api.do(http.MethodPost, "/login", "", creds)
+	token := login["token"].(string)
+
+	cases := []struct {
+		name         string
+		method, path string
+		token        string
+		body         interface{}
+		status       int
+		code         string
+	}{
+		{"missing token", http.MethodGet, "/tasks", "", nil, http.StatusUnauthorized, "missing_token"},
+		{"malformed id", http.MethodGet, "/tasks/not-an-id", token, nil, http.StatusBadRequest, "invalid_id"},
+		{"unknown task", http.MethodGet, "/tasks/" + Domain.NewID().String(), token, nil, http.StatusNotFound, "task_not_found"},
+		{"bad query", http.MethodGet, "/tasks?limit=0", token, nil, http.StatusBadRequest, "invalid_query"},
+		{"bad sort", http.MethodGet, "/tasks?sort=owner", token, nil, http.StatusBadRequest, "invalid_sort_field"},
+		{"duplicate email", http.MethodPost, "/register", "", creds, http.StatusConflict, "email_taken"},
+		{"wrong password", http.MethodPost, "/login", "", map[string]string{"email": "ada@example.com", "password": "nope"}, http.StatusUnauthorized, "invalid_credentials"},
+		{"not admin", http.MethodPost, "/promote/" + Domain.NewID().String(), token, nil, http.StatusForbidden, "insufficient_role"},
+		{"unknown route", http.MethodGet, "/nowhere", "", nil, http.StatusNotFound, "route_not_found"},
+		{"invalid task", http.MethodPost, "/tasks", token, map[string]string{"title": ""}, http.StatusBadRequest, "validation_failed"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			code, problem := api.do(tc.method, tc.path, tc.token, tc.body)
+			assert.Equal(t, tc.status, code)
+			assert.Equal(t, "application/problem+json", api.header.Get("Content-Type"))
+			assert.Equal(t, tc.code, problem["code"])
+			assert.Equal(t, float64(tc.status), problem["status"])
+			assert.Equal(t, http.StatusText(tc.status), problem["title"])
+			assert.Equal(t, "about:blank", problem["type"])
+		})
+	}
+
+	code, task := api.do(http.MethodPost, "/tasks", token, map[string]string{"title": "t"})
+	require.Equal(t, http.StatusCreated, code)
+	code, _ = api.do(http.MethodPost, "/tasks/"+task["id"].(string)+"/transition", token, map[string]string{"status": "Cancelled"})
+	require.Equal(t, http.StatusOK, code)
+	code, problem := api.do(http.MethodPost, "/tasks/"+task["id"].(string)+"/transition", token, map[string]string{"status": "Completed"})
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, "illegal_transition", problem["code"])
+
+	code, problem = api.do(http.MethodPost, "/register", "", map[string]string{"name": "Bob", "email": "bob", "password": "x"})
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"field": "email", "message": "must be a valid email address"},
+		map[string]interface{}{"field": "password", "message": "must be at least 8 characters"},
+	}, problem["errors"])
+}
+
+func TestPatchTaskMergePatch(t *testing.T) {
+	api, _ := newTestAPI(t)
+	creds := map[string]string{"name": "Ada", "email": "ada@example.com", "password": "s3cret-pass"}
+	code, _ := api.do(http.MethodPost, "/register", "", creds)
+	require.Equal(t, http.StatusCreated, code)
+	_, login := api.do(http.MethodPost, "/login", "", creds)
+	token := login["token"].(string)
+
+	due := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	code, task := api.do(http.MethodPost, "/tasks", token, map[string]string{
+		"title": "t", "description": "d", "due_date": due,
+	})
+	require.Equal(t, http.StatusCreated, code)
+	path := "/tasks/" + task["id"].(string)
+
+	code, patched := api.doRaw(http.MethodPatch, path, token, "application/merge-patch+json",
+		`{"title": "renamed", "due_date": null, "status": "In Progress"}`)
+	require.Equal(t, http.StatusOK, code, patched)
+	assert.Equal(t, "renamed", patched["title"])
+	assert.Equal(t, "d", patched["description"], "absent members are untouched")
+	assert.Equal(t, "0001-01-01T00:00:00Z", patched["due_date"], "null clears a member")
+	assert.Equal(t, "In Progress", patched["status"])
+
+	code, problem := api.doRaw(http.MethodPatch, path, token, "application/merge-patch+json",
+		`{"owner_id": "x", "title": 5}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.ElementsMatch(t, []interface{}{
+		map[string]interface{}{"field": "owner_id", "message": "cannot be patched"},
+		map[string]interface{}{"field": "title", "message": "has the wrong type or format"},
+	}, problem["errors"])
+
+	code, problem = api.doRaw(http.MethodPatch, path, token, "application/merge-patch+json", `{"title": null}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "validation_failed", problem["code"], "the merged task is validated")
+
+	code, problem = api.doRaw(http.MethodPatch, path, token, "text/plain", `{}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "unsupported_media_type", problem["code"])
+
+	code, got := api.do(http.MethodGet, path, token, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "renamed", got["title"])
+}
+
+func TestConditionalRequests(t *testing.T) {
+	api, _ := newTestAPI(t)
+	creds := map[string]string{"name": "Ada", "email": "ada@example.com", "password": "s3cret-pass"}
+	code, _ := api.do(http.MethodPost, "/register", "", creds)
+	require.Equal(t, http.StatusCreated, code)
+	_, login := api.do(http.MethodPost, "/login", "", creds)
+	token := login["token"].(string)
+
+	code, task := api.do(http.MethodPost, "/tasks", token, map[string]string{"title": "t"})
+	require.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, `"1"`, api.header.Get("ETag"))
+	path := "/tasks/" + task["id"].(string)
+
+	code, _ = api.do(http.MethodGet, path, token, nil)
+	require.Equal(t, http.StatusOK, code)
+	etag := api.header.Get("ETag")
+	require.Equal(t, `"1"`, etag)
+
+	code, body := api.doWithHeaders(http.MethodGet, path, token, "", http.Header{"If-None-Match": {`"7", ` + etag}})
+	assert.Equal(t, http.StatusNotModified, code)
+	assert.Nil(t, body)
+	assert.Equal(t, etag, api.header.Get("ETag"))
+
+	// the first writer wins; the second still holds the old ETag
+	code, updated := api.doWithHeaders(http.MethodPut, path, token, `{"title": "first"}`, http.Header{"If-Match": {etag}})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(2), updated["version"])
+	assert.Equal(t, `"2"`, api.header.Get("ETag"))
+
+	for _, method := range []string{http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		code, problem := api.doWithHeaders(method, path, token, `{"title": "second"}`, http.Header{"If-Match": {etag}})
+		assert.Equal(t, http.StatusPreconditionFailed, code, method)
+		assert.Equal(t, "version_mismatch", problem["code"], method)
+	}
+
+	code, _ = api.doWithHeaders(http.MethodGet, path, token, "", http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusOK, code, "a stale ETag gets the current representation")
+
+	code, _ = api.doWithHeaders(http.MethodDelete, path, token, "", http.Header{"If-Match": {`"2"`}})
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestTaskHistoryAndAuditLog(t *testing.T) {
+	api, users := newTestAPI(t)
+	team := Domain.NewID()
+	login := func(email string) string {
+		creds := map[string]string{"name": "x", "email": email, "password": "s3cret-pass", "org_id": team.String()}
+		code, _ := api.do(http.MethodPost, "/register", "", creds)
+		require.Equal(t, http.StatusCreated, code)
+		joinOrganization(t, users, email, team)
+		_, tokens := api.do(http.MethodPost, "/login", "", creds)
+		return tokens["token"].(string)
+	}
+	ada, bob := login("ada@example.com"), login("bob@example.com")
+
+	code, task := api.do(http.MethodPost, "/tasks", ada, map[string]string{"title": "draft"})
+	require.Equal(t, http.StatusCreated, code)
+	path := "/tasks/" + task["id"].(string)
+	code, _ = api.do(http.MethodPatch, path, ada, map[string]string{"title": "final"})
+	require.Equal(t, http.StatusOK, code)
+	code, _ = api.do(http.MethodPost, path+"/transition", ada, map[string]string{"status": "In Progress"})
+	require.Equal(t, http.StatusOK, code)
+
+	code, history := api.do(http.MethodGet, path+"/history?limit=2", ada, nil)
+	require.Equal(t, http.StatusOK, code)
+	entries := history["entries"].([]interface{})
+	require.Len(t, entries, 2)
+	created := entries[0].(map[string]interface{})
+	assert.Equal(t, "created", created["action"])
+	assert.Equal(t, task["owner_id"], created["actor_id"])
+	assert.Contains(t, created["changes"], map[string]interface{}{"field": "title", "before": "", "after": "draft"})
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"field": "title", "before": "draft", "after": "final"},
+	}, entries[1].(map[string]interface{})["changes"])
+
+	code, history = api.do(http.MethodGet, path+"/history?cursor="+history["next_cursor"].(string), ada, nil)
+	require.Equal(t, http.StatusOK, code)
+	entries = history["entries"].([]interface{})
+	require.Len(t, entries, 1)
+	assert.Equal(t, "transitioned", entries[0].(map[string]interface{})["action"])
+	assert.Empty(t, history["next_cursor"])
+
+	code, _ = api.do(http.MethodGet, path+"/history", bob, nil)
+	assert.Equal(t, http.StatusNotFound, code, "other users cannot see the history")
+	code, _ = api.do(http.MethodGet, "/audit", bob, nil)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, _ = api.do(http.MethodDelete, path, ada, nil)
+	require.Equal(t, http.StatusOK, code)
+
+	bobUser, err := users.GetByEmail(unscoped, "bob@example.com")
+	require.NoError(t, err)
+	_, err = users.PromoteUser(unscoped, bobUser.UserID, team)
+	require.NoError(t, err)
+	_, tokens := api.do(http.MethodPost, "/login", "", map[string]string{"email": "bob@example.com", "password": "s3cret-pass", "org_id": team.String()})
+	admin := tokens["token"].(string)
+
+	code, audit := api.do(http.MethodGet, "/audit?actor_id="+task["owner_id"].(string), admin, nil)
+	require.Equal(t, http.StatusOK, code)
+	entries = audit["entries"].([]interface{})
+	require.Len(t, entries, 4, "the deletion is audited too")
+	assert.Equal(t, "deleted", entries[3].(map[string]interface{})["action"])
+
+	code, audit = api.do(http.MethodGet, "/audit?actor_id="+bobUser.UserID.String(), admin, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Empty(t, audit["entries"])
+	code, _ = api.do(http.MethodGet, "/audit?from=yesterday", admin, nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestTrashRestoreAndPurge(t *testing.T) {
+	api, users := newTestAPI(t)
+	creds := map[string]string{"name": "Ada", "email": "ada@example.com", "password": "s3cret-pass"}
+	code, _ := api.do(http.MethodPost, "/register", "", creds)
+	require.Equal(t, http.StatusCreated, code)
+	_, login := api.do(http.MethodPost, "/login", "", creds)
+	token := login["token"].(string)
+
+	code, task := api.do(http.MethodPost, "/tasks", token, map[string]string{"title": "t"})
+	require.Equal(t, http.StatusCreated, code)
+	id := task["id"].(string)
+	code, _ = api.do(http.MethodDelete, "/tasks/"+id, token, nil)
+	require.Equal(t, http.StatusOK, code)
+
+	code, _ = api.do(http.MethodGet, "/tasks/"+id, token, nil)
+	assert.Equal(t, http.StatusNotFound, code)
+	_, list := api.do(http.MethodGet, "/tasks", token, nil)
+	assert.Empty(t, list["tasks"])
+	code, trash := api.do(http.MethodGet, "/trash", token, nil)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, trash["tasks"], 1)
+	assert.NotEmpty(t, trash["tasks"].([]interface{})[0].(map[string]interface{})["deleted_at"])
+
+	code, restored := api.do(http.MethodPost, "/tasks/"+id+"/restore", token, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Nil(t, restored["deleted_at"])
+	code, problem := api.do(http.MethodPost, "/tasks/"+id+"/restore", token, nil)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, "task_not_in_trash", problem["code"])
+
+	code, _ = api.do(http.MethodDelete, "/tasks/"+id, token, nil)
+	require.Equal(t, http.StatusOK, code)
+	code, _ = api.do(http.MethodDelete, "/trash/"+id, token, nil)
+	assert.Equal(t, http.StatusForbidden, code, "only admins purge")
+
+	ada, err := users.GetByEmail(unscoped, "ada@example.com")
+	require.NoError(t, err)
+	_, err = users.PromoteUser(unscoped, ada.UserID, ada.UserID)
+	require.NoError(t, err)
+	_, login = api.do(http.MethodPost, "/login", "", creds)
+	admin := login["token"].(string)
+
+	code, _ = api.do(http.MethodDelete, "/trash/"+id, admin, nil)
+	require.Equal(t, http.StatusOK, code)
+	code, _ = api.do(http.MethodPost, "/tasks/"+id+"/restore", admin, nil)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	_, audit := api.do(http.MethodGet, "/audit", admin, nil)
+	var actions []interface{}
+	for _, e := range audit["entries"].([]interface{}) {
+		actions = append(actions, e.(map[string]interface{})["action"])
+	}
+	assert.Equal(t, []interface{}{"created", "deleted", "restored", "deleted", "purged"}, actions)
+}
+
+func TestSubtasksAndTree(t *testing.T) {
+	api, _ := newTestAPI(t)
+	creds := map[string]string{"name": "Ada", "email": "ada@example.com", "password": "s3cret-pass"}
+	code, _ := api.do(http.MethodPost, "/register", "", creds)
+	require.Equal(t, http.StatusCreated, code)
+	_, login := api.do(http.MethodPost, "/login", "", creds)
+	token := login["token"].(string)
+
+	_, parent := api.do(http.MethodPost, "/tasks", token, map[string]string{"title": "release"})
+	parentID := parent["id"].(string)
+	code, child := api.do(http.MethodPost, "/tasks", token, map[string]string{"title": "changelog", "parent_id": parentID})
+	require.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, parentID, child["parent_id"])
+	childID := child["id"].(string)
+	_, grandchild := api.do(http.MethodPost, "/tasks", token, map[string]string{"title": "draft", "parent_id": childID})
+	grandchildID := grandchild["id"].(string)
+
+	code, subtasks := api.do(http.MethodGet, "/tasks/"+parentID+"/subtasks", token, nil)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, subtasks["tasks"], 1)
+	assert.Equal(t, childID, subtasks["tasks"].([]interface{})[0].(map[string]interface{})["id"])
+
+	code, problem := api.doRaw(http.MethodPatch, "/tasks/"+parentID, token, "application/merge-patch+json",
+		`{"parent_id": "`+grandchildID+`"}`)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, "task_cycle", problem["code"])
+
+	code, problem = api.do(http.MethodPost, "/tasks/"+parentID+"/transition", token, map[string]string{"status": "Completed"})
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, "open_subtasks", problem["code"])
+
+	code, _ = api.do(http.MethodPost, "/tasks/"+grandchildID+"/transition", token, map[string]string{"status": "Completed"})
+	require.Equal(t, http.StatusOK, code)
+	code, tree := api.do(http.MethodGet, "/tasks/"+parentID+"/tree?depth=1", token, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 100.0, tree["progress"])
+	sub := tree["subtasks"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, childID, sub["id"])
+	assert.Equal(t, 100.0, sub["progress"])
+	assert.Equal(t, true, sub["truncated"])
+	assert.Empty(t, sub["subtasks"])
+
+	code, _ = api.do(http.MethodGet, "/tasks/"+parentID+"/tree?depth=0", token, nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// detaching the subtask leaves the parent without children
+	code, _ = api.doRaw(http.MethodPatch, "/tasks/"+childID, token, "application/merge-patch+json", `{"parent_id": null}`)
+	require.Equal(t, http.StatusOK, code)
+	_, subtasks = api.do(http.MethodGet, "/tasks/"+parentID+"/subtasks", token, nil)
+	assert.Empty(t, subtasks["tasks"])
+}
+
+func TestDependenciesAndPlan(t *testing.T) {
+	api, _ := newTestAPI(t)
+	creds := map[string]string{"name": "Ada", "email": "ada@example.com", "password": "s3cret-pass"}
+	code, _ := api.do(http.MethodPost, "/register", "", creds)
+	require.Equal(t, http.StatusCreated, code)
+	_, login := api.do(http.MethodPost, "/login", "", creds)
+	token := login["token"].(string)
+
+	create := func(title string) string {
+		_, task := api.do(http.MethodPost, "/tasks", token, map[string]string{"title": title})
+		return task["id"].(string)
+	}
+	build, test, ship := create("build"), create("test"), create("ship")
+
+	code, task := api.do(http.MethodPost, "/tasks/"+ship+"/dependencies", token, map[string]string{"task_id": test})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Blocked", task["status"])
+	assert.Equal(t, []interface{}{test}, task["depends_on"])
+	code, _ = api.do(http.MethodPost, "/tasks/"+test+"/dependencies", token, map[string]string{"task_id": build})
+	require.Equal(t, http.StatusOK, code)
+
+	code, problem := api.do(http.MethodPost, "/tasks/"+build+"/dependencies", token, map[string]string{"task_id": ship})
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, "dependency_cycle", problem["code"])
+	code, problem = api.do(http.MethodPost, "/tasks/"+test+"/transition", token, map[string]string{"status": "In Progress"})
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, "blocked_by_dependencies", problem["code"])
+
+	code, deps := api.do(http.MethodGet, "/tasks/"+test+"/dependencies", token, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, build, deps["blocked_by"].([]interface{})[0].(map[string]interface{})["id"])
+	assert.Equal(t, ship, deps["blocking"].([]interface{})[0].(map[string]interface{})["id"])
+
+	code, plan := api.do(http.MethodGet, "/plan?task_id="+ship, token, nil)
+	require.Equal(t, http.StatusOK, code)
+	var order []interface{}
+	for _, step := range plan["tasks"].([]interface{}) {
+		order = append(order, step.(map[string]interface{})["id"])
+	}
+	assert.Equal(t, []interface{}{build, test, ship}, order)
+
+	// finishing build releases test, but ship still waits for test
+	code, _ = api.do(http.MethodPost, "/tasks/"+build+"/transition", token, map[string]string{"status": "Completed"})
+	require.Equal(t, http.StatusOK, code)
+	_, task = api.do(http.MethodGet, "/tasks/"+test, token, nil)
+	assert.Equal(t, "Pending", task["status"])
+	_, task = api.do(http.MethodGet, "/tasks/"+ship, token, nil)
+	assert.Equal(t, "Blocked", task["status"])
+
+	code, task = api.do(http.MethodDelete, "/tasks/"+ship+"/dependencies/"+test, token, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Pending", task["status"])
+	assert.Nil(t, task["depends_on"])
+	code, problem = api.do(http.MethodDelete, "/tasks/"+ship+"/dependencies/"+test, token, nil)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, "dependency_not_found", problem["code"])
+}
+
+func TestRecurringTasks(t *testing.T) {
+	api, _ := newTestAPI(t)
+	creds := map[string]string{"name": "Ada", "email": "ada@example.com", "password": "s3cret-pass"}
+	code, _ := api.do(http.MethodPost, "/register", "", creds)
+	require.Equal(t, http.StatusCreated, code)
+	_, login := api.do(http.MethodPost, "/login", "", creds)
+	token := login["token"].(string)
+
+	due := time.Now().UTC().Add(24 * time.Hour).Truncate(time.Second)
+	code, problem := api.do(http.MethodPost, "/tasks", token, map[string]string{"title": "standup", "recurrence": "FREQ=HOURLY"})
+	require.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "validation_failed", problem["code"])
+
+	code, task := api.do(http.MethodPost, "/tasks", token, map[string]string{
+		"title": "standup", "due_date": due.Format(time.RFC3339), "recurrence": "freq=daily;count=3",
+	})
+	require.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, "FREQ=DAILY;COUNT=3", task["recurrence"])
+	assert.Equal(t, float64(1), task["occurrence"])
+	id := task["id"].(string)
+
+	code, preview := api.do(http.MethodGet, "/tasks/"+id+"/occurrences?count=5", token, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []interface{}{
+		due.AddDate(0, 0, 1).Format(time.RFC3339), due.AddDate(0, 0, 2).Format(time.RFC3339),
+	}, preview["occurrences"])
+	code, problem = api.do(http.MethodGet, "/tasks/"+id+"/occurrences?count=0", token, nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "invalid_query", problem["code"])
+
+	code, task = api.do(http.MethodPost, "/tasks/"+id+"/transition", token, map[string]string{"status": "Completed"})
+	require.Equal(t, http.StatusOK, code)
+	assert.Nil(t, task["recurrence"])
+
+	_, list := api.do(http.MethodGet, "/tasks?status=Pending", token, nil)
+	tasks := list["tasks"].([]interface{})
+	require.Len(t, tasks, 1)
+	next := tasks[0].(map[string]interface{})
+	assert.Equal(t, "standup", next["title"])
+	assert.Equal(t, due.AddDate(0, 0, 1).Format(time.RFC3339), next["due_date"])
+	assert.Equal(t, float64(2), next["occurrence"])
+	assert.Equal(t, "FREQ=DAILY;COUNT=3", next["recurrence"])
+}
+
+func TestTags(t *testing.T) {
+	api, _ := newTestAPI(t)
+	creds := map[string]string{"name": "Ada", "email": "ada@example.com", "password": "s3cret-pass"}
+	code, _ := api.do(http.MethodPost, "/register", "", creds)
+	require.Equal(t, http.StatusCreated, code)
+	_, login := api.do(http.MethodPost, "/login", "", creds)
+	token := login["token"].(string)
+
+	code, task := api.do(http.MethodPost, "/tasks", token, map[string]interface{}{"title": "login bug", "tags": []string{"Bug", "UI"}})
+	require.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, []interface{}{"bug", "ui"}, task["tags"])
+	loginBug := task["id"].(string)
+	_, task = api.do(http.MethodPost, "/tasks", token, map[string]string{"title": "crash"})
+	crash := task["id"].(string)
+
+	code, task = api.do(http.MethodPost, "/tasks/"+crash+"/tags", token, map[string]interface{}{"tags": []string{"bug", "Needs Triage"}})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []interface{}{"bug", "needs-triage"}, task["tags"])
+	code, problem := api.do(http.MethodPost, "/tasks/"+crash+"/tags", token, map[string]interface{}{"tags": []string{"a/b"}})
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "validation_failed", problem["code"])
+
+	titles := func(query string) []interface{} {
+		code, list := api.do(http.MethodGet, "/tasks?sort=title&"+query, token, nil)
+		require.Equal(t, http.StatusOK, code)
+		var out []interface{}
+		for _, task := range list["tasks"].([]interface{}) {
+			out = append(out, task.(map[string]interface{})["title"])
+		}
+		return out
+	}
+	assert.Equal(t, []interface{}{"crash", "login bug"}, titles("tag=bug"))
+	assert.Equal(t, []interface{}{"crash", "login bug"}, titles("tag=ui&tag=needs-triage"))
+	assert.Equal(t, []interface{}{"login bug"}, titles("tag=bug&tag=ui&tag_match=all"))
+	code, problem = api.do(http.MethodGet, "/tasks?tag=bug&tag_match=some", token, nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "invalid_query", problem["code"])
+
+	code, tags := api.do(http.MethodGet, "/tags", token, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "bug", "count": float64(2)},
+		map[string]interface{}{"name": "needs-triage", "count": float64(1)},
+		map[string]interface{}{"name": "ui", "count": float64(1)},
+	}, tags["tags"])
+
+	code, task = api.do(http.MethodDelete, "/tasks/"+loginBug+"/tags/UI", token, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []interface{}{"bug"}, task["tags"])
+	code, problem = api.do(http.MethodDelete, "/tasks/"+loginBug+"/tags/ui", token, nil)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, "tag_not_found", problem["code"])
+}
+
+func TestPriorityAndUrgency(t *testing.T) {
+	api, _ := newTestAPI(t)
+	creds := map[string]string{"name": "Ada", "email": "ada@example.com", "password": "s3cret-pass"}
+	code, _ := api.do(http.MethodPost, "/register", "", creds)
+	require.Equal(t, http.StatusCreated, code)
+	_, login := api.do(http.MethodPost, "/login", "", creds)
+	token := login["token"].(string)
+
+	code, problem := api.do(http.MethodPost, "/tasks", token, map[string]string{"title": "x", "priority": "urgent"})
+	require.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "validation_failed", problem["code"])
+
+	code, task := api.do(http.MethodPost, "/tasks", token, map[string]string{"title": "default"})
+	require.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, "medium", task["priority"])
+	assert.Greater(t, task["urgency"], 0.0)
+	for _, p := range []string{"Low", "critical"} {
+		code, _ = api.do(http.MethodPost, "/tasks", token, map[string]string{"title": p, "priority": p})
+		require.Equal(t, http.StatusCreated, code)
+	}
+
+	code, list := api.do(http.MethodGet, "/tasks?sort=-urgency&limit=2", token, nil)
+	require.Equal(t, http.StatusOK, code)
+	var titles []interface{}
+	for _, task := range list["tasks"].([]interface{}) {
+		titles = append(titles, task.(map[string]interface{})["title"])
+	}
+	_, list = api.do(http.MethodGet, "/tasks?sort=-urgency&limit=2&cursor="+list["next_cursor"].(string), token, nil)
+	for _, task := range list["tasks"].([]interface{}) {
+		titles = append(titles, task.(map[string]interface{})["title"])
+	}
+	assert.Equal(t, []interface{}{"critical", "default", "Low"}, titles)
+}
+
+func TestCommentsAndMentions(t *testing.T) {
+	api, users := newTestAPI(t)
+	team := Domain.NewID()
+	login := func(name, email string) string {
+		creds := map[string]string{"name": name, "email": email, "password": "s3cret-pass", "org_id": team.String()}
+		api.do(http.MethodPost, "/register", "", creds)
+		joinOrganization(t, users, email, team)
+		code, tokens := api.do(http.MethodPost, "/login", "", creds)
+		require.Equal(t, http.StatusOK, code)
+		return tokens["token"].(string)
+	}
+	ada := login("Ada", "ada@example.com")
+	bob := login("Bob", "bob@example.com")
+	carol := login("Carol", "carol@example.com")
+	carolUser, err := users.GetByEmail(unscoped, "carol@example.com")
+	require.NoError(t, err)
+
+	_, task := api.do(http.MethodPost, "/tasks", ada, map[string]interface{}{"title": "release", "watchers": []string{carolUser.UserID.String()}})
+	comments := "/tasks/" + task["id"].(string) + "/comments"
+
+	body := "@bob@example.com @carol@example.com can you sign off?"
+	code, comment := api.do(http.MethodPost, comments, ada, map[string]string{"body": body})
+	require.Equal(t, http.StatusCreated, code)
+	adaComment := comment["id"].(string)
+	assert.Equal(t, []interface{}{}, comment["edits"])
+	code, problem := api.do(http.MethodPost, comments, ada, map[string]string{"body": " "})
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "validation_failed", problem["code"])
+
+	code, list := api.do(http.MethodGet, "/notifications", bob, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Empty(t, list["notifications"], "users who cannot read the task are not notified")
+	code, _ = api.do(http.MethodGet, comments, bob, nil)
+	assert.Equal(t, http.StatusNotFound, code, "mentions do not grant access to the task")
+
+	code, list = api.do(http.MethodGet, "/notifications", carol, nil)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, list["notifications"], 1)
+	n := list["notifications"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "mentioned", n["kind"])
+	assert.Equal(t, task["id"], n["task_id"])
+	assert.Equal(t, adaComment, n["comment_id"])
+	assert.Nil(t, n["read_at"])
+
+	code, n = api.do(http.MethodPost, "/notifications/"+n["id"].(string)+"/read", carol, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.NotNil(t, n["read_at"])
+	_, list = api.do(http.MethodGet, "/notifications?unread=true", carol, nil)
+	assert.Empty(t, list["notifications"])
+	code, _ = api.do(http.MethodPost, "/notifications/"+n["id"].(string)+"/read", ada, nil)
+	assert.Equal(t, http.StatusNotFound, code, "another user's notification")
+
+	code, comment = api.do(http.MethodPut, comments+"/"+adaComment, ada, map[string]string{"body": "signed off"})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "signed off", comment["body"])
+	require.Len(t, comment["edits"], 1)
+	assert.Equal(t, body, comment["edits"].([]interface{})[0].(map[string]interface{})["body"])
+
+	bobUser, err := users.GetByEmail(unscoped, "bob@example.com")
+	require.NoError(t, err)
+	_, err = users.PromoteUser(unscoped, bobUser.UserID, team)
+	require.NoError(t, err)
+
+	code, comment = api.do(http.MethodPost, comments, bob, map[string]string{"body": "looks good"})
+	require.Equal(t, http.StatusCreated, code)
+	code, problem = api.do(http.MethodPut, comments+"/"+comment["id"].(string), ada, map[string]string{"body": "edited"})
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Equal(t, "not_comment_author", problem["code"])
+	code, _ = api.do(http.MethodDelete, comments+"/"+adaComment, bob, nil)
+	assert.Equal(t, http.StatusOK, code, "admins can delete any comment")
+
+	code, list = api.do(http.MethodGet, comments, ada, nil)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, list["comments"], 1)
+	assert.Equal(t, "looks good", list["comments"].([]interface{})[0].(map[string]interface{})["body"])
+}
+
+func TestAssigneesAndWatchers(t *testing.T) {
+	api, users := newTestAPI(t)
+	team := Domain.NewID()
+	login := func(name, email string) (string, string) {
+		creds := map[string]string{"name": name, "email": email, "password": "s3cret-pass", "org_id": team.String()}
+		api.do(http.MethodPost, "/register", "", creds)
+		joinOrganization(t, users, email, team)
+		code, tokens := api.do(http.MethodPost, "/login", "", creds)
+		require.Equal(t, http.StatusOK, code)
+		user, err := users.GetByEmail(unscoped, email)
+		require.NoError(t, err)
+		return tokens["token"].(string), user.UserID.String()
+	}
+	ada, _ := login("Ada", "ada@example.com")
+	bob, bobID := login("Bob", "bob@example.com")
+	cy, cyID := login("Cy", "cy@example.com")
+
+	code, problem := api.do(http.MethodPost, "/tasks", ada, map[string]interface{}{"title": "release", "assignees": []string{"ghost"}})
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "invalid_assignee", problem["code"])
+
+	code, task := api.do(http.MethodPost, "/tasks", ada, map[string]interface{}{
+		"title": "release", "assignees": []string{bobID, bobID}, "watchers": []string{cyID},
+	})
+	require.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, []interface{}{bobID}, task["assignees"])
+	assert.Equal(t, []interface{}{cyID}, task["watchers"])
+	id := task["id"].(string)
+
+	code, page := api.do(http.MethodGet, "/me/tasks", bob, nil)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, page["tasks"], 1)
+	_, page = api.do(http.MethodGet, "/me/tasks", ada, nil)
+	assert.Empty(t, page["tasks"])
+
+	code, _ = api.do(http.MethodPost, "/tasks/"+id+"/transition", bob, map[string]string{"status": "In Progress"})
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = api.do(http.MethodGet, "/tasks/"+id, cy, nil)
+	assert.Equal(t, http.StatusOK, code)
+	code, problem = api.do(http.MethodPost, "/tasks/"+id+"/transition", cy, map[string]string{"status": "Completed"})
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Equal(t, "task_read_only", problem["code"])
+	code, problem = api.do(http.MethodPatch, "/tasks/"+id, bob, map[string]interface{}{"assignees": []string{}})
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Equal(t, "owner_required", problem["code"])
+	code, problem = api.do(http.MethodDelete, "/tasks/"+id, bob, nil)
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Equal(t, "owner_required", problem["code"])
+
+	kinds := func(token string) []string {
+		_, list := api.do(http.MethodGet, "/notifications", token, nil)
+		var out []string
+		for _, n := range list["notifications"].([]interface{}) {
+			out = append(out, n.(map[string]interface{})["kind"].(string))
+		}
+		return out
+	}
+	assert.Equal(t, []string{"assigned"}, kinds(bob))
+	assert.Equal(t, []string{"task_changed"}, kinds(cy))
+
+	code, _ = api.do(http.MethodPatch, "/tasks/"+id, ada, map[string]interface{}{"assignees": nil})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"unassigned", "assigned"}, kinds(bob))
+	code, _ = api.do(http.MethodPost, "/tasks/"+id+"/transition", bob, map[string]string{"status": "Completed"})
+	assert.Equal(t, http.StatusNotFound, code, "former assignees lose access")
+}
+
+func TestProjects(t *testing.T) {
+	api, users := newTestAPI(t)
+	team := Domain.NewID()
+	login := func(name, email string) (string, string) {
+		creds := map[string]string{"name": name, "email": email, "password": "s3cret-pass", "org_id": team.String()}
+		api.do(http.MethodPost, "/register", "", creds)
+		joinOrganization(t, users, email, team)
+		code, tokens := api.do(http.MethodPost, "/login", "", creds)
+		require.Equal(t, http.StatusOK, code)
+		user, err := users.GetByEmail(unscoped, email)
+		require.NoError(t, err)
+		return tokens["token"].(string), user.UserID.String()
+	}
+	ada, _ := login("Ada", "ada@example.com")
+	bob, bobID := login("Bob", "bob@example.com")
+	cy, cyID := login("Cy", "cy@example.com")
+	dan, _ := login("Dan", "dan@example.com")
+
+	code, project := api.do(http.MethodPost, "/projects", ada, map[string]string{"name": "Launch"})
+	require.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, []interface{}{}, project["members"])
+	assert.Nil(t, project["archived_at"])
+	path := "/projects/" + project["id"].(string)
+
+	code, _ = api.do(http.MethodPut, path+"/members/"+bobID, ada, map[string]string{"role": "editor"})
+	require.Equal(t, http.StatusOK, code)
+	code, project = api.do(http.MethodPut, path+"/members/"+cyID, ada, map[string]string{"role": "viewer"})
+	require.Equal(t, http.StatusOK, code)
+	assert.Len(t, project["members"], 2)
+	code, problem := api.do(http.MethodPut, path+"/members/"+cyID, bob, map[string]string{"role": "maintainer"})
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Equal(t, "project_role_required", problem["code"])
+	code, _ = api.do(http.MethodGet, path, dan, nil)
+	assert.Equal(t, http.StatusNotFound, code, "non-members do not see the project")
+
+	_, list := api.do(http.MethodGet, "/projects", cy, nil)
+	require.Len(t, list["projects"], 1)
+	_, list = api.do(http.MethodGet, "/projects", dan, nil)
+	assert.Empty(t, list["projects"])
+
+	code, task := api.do(http.MethodPost, "/tasks", ada, map[string]interface{}{"title": "plan", "project_id": project["id"]})
+	require.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, project["id"], task["project_id"])
+	code, _ = api.do(http.MethodPost, "/tasks", bob, map[string]interface{}{"title": "build", "project_id": project["id"]})
+	require.Equal(t, http.StatusCreated, code)
+	code, problem = api.do(http.MethodPost, "/tasks", cy, map[string]interface{}{"title": "x", "project_id": project["id"]})
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Equal(t, "project_role_required", problem["code"])
+	code, problem = api.do(http.MethodPost, "/tasks", dan, map[string]interface{}{"title": "x", "project_id": project["id"]})
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "invalid_project", problem["code"])
+
+	code, page := api.do(http.MethodGet, path+"/tasks?sort=title", cy, nil)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, page["tasks"], 2)
+	assert.Equal(t, "build", page["tasks"].([]interface{})[0].(map[string]interface{})["title"])
+	_, page = api.do(http.MethodGet, path+"/tasks?q=pla", cy, nil)
+	assert.Len(t, page["tasks"], 1)
+	code, _ = api.do(http.MethodGet, path+"/tasks", dan, nil)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	id := task["id"].(string)
+	code, problem = api.do(http.MethodPost, "/tasks/"+id+"/transition", cy, map[string]string{"status": "In Progress"})
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Equal(t, "task_read_only", problem["code"])
+	code, _ = api.do(http.MethodPost, "/tasks/"+id+"/transition", bob, map[string]string{"status": "In Progress"})
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = api.do(http.MethodPost, "/tasks/"+id+"/comments", cy, map[string]string{"body": "nice"})
+	assert.Equal(t, http.StatusCreated, code, "viewers may comment")
+
+	code, project = api.do(http.MethodPost, path+"/archive", ada, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.NotNil(t, project["archived_at"])
+	code, problem = api.do(http.MethodPatch, "/tasks/"+id, ada, map[string]string{"title": "late"})
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, "project_archived", problem["code"])
+	code, _ = api.do(http.MethodGet, "/tasks/"+id, cy, nil)
+	assert.Equal(t, http.StatusOK, code, "archived tasks stay readable")
+	code, problem = api.do(http.MethodDelete, path, ada, nil)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, "project_not_empty", problem["code"])
+
+	code, _ = api.do(http.MethodPost, path+"/unarchive", ada, nil)
+	require.Equal(t, http.StatusOK, code)
+	code, _ = api.do(http.MethodPatch, "/tasks/"+id, bob, map[string]string{"title": "later"})
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = api.do(http.MethodDelete, path+"/members/"+bobID, bob, nil)
+	require.Equal(t, http.StatusOK, code, "members may leave")
+	code, _ = api.do(http.MethodGet, "/tasks/"+id, bob, nil)
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+// TestTenantIsolation has Ada fill the Acme organization with data of every
+// kind and checks that no endpoint lets Eve, an admin of another
+// organization, read or change any of it, and that Ada and Bob no longer
+// see it once their tokens are scoped to their personal organizations.
+func TestRemovedMemberLosesAccess(t *testing.T) {
+	api, _ := newTestAPI(t)
+	login := func(name, email string) map[string]interface{} {
+		creds := map[string]string{"name": name, "email": email, "password": "s3cret-pass"}
+		api.do(http.MethodPost, "/register", "", creds)
+		code, tokens := api.do(http.MethodPost, "/login", "", creds)
+		require.Equal(t, http.StatusOK, code)
+		return tokens
+	}
+	ada, bob := login("Ada", "ada@example.com")["token"].(string), login("Bob", "bob@example.com")
+	code, org := api.do(http.MethodPost, "/organizations", ada, map[string]string{"name": "Acme"})
+	require.Equal(t, http.StatusCreated, code)
+	acme := "/organizations/" + org["id"].(string)
+	code, member := api.do(http.MethodPost, acme+"/members", ada, map[string]string{"email": "bob@example.com"})
+	require.Equal(t, http.StatusOK, code)
+	code, inAcme := api.do(http.MethodPost, acme+"/switch", bob["token"].(string), nil)
+	require.Equal(t, http.StatusOK, code)
+	code, _ = api.do(http.MethodGet, "/tasks", inAcme["token"].(string), nil)
+	require.Equal(t, http.StatusOK, code)
+
+	code, _ = api.do(http.MethodDelete, acme+"/members/"+member["id"].(string), ada, nil)
+	require.Equal(t, http.StatusOK, code)
+	code, problem := api.do(http.MethodGet, "/tasks", inAcme["token"].(string), nil)
+	assert.Equal(t, http.StatusUnauthorized, code, "tokens issued before the removal are rejected")
+	assert.Equal(t, "invalid_token", problem["code"])
+	code, _ = api.do(http.MethodGet, "/tasks", bob["token"].(string), nil)
+	assert.Equal(t, http.StatusOK, code, "other organizations are unaffected")
+}
+
+func TestTenantIsolation(t *testing.T) {
+	api, users := newTestAPI(t)
+	login := func(name, email, org string) string {
+		creds := map[string]string{"name": name, "email": email, "password": "s3cret-pass"}
+		api.do(http.MethodPost, "/register", "", creds)
+		creds["org_id"] = org
+		code, tokens := api.do(http.MethodPost, "/login", "", creds)
+		require.Equal(t, http.StatusOK, code)
+		return tokens["token"].(string)
+	}
+	adaPersonal := login("Ada", "ada@example.com", "")
+	bobPersonal := login("Bob", "bob@example.com", "")
+	bob, err := users.GetByEmail(unscoped, "bob@example.com")
+	require.NoError(t, err)
+
+	code, org := api.do(http.MethodPost, "/organizations", adaPersonal, map[string]string{"name": "Acme"})
+	require.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, false, org["personal"])
+	acme := "/organizations/" + org["id"].(string)
+	code, _ = api.do(http.MethodPost, acme+"/members", adaPersonal, map[string]string{"email": "bob@example.com"})
+	require.Equal(t, http.StatusOK, code)
+	code, tokens := api.do(http.MethodPost, acme+"/switch", adaPersonal, nil)
+	require.Equal(t, http.StatusOK, code)
+	ada := tokens["token"].(string)
+	bobAcme := login("Bob", "bob@example.com", org["id"].(string))
+
+	// Acme's data: a project with a member, tasks with subtasks, tags, a
+	// dependency, an assignee and a recurrence, a comment mentioning Bob
+	// and a task in the trash
+	code, project := api.do(http.MethodPost, "/projects", ada, map[string]string{"name": "Launch"})
+	require.Equal(t, http.StatusCreated, code)
+	projectPath := "/projects/" + project["id"].(string)
+	code, _ = api.do(http.MethodPut, projectPath+"/members/"+bob.UserID.String(), ada, map[string]string{"role": "editor"})
+	require.Equal(t, http.StatusOK, code)
+	code, task := api.do(http.MethodPost, "/tasks", ada, map[string]interface{}{
+		"title": "release", "project_id": project["id"], "tags": []string{"launch"}, "assignees": []string{bob.UserID.String()},
+		"due_date": time.Now().Add(24 * time.Hour), "recurrence": "FREQ=WEEKLY",
+	})
+	require.Equal(t, http.StatusCreated, code)
+	taskPath := "/tasks/" + task["id"].(string)
+	code, subtask := api.do(http.MethodPost, "/tasks", ada, map[string]interface{}{"title": "notes", "parent_id": task["id"]})
+	require.Equal(t, http.StatusCreated, code)
+	code, _ = api.do(http.MethodPost, "/tasks/"+subtask["id"].(string)+"/dependencies", ada, map[string]string{"task_id": task["id"].(string)})
+	require.Equal(t, http.StatusOK, code)
+	code, comment := api.do(http.MethodPost, taskPath+"/comments", ada, map[string]string{"body": "@bob@example.com ship it"})
+	require.Equal(t, http.StatusCreated, code)
+	commentPath := taskPath + "/comments/" + comment["id"].(string)
+	code, trashed := api.do(http.MethodPost, "/tasks", ada, map[string]string{"title": "scrapped"})
+	require.Equal(t, http.StatusCreated, code)
+	trashedID := trashed["id"].(string)
+	code, _ = api.do(http.MethodDelete, "/tasks/"+trashedID, ada, nil)
+	require.Equal(t, http.StatusOK, code)
+	code, list := api.do(http.MethodGet, "/notifications", bobAcme, nil)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, list["notifications"], 2, "assigned and mentioned")
+	notification := list["notifications"].([]interface{})[0].(map[string]interface{})["id"].(string)
+
+	// Eve administers her own organization and has a task of her own there
+	login("Eve", "eve@example.com", "")
+	eveUser, err := users.GetByEmail(unscoped, "eve@example.com")
+	require.NoError(t, err)
+	_, err = users.PromoteUser(unscoped, eveUser.UserID, eveUser.UserID)
+	require.NoError(t, err)
+	eve := login("Eve", "eve@example.com", "")
+	code, own := api.do(http.MethodPost, "/tasks", eve, map[string]string{"title": "mine"})
+	require.Equal(t, http.StatusCreated, code)
+	ownPath := "/tasks/" + own["id"].(string)
+
+	// no response to Eve may mention anything of Acme's
+	secrets := []string{
+		org["id"].(string), project["id"].(string), task["id"].(string), subtask["id"].(string),
+		comment["id"].(string), trashedID, notification, bob.UserID.String(), "launch", "release",
+	}
+	cases := []struct {
+		method, path string
+		body         interface{}
+		status       int
+	}{
+		{http.MethodGet, "/tasks", nil, http.StatusOK},
+		{http.MethodGet, "/tasks?include_subtasks=true&tags=launch", nil, http.StatusOK},
+		{http.MethodGet, taskPath, nil, http.StatusNotFound},
+		{http.MethodPut, taskPath, map[string]string{"title": "mine now"}, http.StatusNotFound},
+		{http.MethodPatch, taskPath, map[string]string{"title": "mine now"}, http.StatusNotFound},
+		{http.MethodPost, taskPath + "/transition", map[string]string{"status": "Completed"}, http.StatusNotFound},
+		{http.MethodGet, taskPath + "/subtasks", nil, http.StatusNotFound},
+		{http.MethodGet, taskPath + "/tree", nil, http.StatusNotFound},
+		{http.MethodGet, "/tasks/" + subtask["id"].(string) + "/dependencies", nil, http.StatusNotFound},
+		{http.MethodPost, taskPath + "/dependencies", map[string]string{"task_id": own["id"].(string)}, http.StatusNotFound},
+		{http.MethodPost, ownPath + "/dependencies", map[string]string{"task_id": task["id"].(string)}, http.StatusBadRequest},
+		{http.MethodDelete, "/tasks/" + subtask["id"].(string) + "/dependencies/" + task["id"].(string), nil, http.StatusNotFound},
+		{http.MethodGet, "/plan?task_id=" + subtask["id"].(string), nil, http.StatusNotFound},
+		{http.MethodGet, taskPath + "/occurrences", nil, http.StatusNotFound},
+		{http.MethodPost, taskPath + "/tags", map[string][]string{"tags": {"mine"}}, http.StatusNotFound},
+		{http.MethodDelete, taskPath + "/tags/launch", nil, http.StatusNotFound},
+		{http.MethodGet, "/tags", nil, http.StatusOK},
+		{http.MethodGet, "/me/tasks", nil, http.StatusOK},
+		{http.MethodGet, taskPath + "/history", nil, http.StatusNotFound},
+		{http.MethodGet, taskPath + "/comments", nil, http.StatusNotFound},
+		{http.MethodPost, taskPath + "/comments", map[string]string{"body": "hi"}, http.StatusNotFound},
+		{http.MethodPut, commentPath, map[string]string{"body": "hi"}, http.StatusNotFound},
+		{http.MethodDelete, commentPath, nil, http.StatusNotFound},
+		{http.MethodPost, "/tasks/" + trashedID + "/restore", nil, http.StatusNotFound},
+		{http.MethodGet, "/trash", nil, http.StatusOK},
+		{http.MethodDelete, "/trash/" + trashedID, nil, http.StatusNotFound},
+		{http.MethodGet, "/audit", nil, http.StatusOK},
+		{http.MethodGet, "/audit?actor_id=" + task["owner_id"].(string), nil, http.StatusOK},
+		{http.MethodGet, "/notifications", nil, http.StatusOK},
+		{http.MethodPost, "/notifications/" + notification + "/read", nil, http.StatusNotFound},
+		{http.MethodGet, "/projects", nil, http.StatusOK},
+		{http.MethodGet, projectPath, nil, http.StatusNotFound},
+		{http.MethodPut, projectPath, map[string]string{"name": "mine"}, http.StatusNotFound},
+		{http.MethodDelete, projectPath, nil, http.StatusNotFound},
+		{http.MethodPost, projectPath + "/archive", nil, http.StatusNotFound},
+		{http.MethodPost, projectPath + "/unarchive", nil, http.StatusNotFound},
+		{http.MethodPut, projectPath + "/members/" + eveUser.UserID.String(), map[string]string{"role": "maintainer"}, http.StatusNotFound},
+		{http.MethodDelete, projectPath + "/members/" + bob.UserID.String(), nil, http.StatusNotFound},
+		{http.MethodGet, projectPath + "/tasks", nil, http.StatusNotFound},
+		{http.MethodPost, "/tasks", map[string]interface{}{"title": "x", "project_id": project["id"]}, http.StatusBadRequest},
+		{http.MethodPost, "/tasks", map[string]interface{}{"title": "x", "parent_id": task["id"]}, http.StatusBadRequest},
+		{http.MethodPost, "/tasks", map[string]interface{}{"title": "x", "assignees": []string{bob.UserID.String()}}, http.StatusBadRequest},
+		{http.MethodPost, ownPath + "/comments", map[string]string{"body": "@bob@example.com look"}, http.StatusCreated},
+		{http.MethodGet, "/organizations", nil, http.StatusOK},
+		{http.MethodGet, acme, nil, http.StatusNotFound},
+		{http.MethodGet, acme + "/members", nil, http.StatusNotFound},
+		{http.MethodPost, acme + "/members", map[string]string{"email": "eve@example.com"}, http.StatusNotFound},
+		{http.MethodDelete, acme + "/members/" + bob.UserID.String(), nil, http.StatusNotFound},
+		{http.MethodPost, acme + "/switch", nil, http.StatusNotFound},
+		{http.MethodPost, "/promote/" + bob.UserID.String(), nil, http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			api.t = t
+			code, body := api.do(tc.method, tc.path, eve, tc.body)
+			require.Equal(t, tc.status, code, body)
+			if code >= 300 {
+				return
+			}
+			raw, err := json.Marshal(body)
+			require.NoError(t, err)
+			for _, s := range secrets {
+				assert.NotContains(t, string(raw), s)
+			}
+		})
+	}
+	api.t = t
+
+	// Acme is untouched
+	code, got := api.do(http.MethodGet, taskPath, ada, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "release", got["title"])
+	code, list = api.do(http.MethodGet, "/notifications", bobAcme, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Len(t, list["notifications"], 2, "Eve's mention does not reach Bob")
+
+	// the same users see none of it from their personal organizations
+	for _, token := range []string{adaPersonal, bobPersonal} {
+		for _, path := range []string{"/tasks", "/me/tasks", "/trash", "/projects", "/notifications", "/tags"} {
+			code, body := api.do(http.MethodGet, path, token, nil)
+			require.Equal(t, http.StatusOK, code)
+			raw, err := json.Marshal(body)
+			require.NoError(t, err)
+			for _, s := range secrets[1:] {
+				assert.NotContains(t, string(raw), s, path)
+			}
+		}
+		code, _ = api.do(http.MethodGet, taskPath, token, nil)
+		assert.Equal(t, http.StatusNotFound, code)
+	}
+
+	// members may leave; Acme is then gone for Bob
+	code, _ = api.do(http.MethodDelete, acme+"/members/"+bob.UserID.String(), bobAcme, nil)
+	require.Equal(t, http.StatusOK, code)
+	code, _ = api.do(http.MethodGet, acme, bobPersonal, nil)
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+type failingPinger struct{}
+
+func (failingPinger) Ping(context.Context) error { return errors.New("connection refused") }
+
+func TestHealthProbes(t *testing.T) {
+	api, _ := newTestAPI(t)
+
+	code, body := api.do(http.MethodGet, "/healthz", "", nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", body["status"])
+
+	code, body = api.do(http.MethodGet, "/readyz", "", nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]interface{}{"tasks": "ok", "users": "ok"}, body["checks"])
+
+	api.health.Drain()
+	code, _ = api.do(http.MethodGet, "/readyz", "", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, code, "readiness fails once shutdown begins")
+	code, _ = api.do(http.MethodGet, "/healthz", "", nil)
+	assert.Equal(t, http.StatusOK, code, "liveness is unaffected by draining")
+}
+
+func TestReadyzReportsUnreachableDependency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	hc := controllers.NewHealthController(map[string]Domain.Pinger{"tasks": failingPinger{}})
+	r := gin.New()
+	r.GET("/readyz", hc.Readyz)
+	api := &apiClient{t: t, router: r}
+
+	code, body := api.do(http.MethodGet, "/readyz", "", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "unavailable", body["status"])
+	assert.Equal(t, "connection refused", body["checks"].(map[string]interface{})["tasks"])
+}
+
+// blockingTaskRepo never answers Find; only the context ends the call.
+type blockingTaskRepo struct {
+	*Repositories.InMemoryTaskRepository
+}
+
+func (blockingTaskRepo) Find(ctx context.Context, _ Domain.TaskQuery) (*Domain.TaskPage, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestQueryDeadline(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tc := controllers.NewTaskController(Usecases.NewTaskUseCase(blockingTaskRepo{Repositories.NewInMemoryTaskRepository()}))
+	uid := Domain.NewID()
+	r := gin.New()
+	r.Use(Infrastructure.ErrorMiddleware(), Infrastructure.DeadlineMiddleware(20*time.Millisecond))
+	r.GET("/tasks", func(c *gin.Context) {
+		c.Set("user_id", &uid)
+		c.Set("user_role", "user")
+	}, tc.GetTasks)
+	api := &apiClient{t: t, router: r}
+
+	start := time.Now()
+	code, problem := api.do(http.MethodGet, "/tasks", "", nil)
+	assert.Less(t, time.Since(start), time.Second, "the repository call must be cancelled")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "timeout", problem["code"])
+}
@@ -0,0 +1,562 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	Domain "github.com/surafelbkassa/go-task-manager/Domain"
+	"github.com/surafelbkassa/go-task-manager/Usecases"
+)
+
+// Request and response bodies of the HTTP API. Handlers never bind into or
+// serialize domain entities directly, so storage fields (and password
+// hashes) cannot leak and the API can evolve independently of BSON tags.
+
+type TaskRequest struct {
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	DueDate     time.Time   `json:"due_date"`
+	Status      string      `json:"status"`
+	ParentID    *string     `json:"parent_id"`
+	ProjectID   *string     `json:"project_id"`
+	Recurrence  string      `json:"recurrence"`
+	Tags        []string    `json:"tags"`
+	Priority    string      `json:"priority"`
+	Assignees   []Domain.ID `json:"assignees"`
+	Watchers    []Domain.ID `json:"watchers"`
+}
+
+func (r TaskRequest) toDomain() Domain.Task {
+	t := Domain.Task{
+		Title:       r.Title,
+		Description: r.Description,
+		DueDate:     r.DueDate,
+		Status:      Domain.TaskStatus(r.Status),
+		Recurrence:  r.Recurrence,
+		Tags:        r.Tags,
+		Priority:    Domain.Priority(r.Priority),
+		Assignees:   r.Assignees,
+		Watchers:    r.Watchers,
+	}
+	if r.ParentID != nil && *r.ParentID != "" {
+		parent := Domain.ID(*r.ParentID)
+		t.ParentID = &parent
+	}
+	if r.ProjectID != nil && *r.ProjectID != "" {
+		project := Domain.ID(*r.ProjectID)
+		t.ProjectID = &project
+	}
+	return t
+}
+
+// decodeTaskMergePatch reads an RFC 7396 merge patch of a task. Absent
+// members are left unchanged and null clears a member; members that are
+// unknown or read-only are rejected rather than ignored.
+func decodeTaskMergePatch(body []byte) (Domain.TaskPatch, error) {
+	var patch Domain.TaskPatch
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil {
+		return patch, invalidBody(err)
+	}
+	if members == nil {
+		return patch, invalidBody(errNotAnObject)
+	}
+	var fields []Domain.FieldError
+	for name, raw := range members {
+		var dst interface{}
+		switch name {
+		case "title":
+			patch.Title = new(string)
+			dst = patch.Title
+		case "description":
+			patch.Description = new(string)
+			dst = patch.Description
+		case "due_date":
+			patch.DueDate = new(time.Time)
+			dst = patch.DueDate
+		case "parent_id":
+			patch.ParentID = new(Domain.ID)
+			dst = patch.ParentID
+		case "project_id":
+			patch.ProjectID = new(Domain.ID)
+			dst = patch.ProjectID
+		case "recurrence":
+			patch.Recurrence = new(string)
+			dst = patch.Recurrence
+		case "tags":
+			patch.Tags = new([]string)
+			dst = patch.Tags
+		case "priority":
+			patch.Priority = new(Domain.Priority)
+			dst = patch.Priority
+		case "assignees":
+			patch.Assignees = new([]Domain.ID)
+			dst = patch.Assignees
+		case "watchers":
+			patch.Watchers = new([]Domain.ID)
+			dst = patch.Watchers
+		case "status":
+			if isNull(raw) {
+				fields = append(fields, Domain.FieldError{Field: name, Message: "cannot be removed"})
+				continue
+			}
+			patch.Status = new(string)
+			dst = patch.Status
+		default:
+			fields = append(fields, Domain.FieldError{Field: name, Message: "cannot be patched"})
+			continue
+		}
+		if isNull(raw) {
+			continue
+		}
+		if err := json.Unmarshal(raw, dst); err != nil {
+			fields = append(fields, Domain.FieldError{Field: name, Message: "has the wrong type or format"})
+		}
+	}
+	if len(fields) > 0 {
+		return patch, &Domain.ValidationError{Fields: fields}
+	}
+	return patch, nil
+}
+
+func isNull(raw json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+}
+
+type TransitionRequest struct {
+	Status string `json:"status" binding:"required"`
+}
+
+type TaskResponse struct {
+	ID          string     `json:"id"`
+	OwnerID     string     `json:"owner_id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	DueDate     time.Time  `json:"due_date"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	Version     int64      `json:"version"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	ParentID    string     `json:"parent_id,omitempty"`
+	ProjectID   string     `json:"project_id,omitempty"`
+	DependsOn   []string   `json:"depends_on,omitempty"`
+	Recurrence  string     `json:"recurrence,omitempty"`
+	Occurrence  int        `json:"occurrence,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	Priority    string     `json:"priority,omitempty"`
+	Urgency     float64    `json:"urgency"`
+	Assignees   []string   `json:"assignees,omitempty"`
+	Watchers    []string   `json:"watchers,omitempty"`
+}
+
+func NewTaskResponse(t *Domain.Task) TaskResponse {
+	return TaskResponse{
+		ID:          t.TaskID.String(),
+		OwnerID:     t.OwnerID.String(),
+		Title:       t.Title,
+		Description: t.Description,
+		DueDate:     t.DueDate,
+		Status:      string(t.Status),
+		CreatedAt:   t.CreatedAt,
+		UpdatedAt:   t.UpdatedAt,
+		CompletedAt: t.CompletedAt,
+		Version:     t.Version,
+		DeletedAt:   t.DeletedAt,
+		ParentID:    parentID(t),
+		ProjectID:   projectID(t),
+		DependsOn:   idStrings(t.DependsOn),
+		Recurrence:  t.Recurrence,
+		Occurrence:  t.Occurrence,
+		Tags:        t.Tags,
+		Priority:    string(t.Priority),
+		Urgency:     t.UrgencyScore,
+		Assignees:   idStrings(t.Assignees),
+		Watchers:    idStrings(t.Watchers),
+	}
+}
+
+func idStrings(ids []Domain.ID) []string {
+	if len(ids) == 0 {
+		return nil
+	}
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = id.String()
+	}
+	return s
+}
+
+func parentID(t *Domain.Task) string {
+	if t.ParentID == nil {
+		return ""
+	}
+	return t.ParentID.String()
+}
+
+func projectID(t *Domain.Task) string {
+	if t.ProjectID == nil {
+		return ""
+	}
+	return t.ProjectID.String()
+}
+
+type TaskListResponse struct {
+	Tasks      []TaskResponse `json:"tasks"`
+	NextCursor string         `json:"next_cursor"`
+}
+
+func NewTaskListResponse(p *Domain.TaskPage) TaskListResponse {
+	return TaskListResponse{Tasks: newTaskResponses(p.Tasks), NextCursor: p.NextCursor}
+}
+
+func newTaskResponses(tasks []Domain.Task) []TaskResponse {
+	resp := make([]TaskResponse, 0, len(tasks))
+	for i := range tasks {
+		resp = append(resp, NewTaskResponse(&tasks[i]))
+	}
+	return resp
+}
+
+type DependencyRequest struct {
+	TaskID string `json:"task_id" binding:"required"`
+}
+
+type DependenciesResponse struct {
+	BlockedBy []TaskResponse `json:"blocked_by"`
+	Blocking  []TaskResponse `json:"blocking"`
+}
+
+func NewDependenciesResponse(d *Usecases.TaskDependencies) DependenciesResponse {
+	return DependenciesResponse{BlockedBy: newTaskResponses(d.BlockedBy), Blocking: newTaskResponses(d.Blocking)}
+}
+
+type PlanResponse struct {
+	Tasks []TaskResponse `json:"tasks"`
+}
+
+type TagsRequest struct {
+	Tags []string `json:"tags" binding:"required"`
+}
+
+type TagCountResponse struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type TagListResponse struct {
+	Tags []TagCountResponse `json:"tags"`
+}
+
+func NewTagListResponse(counts []Domain.TagCount) TagListResponse {
+	resp := TagListResponse{Tags: make([]TagCountResponse, 0, len(counts))}
+	for _, tc := range counts {
+		resp.Tags = append(resp.Tags, TagCountResponse{Name: tc.Name, Count: tc.Count})
+	}
+	return resp
+}
+
+type OccurrencesResponse struct {
+	Occurrences []time.Time `json:"occurrences"`
+}
+
+// TaskTreeResponse is a task with its nested subtasks. Truncated marks a
+// task whose subtasks lie below the requested depth.
+type TaskTreeResponse struct {
+	TaskResponse
+	Progress  float64            `json:"progress"`
+	Truncated bool               `json:"truncated"`
+	Subtasks  []TaskTreeResponse `json:"subtasks"`
+}
+
+func NewTaskTreeResponse(n *Domain.TaskNode) TaskTreeResponse {
+	resp := TaskTreeResponse{
+		TaskResponse: NewTaskResponse(&n.Task),
+		Progress:     n.Progress,
+		Truncated:    n.Truncated,
+		Subtasks:     make([]TaskTreeResponse, 0, len(n.Children)),
+	}
+	for _, c := range n.Children {
+		resp.Subtasks = append(resp.Subtasks, NewTaskTreeResponse(c))
+	}
+	return resp
+}
+
+type HistoryEntryResponse struct {
+	ID      string               `json:"id"`
+	TaskID  string               `json:"task_id"`
+	ActorID string               `json:"actor_id"`
+	Action  string               `json:"action"`
+	At      time.Time            `json:"at"`
+	Changes []Domain.FieldChange `json:"changes"`
+}
+
+type HistoryListResponse struct {
+	Entries    []HistoryEntryResponse `json:"entries"`
+	NextCursor string                 `json:"next_cursor"`
+}
+
+func NewHistoryListResponse(p *Domain.HistoryPage) HistoryListResponse {
+	resp := HistoryListResponse{Entries: make([]HistoryEntryResponse, 0, len(p.Entries)), NextCursor: p.NextCursor}
+	for _, e := range p.Entries {
+		changes := e.Changes
+		if changes == nil {
+			changes = []Domain.FieldChange{}
+		}
+		resp.Entries = append(resp.Entries, HistoryEntryResponse{
+			ID:      e.ID.String(),
+			TaskID:  e.TaskID.String(),
+			ActorID: e.ActorID.String(),
+			Action:  string(e.Action),
+			At:      e.At,
+			Changes: changes,
+		})
+	}
+	return resp
+}
+
+type CommentRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+type CommentResponse struct {
+	ID        string               `json:"id"`
+	TaskID    string               `json:"task_id"`
+	AuthorID  string               `json:"author_id"`
+	Body      string               `json:"body"`
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
+	Edits     []Domain.CommentEdit `json:"edits"`
+}
+
+func NewCommentResponse(cm *Domain.Comment) CommentResponse {
+	edits := cm.Edits
+	if edits == nil {
+		edits = []Domain.CommentEdit{}
+	}
+	return CommentResponse{
+		ID:        cm.ID.String(),
+		TaskID:    cm.TaskID.String(),
+		AuthorID:  cm.AuthorID.String(),
+		Body:      cm.Body,
+		CreatedAt: cm.CreatedAt,
+		UpdatedAt: cm.UpdatedAt,
+		Edits:     edits,
+	}
+}
+
+type CommentListResponse struct {
+	Comments   []CommentResponse `json:"comments"`
+	NextCursor string            `json:"next_cursor"`
+}
+
+func NewCommentListResponse(p *Domain.CommentPage) CommentListResponse {
+	resp := CommentListResponse{Comments: make([]CommentResponse, 0, len(p.Comments)), NextCursor: p.NextCursor}
+	for i := range p.Comments {
+		resp.Comments = append(resp.Comments, NewCommentResponse(&p.Comments[i]))
+	}
+	return resp
+}
+
+type NotificationResponse struct {
+	ID        string     `json:"id"`
+	Kind      string     `json:"kind"`
+	TaskID    string     `json:"task_id"`
+	ActorID   string     `json:"actor_id"`
+	CommentID string     `json:"comment_id,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	ReadAt    *time.Time `json:"read_at"`
+}
+
+func NewNotificationResponse(n *Domain.Notification) NotificationResponse {
+	resp := NotificationResponse{
+		ID:        n.ID.String(),
+		Kind:      string(n.Kind),
+		TaskID:    n.TaskID.String(),
+		ActorID:   n.ActorID.String(),
+		CreatedAt: n.CreatedAt,
+		ReadAt:    n.ReadAt,
+	}
+	if n.CommentID != nil {
+		resp.CommentID = n.CommentID.String()
+	}
+	return resp
+}
+
+type NotificationListResponse struct {
+	Notifications []NotificationResponse `json:"notifications"`
+	NextCursor    string                 `json:"next_cursor"`
+}
+
+func NewNotificationListResponse(p *Domain.NotificationPage) NotificationListResponse {
+	resp := NotificationListResponse{Notifications: make([]NotificationResponse, 0, len(p.Notifications)), NextCursor: p.NextCursor}
+	for i := range p.Notifications {
+		resp.Notifications = append(resp.Notifications, NewNotificationResponse(&p.Notifications[i]))
+	}
+	return resp
+}
+
+type ProjectRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+func (r ProjectRequest) toDomain() Domain.Project {
+	return Domain.Project{Name: r.Name, Description: r.Description}
+}
+
+type MemberRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+type ProjectResponse struct {
+	ID          string                 `json:"id"`
+	OwnerID     string                 `json:"owner_id"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Members     []Domain.ProjectMember `json:"members"`
+	ArchivedAt  *time.Time             `json:"archived_at"`
+	CreatedAt   time.Time              `json:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+}
+
+func NewProjectResponse(p *Domain.Project) ProjectResponse {
+	members := p.Members
+	if members == nil {
+		members = []Domain.ProjectMember{}
+	}
+	return ProjectResponse{
+		ID:          p.ID.String(),
+		OwnerID:     p.OwnerID.String(),
+		Name:        p.Name,
+		Description: p.Description,
+		Members:     members,
+		ArchivedAt:  p.ArchivedAt,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+	}
+}
+
+type ProjectListResponse struct {
+	Projects   []ProjectResponse `json:"projects"`
+	NextCursor string            `json:"next_cursor"`
+}
+
+func NewProjectListResponse(p *Domain.ProjectPage) ProjectListResponse {
+	resp := ProjectListResponse{Projects: make([]ProjectResponse, 0, len(p.Projects)), NextCursor: p.NextCursor}
+	for i := range p.Projects {
+		resp.Projects = append(resp.Projects, NewProjectResponse(&p.Projects[i]))
+	}
+	return resp
+}
+
+type OrganizationRequest struct {
+	Name string `json:"name"`
+}
+
+type OrganizationMemberRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+type OrganizationResponse struct {
+	ID        string    `json:"id"`
+	OwnerID   string    `json:"owner_id"`
+	Name      string    `json:"name"`
+	Personal  bool      `json:"personal"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewOrganizationResponse reports o, marking it active when it is the
+// organization the caller's access token is scoped to.
+func NewOrganizationResponse(o *Domain.Organization, active Domain.ID) OrganizationResponse {
+	return OrganizationResponse{
+		ID:        o.ID.String(),
+		OwnerID:   o.OwnerID.String(),
+		Name:      o.Name,
+		Personal:  o.Personal(),
+		Active:    o.ID == active,
+		CreatedAt: o.CreatedAt,
+	}
+}
+
+type OrganizationListResponse struct {
+	Organizations []OrganizationResponse `json:"organizations"`
+}
+
+func NewOrganizationListResponse(orgs []Domain.Organization, active Domain.ID) OrganizationListResponse {
+	resp := OrganizationListResponse{Organizations: make([]OrganizationResponse, 0, len(orgs))}
+	for i := range orgs {
+		resp.Organizations = append(resp.Organizations, NewOrganizationResponse(&orgs[i], active))
+	}
+	return resp
+}
+
+type UserListResponse struct {
+	Users []UserResponse `json:"users"`
+}
+
+func NewUserListResponse(users []*Domain.User, org Domain.ID) UserListResponse {
+	resp := UserListResponse{Users: make([]UserResponse, 0, len(users))}
+	for _, u := range users {
+		resp.Users = append(resp.Users, NewUserResponse(u, org))
+	}
+	return resp
+}
+
+type RegisterRequest struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	OrgID    string `json:"org_id"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type TokenResponse struct {
+	Token            string    `json:"token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+func NewTokenResponse(p *Domain.TokenPair) TokenResponse {
+	return TokenResponse{
+		Token:            p.AccessToken,
+		ExpiresAt:        p.AccessExpiresAt,
+		RefreshToken:     p.RefreshToken,
+		RefreshExpiresAt: p.RefreshExpiresAt,
+	}
+}
+
+type UserResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewUserResponse shows u with their role in org.
+func NewUserResponse(u *Domain.User, org Domain.ID) UserResponse {
+	return UserResponse{
+		ID:        u.UserID.String(),
+		Name:      u.Name,
+		Email:     u.Email,
+		Role:      u.RoleIn(org),
+		CreatedAt: u.CreatedAt,
+	}
+}
@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	Domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+// A task's ETag is its quoted version. Versions only grow, so the tag
+// changes with every write and never repeats for the same task.
+
+var errInvalidIfMatch = Domain.NewError(Domain.ErrValidation, "invalid_precondition",
+	"If-Match must be a single ETag or *")
+
+func taskETag(t *Domain.Task) string {
+	return strconv.Quote(strconv.FormatInt(t.Version, 10))
+}
+
+// respondTask writes a single task along with its ETag.
+func respondTask(c *gin.Context, status int, t *Domain.Task) {
+	c.Header("ETag", taskETag(t))
+	c.JSON(status, NewTaskResponse(t))
+}
+
+// ifMatchVersion returns the version required by the If-Match header, or
+// 0 when the write is unconditional. Tags this server never issued, such
+// as weak ones, cannot match and fail the precondition.
+func ifMatchVersion(c *gin.Context) (int64, error) {
+	h := strings.TrimSpace(c.GetHeader("If-Match"))
+	if h == "" || h == "*" {
+		return 0, nil
+	}
+	if strings.Contains(h, ",") {
+		return 0, errInvalidIfMatch
+	}
+	s, err := strconv.Unquote(h)
+	if err != nil || strings.HasPrefix(h, "W/") {
+		return 0, Domain.ErrVersionMismatch
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || v <= 0 {
+		return 0, Domain.ErrVersionMismatch
+	}
+	return v, nil
+}
+
+// notModified reports whether If-None-Match lists the current ETag, using
+// the weak comparison RFC 9110 prescribes for it, and if so answers 304.
+func notModified(c *gin.Context, t *Domain.Task) bool {
+	h := c.GetHeader("If-None-Match")
+	if h == "" {
+		return false
+	}
+	etag := taskETag(t)
+	for _, tag := range strings.Split(h, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			c.Header("ETag", etag)
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	Domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+// readyTimeout bounds each dependency check so a hung database fails the
+// probe instead of stalling it.
+const readyTimeout = 2 * time.Second
+
+// HealthController serves the liveness and readiness probes.
+type HealthController struct {
+	checks   map[string]Domain.Pinger
+	draining atomic.Bool
+}
+
+// NewHealthController checks every named dependency on /readyz.
+func NewHealthController(checks map[string]Domain.Pinger) *HealthController {
+	return &HealthController{checks: checks}
+}
+
+// Drain makes /readyz fail from now on so load balancers stop routing new
+// requests while in-flight ones finish.
+func (hc *HealthController) Drain() {
+	hc.draining.Store(true)
+}
+
+// Healthz reports that the process is up and serving requests.
+func (hc *HealthController) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz reports whether the server can do useful work: it is not shutting
+// down and every dependency answers a ping.
+func (hc *HealthController) Readyz(c *gin.Context) {
+	if hc.draining.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "shutting down"})
+		return
+	}
+	status, code := "ok", http.StatusOK
+	results := make(map[string]string, len(hc.checks))
+	for name, p := range hc.checks {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), readyTimeout)
+		err := p.Ping(ctx)
+		cancel()
+		if err != nil {
+			status, code = "unavailable", http.StatusServiceUnavailable
+			results[name] = err.Error()
+			continue
+		}
+		results[name] = "ok"
+	}
+	c.JSON(code, gin.H{"status": status, "checks": results})
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	Domain "github.com/surafelbkassa/go-task-manager/Domain"
+	"github.com/surafelbkassa/go-task-manager/Usecases"
+)
+
+// OrganizationController serves organizations, their members and
+// switching the organization an access token is scoped to.
+type OrganizationController struct {
+	uc   Usecases.OrganizationUseCaseInterface
+	auth Usecases.AuthUseCaseInterface
+}
+
+func NewOrganizationController(u Usecases.OrganizationUseCaseInterface, a Usecases.AuthUseCaseInterface) *OrganizationController {
+	return &OrganizationController{uc: u, auth: a}
+}
+
+// GetOrganizations handles GET /organizations.
+func (oc *OrganizationController) GetOrganizations(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	orgs, err := oc.uc.GetOrganizations(c.Request.Context(), actor)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, NewOrganizationListResponse(orgs, actor.OrgID))
+}
+
+// GetOrganization handles GET /organizations/:id.
+func (oc *OrganizationController) GetOrganization(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	org, err := oc.uc.GetOrganization(c.Request.Context(), actor, c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, NewOrganizationResponse(org, actor.OrgID))
+}
+
+// CreateOrganization handles POST /organizations.
+func (oc *OrganizationController) CreateOrganization(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	var req OrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(invalidBody(err))
+		return
+	}
+	org, err := oc.uc.CreateOrganization(c.Request.Context(), actor, Domain.Organization{Name: req.Name})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, NewOrganizationResponse(org, actor.OrgID))
+}
+
+// GetMembers handles GET /organizations/:id/members.
+func (oc *OrganizationController) GetMembers(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	users, err := oc.uc.GetMembers(c.Request.Context(), actor, c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	// the use case accepted the ID
+	org, _ := Domain.ParseID(c.Param("id"))
+	c.JSON(http.StatusOK, NewUserListResponse(users, org))
+}
+
+// AddMember handles POST /organizations/:id/members.
+func (oc *OrganizationController) AddMember(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	var req OrganizationMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(invalidBody(err))
+		return
+	}
+	user, err := oc.uc.AddMember(c.Request.Context(), actor, c.Param("id"), req.Email)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	org, _ := Domain.ParseID(c.Param("id"))
+	c.JSON(http.StatusOK, NewUserResponse(user, org))
+}
+
+// RemoveMember handles DELETE /organizations/:id/members/:user_id.
+func (oc *OrganizationController) RemoveMember(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	if err := oc.uc.RemoveMember(c.Request.Context(), actor, c.Param("id"), c.Param("user_id")); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "removed"})
+}
+
+// SwitchOrganization handles POST /organizations/:id/switch. It answers
+// with a new token pair scoped to the organization; the caller's current
+// tokens stay valid for the organization they were issued for.
+func (oc *OrganizationController) SwitchOrganization(c *gin.Context) {
+	claims, ok := c.Get("token_claims")
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	org, err := Domain.ParseID(c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	pair, err := oc.auth.SwitchOrganization(c.Request.Context(), claims.(*Domain.AccessClaims), org)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, NewTokenResponse(pair))
+}
@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	Domain "github.com/surafelbkassa/go-task-manager/Domain"
+	"github.com/surafelbkassa/go-task-manager/Usecases"
+)
+
+// ProjectController serves projects and their members. The tasks of a
+// project are listed by TaskController.GetProjectTasks.
+type ProjectController struct {
+	uc Usecases.ProjectUseCaseInterface
+}
+
+func NewProjectController(u Usecases.ProjectUseCaseInterface) *ProjectController {
+	return &ProjectController{uc: u}
+}
+
+// GetProjects handles GET /projects.
+func (pc *ProjectController) GetProjects(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	q := Domain.ProjectQuery{Cursor: c.Query("cursor")}
+	limit, err := parseLimit(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	q.Limit = limit
+	if archived := c.Query("archived"); archived != "" {
+		b, err := strconv.ParseBool(archived)
+		if err != nil {
+			_ = c.Error(invalidQuery("archived must be true or false"))
+			return
+		}
+		q.Archived = &b
+	}
+	page, err := pc.uc.GetProjects(c.Request.Context(), actor, q)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, NewProjectListResponse(page))
+}
+
+// GetProject handles GET /projects/:id.
+func (pc *ProjectController) GetProject(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	project, err := pc.uc.GetProject(c.Request.Context(), actor, c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, NewProjectResponse(project))
+}
+
+// CreateProject handles POST /projects.
+func (pc *ProjectController) CreateProject(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	var req ProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(invalidBody(err))
+		return
+	}
+	project, err := pc.uc.CreateProject(c.Request.Context(), actor, req.toDomain())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, NewProjectResponse(project))
+}
+
+// UpdateProject handles PUT /projects/:id.
+func (pc *ProjectController) UpdateProject(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	var req ProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(invalidBody(err))
+		return
+	}
+	project, err := pc.uc.UpdateProject(c.Request.Context(), actor, c.Param("id"), req.toDomain())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, NewProjectResponse(project))
+}
+
+// DeleteProject handles DELETE /projects/:id.
+func (pc *ProjectController) DeleteProject(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	if err := pc.uc.DeleteProject(c.Request.Context(), actor, c.Param("id")); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// ArchiveProject handles POST /projects/:id/archive.
+func (pc *ProjectController) ArchiveProject(c *gin.Context) {
+	pc.setArchived(c, true)
+}
+
+// UnarchiveProject handles POST /projects/:id/unarchive.
+func (pc *ProjectController) UnarchiveProject(c *gin.Context) {
+	pc.setArchived(c, false)
+}
+
+func (pc *ProjectController) setArchived(c *gin.Context, archived bool) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	project, err := pc.uc.ArchiveProject(c.Request.Context(), actor, c.Param("id"), archived)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, NewProjectResponse(project))
+}
+
+// SetMember handles PUT /projects/:id/members/:user_id.
+func (pc *ProjectController) SetMember(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	var req MemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(invalidBody(err))
+		return
+	}
+	project, err := pc.uc.SetMember(c.Request.Context(), actor, c.Param("id"), c.Param("user_id"), req.Role)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, NewProjectResponse(project))
+}
+
+// RemoveMember handles DELETE /projects/:id/members/:user_id.
+func (pc *ProjectController) RemoveMember(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		_ = c.Error(errUnauthenticated)
+		return
+	}
+	project, err := pc.uc.RemoveMember(c.Request.Context(), actor, c.Param("id"), c.Param("user_id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, NewProjectResponse(project))
+}
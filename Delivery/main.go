@@ -2,53 +2,251 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/surafelbkassa/go-task-manager/Delivery/controllers"
 	routers "github.com/surafelbkassa/go-task-manager/Delivery/router"
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
 	"github.com/surafelbkassa/go-task-manager/Infrastructure"
 	"github.com/surafelbkassa/go-task-manager/Repositories"
 	"github.com/surafelbkassa/go-task-manager/Usecases"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"gopkg.in/yaml.v3"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
 )
 
 func main() {
+	cfg, opts, err := Infrastructure.LoadConfig(os.Args[1:], os.Getenv)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if opts.PrintConfig {
+		out, err := yaml.Marshal(cfg.Redacted())
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Print(string(out))
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("invalid configuration:\n%v", err)
+	}
+	if opts.PrintConfig {
+		return
+	}
+
+	if cfg.Env == "prod" {
+		gin.SetMode(gin.ReleaseMode)
+	}
 	r := gin.Default()
+	r.Use(Infrastructure.DeadlineMiddleware(time.Duration(cfg.Storage.QueryTimeout)))
 	ctx := context.Background()
 
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI("mongodb://localhost:27017"))
+	// repositories
+	repos, err := newRepositories(ctx, cfg.Storage)
 	if err != nil {
 		log.Fatal(err)
 	}
-	if err := client.Ping(ctx, nil); err != nil {
-		log.Fatal(err)
-	}
 
 	// returns the interface type
-	jwtSvc := Infrastructure.NewJWTService("secret-key", 24*time.Hour)
+	jwtSvc := Infrastructure.NewJWTService(cfg.Auth.JWTSecret, time.Duration(cfg.Auth.AccessTokenTTL), repos.denylist)
 	hasher := Infrastructure.NewPasswordService()
 
-	// repositories
-	taskRepo := Repositories.NewTaskRepository(client.Database("task_manager").Collection("tasks"))
-	userRepo := Repositories.NewUserRepository(client.Database("task_manager").Collection("users"), ctx)
+	// validated above
+	transitions, err := cfg.Tasks.Transitions.Table()
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// use‐cases
-	taskUC := Usecases.NewTaskUseCase(taskRepo)
-	userUC := Usecases.NewUserUseCase(userRepo, hasher)
+	taskUC := Usecases.NewTaskUseCase(repos.tasks,
+		Usecases.WithHistory(repos.history),
+		Usecases.WithUsers(repos.users),
+		Usecases.WithNotifications(repos.notifications),
+		Usecases.WithProjects(repos.projects),
+		Usecases.WithOpenSubtasksAllowed(cfg.Tasks.AllowOpenSubtasks),
+		Usecases.WithUrgencyWeights(cfg.Tasks.Urgency.Weights()),
+		Usecases.WithTransitions(transitions),
+	)
+	userUC := Usecases.NewUserUseCase(repos.users, repos.organizations, hasher)
+	commentUC := Usecases.NewCommentUseCase(repos.comments, repos.tasks, repos.projects, repos.users, repos.notifications)
+	projectUC := Usecases.NewProjectUseCase(repos.projects, repos.tasks, repos.users)
+	notificationUC := Usecases.NewNotificationUseCase(repos.notifications)
+	authUC := Usecases.NewAuthUseCase(repos.users, repos.refreshTokens, repos.denylist, jwtSvc, time.Duration(cfg.Auth.RefreshTokenTTL))
+	orgUC := Usecases.NewOrganizationUseCase(repos.organizations, repos.users)
 
 	// controllers
 	taskCtrl := controllers.NewTaskController(taskUC)
-	userCtrl := controllers.NewUserController(userUC, jwtSvc)
+	userCtrl := controllers.NewUserController(userUC, authUC)
+	commentCtrl := controllers.NewCommentController(commentUC)
+	notificationCtrl := controllers.NewNotificationController(notificationUC)
+	projectCtrl := controllers.NewProjectController(projectUC)
+	orgCtrl := controllers.NewOrganizationController(orgUC, authUC)
+	healthCtrl := controllers.NewHealthController(map[string]domain.Pinger{
+		"tasks": repos.tasks,
+		"users": repos.users,
+	})
 
 	// routes
-	routers.SetupRouter(r, jwtSvc, taskCtrl, userCtrl)
+	routers.SetupRouter(r, jwtSvc, repos.users, taskCtrl, userCtrl, commentCtrl, notificationCtrl, projectCtrl, orgCtrl, healthCtrl)
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if retention := time.Duration(cfg.Trash.Retention); retention > 0 {
+		go Infrastructure.RunPeriodically(ctx, "trash retention", time.Duration(cfg.Trash.PurgeInterval), func(ctx context.Context) error {
+			n, err := taskUC.PurgeExpiredTrash(domain.WithoutTenant(ctx), retention)
+			if n > 0 {
+				log.Printf("Purged %d tasks from the trash", n)
+			}
+			return err
+		})
+	}
 
-	fmt.Println("Starting server on :8080")
-	if err := r.Run(":8080"); err != nil {
+	srv := &http.Server{Addr: cfg.Server.Addr, Handler: r}
+	serveErr := make(chan error, 1)
+	go func() {
+		fmt.Println("Starting server on", cfg.Server.Addr)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
 		log.Fatal(fmt.Sprintf("Failed to start server: %v", err))
+	case <-ctx.Done():
+	}
+	stop()
+
+	// fail readiness first, then let in-flight requests finish
+	log.Println("Shutting down")
+	healthCtrl.Drain()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownTimeout))
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Forced shutdown: %v", err)
+	}
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Server error: %v", err)
+	}
+	if err := repos.close(shutdownCtx); err != nil {
+		log.Printf("Closing repositories: %v", err)
+	}
+	log.Println("Server stopped")
+}
+
+type repositories struct {
+	tasks         domain.TaskRepository
+	history       domain.HistoryRepository
+	comments      domain.CommentRepository
+	notifications domain.NotificationRepository
+	projects      domain.ProjectRepository
+	organizations domain.OrganizationRepository
+	users         domain.UserRepository
+	refreshTokens domain.RefreshTokenRepository
+	denylist      domain.TokenDenylist
+	// close releases the underlying connections.
+	close func(ctx context.Context) error
+}
+
+// newRepositories builds every repository for the chosen backend.
+func newRepositories(ctx context.Context, cfg Infrastructure.StorageConfig) (*repositories, error) {
+	switch storage := cfg.Backend; storage {
+	case "memory":
+		log.Println("Using in-memory storage; data is lost on restart")
+		return &repositories{
+			tasks:         Repositories.NewInMemoryTaskRepository(),
+			history:       Repositories.NewInMemoryHistoryRepository(),
+			comments:      Repositories.NewInMemoryCommentRepository(),
+			notifications: Repositories.NewInMemoryNotificationRepository(),
+			projects:      Repositories.NewInMemoryProjectRepository(),
+			organizations: Repositories.NewInMemoryOrganizationRepository(),
+			users:         Repositories.NewInMemoryUserRepository(),
+			refreshTokens: Repositories.NewInMemoryRefreshTokenRepository(),
+			denylist:      Repositories.NewInMemoryTokenDenylist(),
+			close:         func(context.Context) error { return nil },
+		}, nil
+	case "mongo":
+		client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.MongoURI))
+		if err != nil {
+			return nil, err
+		}
+		if err := client.Ping(ctx, nil); err != nil {
+			return nil, err
+		}
+		db := client.Database(cfg.MongoDatabase)
+		userRepo := Repositories.NewUserRepository(db.Collection("users"))
+		refreshRepo := Repositories.NewRefreshTokenRepository(db.Collection("refresh_tokens"))
+		denylist := Repositories.NewTokenDenylist(db.Collection("revoked_access_tokens"))
+		historyRepo := Repositories.NewHistoryRepository(db.Collection("task_history"))
+		commentRepo := Repositories.NewCommentRepository(db.Collection("comments"))
+		notificationRepo := Repositories.NewNotificationRepository(db.Collection("notifications"))
+		projectRepo := Repositories.NewProjectRepository(db.Collection("projects"))
+		taskRepo := Repositories.NewTaskRepository(db.Collection("tasks"))
+		orgRepo := Repositories.NewOrganizationRepository(db.Collection("organizations"))
+		for _, ensure := range []func(context.Context) error{
+			taskRepo.EnsureIndexes, userRepo.EnsureIndexes, refreshRepo.EnsureIndexes, denylist.EnsureIndexes, historyRepo.EnsureIndexes,
+			commentRepo.EnsureIndexes, notificationRepo.EnsureIndexes, projectRepo.EnsureIndexes,
+		} {
+			if err := ensure(ctx); err != nil {
+				return nil, err
+			}
+		}
+		// documents written before organizations existed join their
+		// owner's personal organization
+		if err := Repositories.MigrateTenants(ctx, db); err != nil {
+			return nil, err
+		}
+		return &repositories{
+			tasks:         taskRepo,
+			history:       historyRepo,
+			comments:      commentRepo,
+			notifications: notificationRepo,
+			projects:      projectRepo,
+			organizations: orgRepo,
+			users:         userRepo,
+			refreshTokens: refreshRepo,
+			denylist:      denylist,
+			close:         client.Disconnect,
+		}, nil
+	case "sqlite", "postgres":
+		dialect, err := Repositories.ParseSQLDialect(storage)
+		if err != nil {
+			return nil, err
+		}
+		driver := map[string]string{"sqlite": "sqlite", "postgres": "pgx"}[storage]
+		db, err := sql.Open(driver, cfg.SQLDSN)
+		if err != nil {
+			return nil, err
+		}
+		if err := db.PingContext(ctx); err != nil {
+			return nil, err
+		}
+		if err := Repositories.Migrate(ctx, db, dialect); err != nil {
+			return nil, err
+		}
+		return &repositories{
+			tasks:         Repositories.NewSQLTaskRepository(db, dialect),
+			history:       Repositories.NewSQLHistoryRepository(db, dialect),
+			comments:      Repositories.NewSQLCommentRepository(db, dialect),
+			notifications: Repositories.NewSQLNotificationRepository(db, dialect),
+			projects:      Repositories.NewSQLProjectRepository(db, dialect),
+			organizations: Repositories.NewSQLOrganizationRepository(db, dialect),
+			users:         Repositories.NewSQLUserRepository(db, dialect),
+			refreshTokens: Repositories.NewSQLRefreshTokenRepository(db, dialect),
+			denylist:      Repositories.NewSQLTokenDenylist(db, dialect),
+			close:         func(context.Context) error { return db.Close() },
+		}, nil
 	}
+	return nil, fmt.Errorf("unknown storage %q", cfg.Backend)
 }
@@ -3,6 +3,7 @@ package routers
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/surafelbkassa/go-task-manager/Delivery/controllers"
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
 	"github.com/surafelbkassa/go-task-manager/Infrastructure"
 )
 
@@ -10,18 +11,81 @@ import (
 func SetupRouter(
 	r *gin.Engine,
 	jwtSvc Infrastructure.JWTServiceInterface,
+	users domain.UserRepository,
 	taskCtrl *controllers.TaskController,
 	userCtrl *controllers.UserController,
+	commentCtrl *controllers.CommentController,
+	notificationCtrl *controllers.NotificationController,
+	projectCtrl *controllers.ProjectController,
+	orgCtrl *controllers.OrganizationController,
+	healthCtrl *controllers.HealthController,
 ) {
-	auth := Infrastructure.AuthMiddleware
+	auth := func(jwtSvc Infrastructure.JWTServiceInterface, role string) gin.HandlerFunc {
+		return Infrastructure.AuthMiddleware(jwtSvc, users, role)
+	}
+
+	// every error, including unknown routes, is rendered as problem+json
+	r.Use(Infrastructure.ErrorMiddleware())
+	r.NoRoute(func(c *gin.Context) {
+		_ = c.Error(domain.NewError(domain.ErrNotFound, "route_not_found", "no such route"))
+	})
+
+	r.GET("/healthz", healthCtrl.Healthz)
+	r.GET("/readyz", healthCtrl.Readyz)
 
 	r.GET("/tasks", auth(jwtSvc, ""), taskCtrl.GetTasks)
 	r.GET("/tasks/:id", auth(jwtSvc, "user"), taskCtrl.GetTaskById)
 	r.POST("/tasks", auth(jwtSvc, "user"), taskCtrl.CreateTask)
 	r.PUT("/tasks/:id", auth(jwtSvc, "user"), taskCtrl.UpdatedTask)
+	r.PATCH("/tasks/:id", auth(jwtSvc, "user"), taskCtrl.PatchTask)
 	r.DELETE("/tasks/:id", auth(jwtSvc, "user"), taskCtrl.DeleteTask)
+	r.POST("/tasks/:id/transition", auth(jwtSvc, "user"), taskCtrl.TransitionTask)
+	r.GET("/tasks/:id/subtasks", auth(jwtSvc, "user"), taskCtrl.GetSubtasks)
+	r.GET("/tasks/:id/tree", auth(jwtSvc, "user"), taskCtrl.GetTaskTree)
+	r.GET("/tasks/:id/dependencies", auth(jwtSvc, "user"), taskCtrl.GetDependencies)
+	r.POST("/tasks/:id/dependencies", auth(jwtSvc, "user"), taskCtrl.AddDependency)
+	r.DELETE("/tasks/:id/dependencies/:dependency_id", auth(jwtSvc, "user"), taskCtrl.RemoveDependency)
+	r.GET("/plan", auth(jwtSvc, "user"), taskCtrl.GetPlan)
+	r.GET("/tasks/:id/occurrences", auth(jwtSvc, "user"), taskCtrl.GetOccurrences)
+	r.POST("/tasks/:id/tags", auth(jwtSvc, "user"), taskCtrl.AddTags)
+	r.DELETE("/tasks/:id/tags/:tag", auth(jwtSvc, "user"), taskCtrl.RemoveTag)
+	r.GET("/tags", auth(jwtSvc, "user"), taskCtrl.GetTags)
+	r.GET("/me/tasks", auth(jwtSvc, "user"), taskCtrl.GetAssignedTasks)
+	r.GET("/tasks/:id/history", auth(jwtSvc, "user"), taskCtrl.GetTaskHistory)
+	r.GET("/tasks/:id/comments", auth(jwtSvc, "user"), commentCtrl.GetComments)
+	r.POST("/tasks/:id/comments", auth(jwtSvc, "user"), commentCtrl.AddComment)
+	r.PUT("/tasks/:id/comments/:comment_id", auth(jwtSvc, "user"), commentCtrl.UpdateComment)
+	r.DELETE("/tasks/:id/comments/:comment_id", auth(jwtSvc, "user"), commentCtrl.DeleteComment)
+	r.POST("/tasks/:id/restore", auth(jwtSvc, "user"), taskCtrl.RestoreTask)
+	r.GET("/trash", auth(jwtSvc, ""), taskCtrl.GetTrash)
+	r.DELETE("/trash/:id", auth(jwtSvc, "admin"), taskCtrl.PurgeTask)
+	r.GET("/audit", auth(jwtSvc, "admin"), taskCtrl.GetAuditLog)
+
+	r.GET("/notifications", auth(jwtSvc, "user"), notificationCtrl.GetNotifications)
+	r.POST("/notifications/:id/read", auth(jwtSvc, "user"), notificationCtrl.MarkNotificationRead)
+
+	r.GET("/projects", auth(jwtSvc, "user"), projectCtrl.GetProjects)
+	r.POST("/projects", auth(jwtSvc, "user"), projectCtrl.CreateProject)
+	r.GET("/projects/:id", auth(jwtSvc, "user"), projectCtrl.GetProject)
+	r.PUT("/projects/:id", auth(jwtSvc, "user"), projectCtrl.UpdateProject)
+	r.DELETE("/projects/:id", auth(jwtSvc, "user"), projectCtrl.DeleteProject)
+	r.POST("/projects/:id/archive", auth(jwtSvc, "user"), projectCtrl.ArchiveProject)
+	r.POST("/projects/:id/unarchive", auth(jwtSvc, "user"), projectCtrl.UnarchiveProject)
+	r.PUT("/projects/:id/members/:user_id", auth(jwtSvc, "user"), projectCtrl.SetMember)
+	r.DELETE("/projects/:id/members/:user_id", auth(jwtSvc, "user"), projectCtrl.RemoveMember)
+	r.GET("/projects/:id/tasks", auth(jwtSvc, "user"), taskCtrl.GetProjectTasks)
+
+	r.GET("/organizations", auth(jwtSvc, "user"), orgCtrl.GetOrganizations)
+	r.POST("/organizations", auth(jwtSvc, "user"), orgCtrl.CreateOrganization)
+	r.GET("/organizations/:id", auth(jwtSvc, "user"), orgCtrl.GetOrganization)
+	r.POST("/organizations/:id/switch", auth(jwtSvc, "user"), orgCtrl.SwitchOrganization)
+	r.GET("/organizations/:id/members", auth(jwtSvc, "user"), orgCtrl.GetMembers)
+	r.POST("/organizations/:id/members", auth(jwtSvc, "user"), orgCtrl.AddMember)
+	r.DELETE("/organizations/:id/members/:user_id", auth(jwtSvc, "user"), orgCtrl.RemoveMember)
 
 	r.POST("/register", userCtrl.RegisterUser)
 	r.POST("/login", userCtrl.LoginUser)
+	r.POST("/token/refresh", userCtrl.RefreshToken)
+	r.POST("/logout", auth(jwtSvc, ""), userCtrl.Logout)
 	r.POST("/promote/:id", auth(jwtSvc, "admin"), userCtrl.PromoteUser)
 }
@@ -0,0 +1,61 @@
+package domain
+
+import "sort"
+
+// Limits on the people attached to a task.
+const (
+	MaxAssignees = 20
+	MaxWatchers  = 50
+)
+
+// NormalizeIDs sorts ids and drops duplicates. An empty list is nil.
+func NormalizeIDs(ids []ID) []ID {
+	if len(ids) == 0 {
+		return nil
+	}
+	out := append([]ID(nil), ids...)
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	n := 1
+	for _, id := range out[1:] {
+		if id != out[n-1] {
+			out[n] = id
+			n++
+		}
+	}
+	return out[:n]
+}
+
+// AddedIDs lists the IDs in after that are not in before.
+func AddedIDs(before, after []ID) []ID {
+	var added []ID
+	for _, id := range after {
+		if !containsID(before, id) {
+			added = append(added, id)
+		}
+	}
+	return added
+}
+
+// SameIDs reports whether a and b hold the same IDs, in any order.
+func SameIDs(a, b []ID) bool {
+	return len(AddedIDs(a, b)) == 0 && len(AddedIDs(b, a)) == 0
+}
+
+func containsID(ids []ID, id ID) bool {
+	for _, x := range ids {
+		if x == id {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAssignee reports whether user is assigned to the task.
+func (t Task) IsAssignee(user ID) bool {
+	return containsID(t.Assignees, user)
+}
+
+// IsWatcher reports whether user watches the task.
+func (t Task) IsWatcher(user ID) bool {
+	return containsID(t.Watchers, user)
+}
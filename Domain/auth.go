@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// AccessClaims are the verified contents of an access token.
+type AccessClaims struct {
+	UserID    ID
+	Role      string
+	OrgID     ID     // the organization the token is scoped to
+	TokenID   string // jti, used to revoke a single access token
+	ExpiresAt time.Time
+}
+
+// TokenPair is what a client receives after logging in or refreshing.
+type TokenPair struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// RefreshToken is the server-side record of an issued refresh token. Only
+// a hash of the token value is stored. Every rotation creates a new token
+// in the same family, so a leaked token can be traced and its whole
+// family revoked.
+type RefreshToken struct {
+	ID         ID         `bson:"_id,omitempty"`
+	FamilyID   ID         `bson:"family_id"`
+	UserID     ID         `bson:"user_id"`
+	OrgID      ID         `bson:"org_id"`
+	TokenHash  string     `bson:"token_hash"`
+	ExpiresAt  time.Time  `bson:"expires_at"`
+	CreatedAt  time.Time  `bson:"created_at"`
+	RevokedAt  *time.Time `bson:"revoked_at,omitempty"`
+	ReplacedBy ID         `bson:"replaced_by,omitempty"`
+}
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token RefreshToken) (*RefreshToken, error)
+	GetByHash(ctx context.Context, hash string) (*RefreshToken, error)
+	// MarkRotated revokes a token in favour of its successor. It reports
+	// false when the token had already been revoked, e.g. by a concurrent
+	// refresh using the same token.
+	MarkRotated(ctx context.Context, id ID, replacedBy ID) (bool, error)
+	RevokeFamily(ctx context.Context, familyID ID) error
+}
+
+// TokenDenylist records revoked access token IDs until they expire.
+type TokenDenylist interface {
+	Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, tokenID string) (bool, error)
+}
@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"regexp"
+	"time"
+)
+
+// Comment is a remark left on a task. Editing a comment keeps every
+// earlier body in Edits.
+type Comment struct {
+	ID        ID            `bson:"_id,omitempty"`
+	OrgID     ID            `bson:"org_id"`
+	TaskID    ID            `bson:"task_id"`
+	AuthorID  ID            `bson:"author_id"`
+	Body      string        `bson:"body"`
+	CreatedAt time.Time     `bson:"created_at"`
+	UpdatedAt time.Time     `bson:"updated_at"`
+	Edits     []CommentEdit `bson:"edits,omitempty"`
+}
+
+// CommentEdit is a body a comment used to have, with the time it was
+// replaced.
+type CommentEdit struct {
+	Body     string    `bson:"body" json:"body"`
+	EditedAt time.Time `bson:"edited_at" json:"edited_at"`
+}
+
+// Edit replaces the comment's body and keeps the old one in Edits.
+func (c *Comment) Edit(body string, at time.Time) {
+	c.Edits = append(c.Edits, CommentEdit{Body: c.Body, EditedAt: at})
+	c.Body = body
+	c.UpdatedAt = at
+}
+
+// mentionPattern matches "@" followed by an email address, at the start
+// of the body or after a space or an opening bracket.
+var mentionPattern = regexp.MustCompile(`(?:^|[\s(\[])@([A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,})`)
+
+// ParseMentions returns the emails mentioned as "@email" in body, in order
+// of first appearance and without duplicates.
+func ParseMentions(body string) []string {
+	var emails []string
+	seen := map[string]bool{}
+	for _, m := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			emails = append(emails, m[1])
+		}
+	}
+	return emails
+}
+
+// CommentQuery selects the comments of a task, oldest first.
+type CommentQuery struct {
+	TaskID ID
+	Cursor string // opaque, taken from a previous CommentPage.NextCursor
+	Limit  int
+}
+
+// CommentPage is one page of a CommentQuery result.
+type CommentPage struct {
+	Comments   []Comment
+	NextCursor string // empty on the last page
+}
+
+type CommentRepository interface {
+	Create(ctx context.Context, c Comment) (*Comment, error)
+	GetByID(ctx context.Context, id ID) (*Comment, error)
+	// Update stores the body, edits and update time of the comment.
+	Update(ctx context.Context, c Comment) (*Comment, error)
+	Delete(ctx context.Context, id ID) error
+	Find(ctx context.Context, q CommentQuery) (*CommentPage, error)
+}
+
+// EncodeCommentCursor builds the opaque cursor pointing just after c. It
+// uses the task cursor format with the creation time as sort value.
+func EncodeCommentCursor(c Comment) string {
+	raw, _ := json.Marshal(TaskCursor{Value: c.CreatedAt.UTC().Format(time.RFC3339Nano), ID: c.ID})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCommentCursor returns the position encoded by EncodeCommentCursor.
+func DecodeCommentCursor(s string) (time.Time, ID, error) {
+	c, err := DecodeTaskCursor(s)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	at, err := c.TimeValue()
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	return at, c.ID, nil
+}
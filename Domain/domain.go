@@ -1,50 +1,189 @@
 package domain
 
 import (
+	"context"
 	"time"
-
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Pinger reports whether a repository's backing store is reachable.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
 // ???
 type TaskRepository interface {
-	GetAll() ([]Task, error)
-	GetByID(id primitive.ObjectID) (*Task, error)
-	Create(Task) (*Task, error)
-	Update(id primitive.ObjectID, task Task) (*Task, error)
-	Delete(id primitive.ObjectID) error
+	Pinger
+	Find(ctx context.Context, q TaskQuery) (*TaskPage, error)
+	GetByID(ctx context.Context, id ID) (*Task, error)
+	Create(ctx context.Context, task Task) (*Task, error)
+	// Update stores task only if the stored copy is still at task.Version,
+	// and bumps the version; otherwise it returns ErrVersionConflict.
+	Update(ctx context.Context, id ID, task Task) (*Task, error)
+	// Delete moves a live task to the trash by setting DeletedAt. Trashed
+	// tasks are still returned by GetByID but left out of Find unless
+	// TaskQuery.Trashed is set. Like Update it only applies while the
+	// stored task is at version, and returns ErrVersionConflict otherwise.
+	Delete(ctx context.Context, id ID, version int64) error
+	// Restore takes a task out of the trash.
+	Restore(ctx context.Context, id ID) (*Task, error)
+	// Purge removes a trashed task for good.
+	Purge(ctx context.Context, id ID) error
+	// PurgeDeletedBefore purges every task trashed before cutoff and
+	// returns them as they were last stored.
+	PurgeDeletedBefore(ctx context.Context, cutoff time.Time) ([]Task, error)
+	// CountTags tallies the tags of the live tasks of owner, or of all
+	// owners when owner is nil, ordered like the CountTags function.
+	CountTags(ctx context.Context, owner *ID) ([]TagCount, error)
 }
 
 type UserRepository interface {
-	Create(user User) (*User, error)
-	GetByID(id primitive.ObjectID) (*User, error)
-	GetByEmail(email string) (*User, error)
-	GetAll() ([]*User, error)
+	Pinger
+	Create(ctx context.Context, user User) (*User, error)
+	GetByID(ctx context.Context, id ID) (*User, error)
+	GetByEmail(ctx context.Context, email string) (*User, error)
+	GetAll(ctx context.Context) ([]*User, error)
+	// AddOrganization and RemoveOrganization change the organizations
+	// the user belongs to. Adding an organization twice keeps it once.
+	AddOrganization(ctx context.Context, id, org ID) (*User, error)
+	RemoveOrganization(ctx context.Context, id, org ID) (*User, error)
 	//what is the return []*User and *User difference?
 	// Update(id primitive.ObjectID, user User) (*User, error)
 	// Delete(id primitive.ObjectID) error
 	// Login(email, password primitive.ObjectID) (string, error)
-	PromoteUser(id primitive.ObjectID) (*User, error)
+	// PromoteUser makes the user an admin of org, which they must belong
+	// to (ErrUserNotFound otherwise). Leaving org drops the role.
+	PromoteUser(ctx context.Context, id, org ID) (*User, error)
 }
 
 // ???
 type Task struct {
-	TaskID      primitive.ObjectID `bson:"_id,omitempty"`
-	Title       string             `json:"title" bson:"title"`
-	Description string             `json:"description" bson:"description"`
-	DueDate     time.Time          `json:"due_date" bson:"due_date"`
-	Status      string             `json:"status" bson:"status"`
-	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
-	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+	TaskID ID `bson:"_id,omitempty"`
+	// OrgID is the organization the task belongs to; see WithTenant.
+	OrgID       ID         `json:"-" bson:"org_id"`
+	OwnerID     ID         `json:"owner_id" bson:"owner_id"`
+	Title       string     `json:"title" bson:"title"`
+	Description string     `json:"description" bson:"description"`
+	DueDate     time.Time  `json:"due_date" bson:"due_date"`
+	Status      TaskStatus `json:"status" bson:"status"`
+	CreatedAt   time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" bson:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+	// Version counts the writes to the task, starting at 1 on creation.
+	Version int64 `json:"version" bson:"version"`
+	// DeletedAt is set while the task is in the trash.
+	DeletedAt *time.Time `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+	// ParentID is set on subtasks and points at the task they belong to.
+	ParentID *ID `json:"parent_id,omitempty" bson:"parent_id,omitempty"`
+	// ProjectID is set on tasks that belong to a project; its members may
+	// access them according to their role.
+	ProjectID *ID `json:"project_id,omitempty" bson:"project_id,omitempty"`
+	// DependsOn lists the tasks that must be finished before this one.
+	DependsOn []ID `json:"depends_on,omitempty" bson:"depends_on,omitempty"`
+	// Recurrence is an RRULE; completing the task creates the next
+	// occurrence. Occurrence is the task's position in the series.
+	Recurrence string `json:"recurrence,omitempty" bson:"recurrence,omitempty"`
+	Occurrence int    `json:"occurrence,omitempty" bson:"occurrence,omitempty"`
+	// Tags are normalized, sorted and unique; see NormalizeTags.
+	Tags     []string `json:"tags,omitempty" bson:"tags,omitempty"`
+	Priority Priority `json:"priority,omitempty" bson:"priority,omitempty"`
+	// Assignees do the work on the task and may change it like its owner,
+	// except for deleting it. Watchers may only read it. Both are sorted
+	// and unique; see NormalizeIDs.
+	Assignees []ID `json:"assignees,omitempty" bson:"assignees,omitempty"`
+	Watchers  []ID `json:"watchers,omitempty" bson:"watchers,omitempty"`
+	// UrgencyScore is computed when the task is read and never stored;
+	// see Task.Urgency.
+	UrgencyScore float64 `json:"-" bson:"-"`
 }
 
 type User struct {
-	UserID    primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	Name      string             `json:"name" bson:"name"`
-	Email     string             `json:"email" bson:"email"`
-	Password  string             `json:"password" bson:"password"`
-	Role      string             `json:"role" bson:"role"` // e.g., "admin", "user"
-	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	UserID    ID        `json:"id" bson:"_id,omitempty"`
+	Name      string    `json:"name" bson:"name"`
+	Email     string    `json:"email" bson:"email"`
+	Password  string    `json:"-" bson:"password"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	// OrgIDs are the organizations the user belongs to, in the order
+	// they joined them.
+	OrgIDs []ID `json:"org_ids,omitempty" bson:"org_ids,omitempty"`
+	// AdminOrgIDs are the organizations among OrgIDs the user is an admin
+	// of; in the others they are a plain user. See RoleIn.
+	AdminOrgIDs []ID `json:"-" bson:"admin_org_ids,omitempty"`
+}
+
+// Actor is the authenticated caller a use case runs on behalf of.
+type Actor struct {
+	UserID ID
+	// Role is the actor's role in OrgID, e.g. "admin" or "user".
+	Role string
+	// OrgID is the organization the actor is working in.
+	OrgID ID
+}
+
+// IsAdmin reports whether the actor bypasses ownership checks. Admin is
+// a role in an organization, so it only holds within a.OrgID.
+func (a Actor) IsAdmin() bool {
+	return a.Role == "admin"
+}
+
+// CanAccess reports whether the actor may read the task: admins, its
+// owner, its assignees and its watchers may.
+func (a Actor) CanAccess(t *Task) bool {
+	return a.CanEdit(t) || t.IsWatcher(a.UserID)
+}
+
+// CanEdit reports whether the actor may update and transition the task.
+func (a Actor) CanEdit(t *Task) bool {
+	return a.Owns(t) || t.IsAssignee(a.UserID)
+}
+
+// Owns reports whether the actor owns the task or is an admin. Only they
+// may delete it or change who is assigned to or watching it.
+func (a Actor) Owns(t *Task) bool {
+	return a.IsAdmin() || t.OwnerID == a.UserID
+}
+
+// TaskAccess is what an actor may do with a task; each level includes the
+// ones below it.
+type TaskAccess int
+
+const (
+	NoAccess TaskAccess = iota
+	// ReadAccess allows reading the task.
+	ReadAccess
+	// EditAccess allows updating and transitioning it.
+	EditAccess
+	// OwnerAccess allows deleting and restoring it and changing its
+	// project, assignees and watchers.
+	OwnerAccess
+)
+
+// TaskAccess combines the actor's own rights on t with their role in its
+// project p, which is nil for a task outside any project. Maintainers
+// treat the tasks of their project as their own, editors as assignees
+// and viewers as watchers.
+func (a Actor) TaskAccess(t *Task, p *Project) TaskAccess {
+	access := NoAccess
+	switch {
+	case a.Owns(t):
+		return OwnerAccess
+	case a.CanEdit(t):
+		access = EditAccess
+	case a.CanAccess(t):
+		access = ReadAccess
+	}
+	if p == nil {
+		return access
+	}
+	role := a.ProjectRole(p)
+	switch {
+	case role.AtLeast(ProjectMaintainer):
+		return OwnerAccess
+	case role.AtLeast(ProjectEditor) && access < EditAccess:
+		return EditAccess
+	case role.AtLeast(ProjectViewer) && access < ReadAccess:
+		return ReadAccess
+	}
+	return access
 }
 
 type PasswordHasher interface {
@@ -53,6 +192,6 @@ type PasswordHasher interface {
 }
 
 type JWTService interface {
-	GenerateToken(userID primitive.ObjectID, role string) (string, error)
-	ValidateToken(token string) (*primitive.ObjectID, string, error)
+	GenerateToken(userID ID, role string, org ID) (string, time.Time, error)
+	ValidateToken(ctx context.Context, token string) (*AccessClaims, error)
 }
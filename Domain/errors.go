@@ -0,0 +1,65 @@
+package domain
+
+import "errors"
+
+// Error kinds. Every expected failure wraps exactly one of them, which is
+// all the delivery layer needs to pick a status code.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrInvalidID    = errors.New("invalid id")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrForbidden    = errors.New("forbidden")
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrPrecondition means a conditional request (If-Match) no longer
+	// holds.
+	ErrPrecondition = errors.New("precondition failed")
+)
+
+// Error is a failure of a given kind carrying a stable, machine-readable
+// code for API clients. errors.Is matches both the error itself and its kind.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+}
+
+func NewError(kind error, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// Errors shared by every repository implementation so callers can rely on
+// the same semantics whichever backend is configured.
+var (
+	ErrTaskNotFound   = NewError(ErrNotFound, "task_not_found", "task not found")
+	ErrUserNotFound   = NewError(ErrNotFound, "user_not_found", "user not found")
+	ErrDuplicateEmail = NewError(ErrConflict, "email_taken", "email already registered")
+	// ErrVersionConflict is returned by TaskRepository.Update and Delete
+	// when the stored task is no longer at the version the write was
+	// based on.
+	ErrVersionConflict = NewError(ErrConflict, "version_conflict", "task was modified concurrently")
+	// ErrVersionMismatch rejects a write whose expected version is stale.
+	ErrVersionMismatch = NewError(ErrPrecondition, "version_mismatch", "task has been modified since the given version")
+	// ErrTaskNotInTrash is returned by Restore and Purge for live tasks.
+	ErrTaskNotInTrash = NewError(ErrConflict, "task_not_in_trash", "task is not in the trash")
+
+	ErrRefreshTokenNotFound = NewError(ErrNotFound, "refresh_token_not_found", "refresh token not found")
+	ErrCommentNotFound      = NewError(ErrNotFound, "comment_not_found", "comment not found")
+	ErrNotificationNotFound = NewError(ErrNotFound, "notification_not_found", "notification not found")
+	ErrProjectNotFound      = NewError(ErrNotFound, "project_not_found", "project not found")
+	ErrOrganizationNotFound = NewError(ErrNotFound, "organization_not_found", "organization not found")
+	// ErrOrganizationExists is returned by OrganizationRepository.Create
+	// for an ID already in use.
+	ErrOrganizationExists = NewError(ErrConflict, "organization_exists", "organization already exists")
+	// ErrNoTenant is returned for a record created from a context that
+	// has no tenant; see TenantFrom.
+	ErrNoTenant = errors.New("context has no tenant")
+)
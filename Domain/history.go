@@ -0,0 +1,142 @@
+package domain
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// HistoryAction is the kind of change a history entry records.
+type HistoryAction string
+
+const (
+	HistoryCreated      HistoryAction = "created"
+	HistoryUpdated      HistoryAction = "updated"
+	HistoryTransitioned HistoryAction = "transitioned"
+	HistoryDeleted      HistoryAction = "deleted"
+	HistoryRestored     HistoryAction = "restored"
+	HistoryPurged       HistoryAction = "purged"
+)
+
+// HistoryEntry is an immutable record of one change to a task: who made
+// it, when, and the before and after value of every field it touched.
+type HistoryEntry struct {
+	ID      ID            `bson:"_id,omitempty"`
+	OrgID   ID            `bson:"org_id"`
+	TaskID  ID            `bson:"task_id"`
+	ActorID ID            `bson:"actor_id"`
+	Action  HistoryAction `bson:"action"`
+	At      time.Time     `bson:"at"`
+	Changes []FieldChange `bson:"changes"`
+}
+
+// FieldChange is the old and new value of a field, rendered as text. An
+// empty string stands for an unset value.
+type FieldChange struct {
+	Field  string `bson:"field" json:"field"`
+	Before string `bson:"before" json:"before"`
+	After  string `bson:"after" json:"after"`
+}
+
+// DiffTasks lists the user-visible fields that differ between before and
+// after. Creation and purging diff against a zero Task.
+func DiffTasks(before, after Task) []FieldChange {
+	changes := []FieldChange{}
+	for _, f := range []struct {
+		name          string
+		before, after string
+	}{
+		{"title", before.Title, after.Title},
+		{"description", before.Description, after.Description},
+		{"due_date", formatHistoryTime(before.DueDate), formatHistoryTime(after.DueDate)},
+		{"status", string(before.Status), string(after.Status)},
+		{"completed_at", formatHistoryTimePtr(before.CompletedAt), formatHistoryTimePtr(after.CompletedAt)},
+		{"deleted_at", formatHistoryTimePtr(before.DeletedAt), formatHistoryTimePtr(after.DeletedAt)},
+		{"parent_id", formatHistoryID(before.ParentID), formatHistoryID(after.ParentID)},
+		{"project_id", formatHistoryID(before.ProjectID), formatHistoryID(after.ProjectID)},
+		{"depends_on", formatHistoryIDs(before.DependsOn), formatHistoryIDs(after.DependsOn)},
+		{"recurrence", before.Recurrence, after.Recurrence},
+		{"tags", strings.Join(before.Tags, ","), strings.Join(after.Tags, ",")},
+		{"priority", string(before.Priority), string(after.Priority)},
+		{"assignees", formatHistoryIDs(before.Assignees), formatHistoryIDs(after.Assignees)},
+		{"watchers", formatHistoryIDs(before.Watchers), formatHistoryIDs(after.Watchers)},
+	} {
+		if f.before != f.after {
+			changes = append(changes, FieldChange{Field: f.name, Before: f.before, After: f.after})
+		}
+	}
+	return changes
+}
+
+func formatHistoryTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func formatHistoryTimePtr(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return formatHistoryTime(*t)
+}
+
+func formatHistoryID(id *ID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+func formatHistoryIDs(ids []ID) string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = id.String()
+	}
+	return strings.Join(s, ",")
+}
+
+// HistoryQuery selects history entries, oldest first. Zero-valued fields
+// do not filter; the time range is inclusive.
+type HistoryQuery struct {
+	TaskID   *ID
+	ActorID  *ID
+	From, To *time.Time
+	Cursor   string // opaque, taken from a previous HistoryPage.NextCursor
+	Limit    int
+}
+
+// HistoryPage is one page of a HistoryQuery result.
+type HistoryPage struct {
+	Entries    []HistoryEntry
+	NextCursor string // empty on the last page
+}
+
+// HistoryRepository is an append-only store of history entries.
+type HistoryRepository interface {
+	Append(ctx context.Context, entry HistoryEntry) (*HistoryEntry, error)
+	Find(ctx context.Context, q HistoryQuery) (*HistoryPage, error)
+}
+
+// EncodeHistoryCursor builds the opaque cursor pointing just after e. It
+// uses the task cursor format with the entry's timestamp as sort value.
+func EncodeHistoryCursor(e HistoryEntry) string {
+	raw, _ := json.Marshal(TaskCursor{Value: e.At.UTC().Format(time.RFC3339Nano), ID: e.ID})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeHistoryCursor returns the position encoded by EncodeHistoryCursor.
+func DecodeHistoryCursor(s string) (time.Time, ID, error) {
+	c, err := DecodeTaskCursor(s)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	at, err := c.TimeValue()
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	return at, c.ID, nil
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ID identifies an entity independently of the storage backend. New IDs
+// are 24-character hex strings; SQL backends store them as text while the
+// Mongo backend stores them as ObjectIDs, so existing documents still match.
+type ID string
+
+// NewID returns a new, time-ordered unique ID.
+func NewID() ID {
+	return ID(primitive.NewObjectID().Hex())
+}
+
+// ParseID validates an ID received from a client.
+func ParseID(s string) (ID, error) {
+	if _, err := primitive.ObjectIDFromHex(s); err != nil {
+		return "", NewError(ErrInvalidID, "invalid_id", "invalid ID format")
+	}
+	return ID(s), nil
+}
+
+func (id ID) String() string {
+	return string(id)
+}
+
+// IsZero reports whether the ID is unset. The bson encoder also uses it
+// to honour omitempty.
+func (id ID) IsZero() bool {
+	return id == ""
+}
+
+// MarshalBSONValue encodes hex IDs as ObjectIDs and the zero ID as null.
+func (id ID) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	if id == "" {
+		return bson.TypeNull, nil, nil
+	}
+	if oid, err := primitive.ObjectIDFromHex(string(id)); err == nil {
+		return bson.MarshalValue(oid)
+	}
+	return bson.MarshalValue(string(id))
+}
+
+// UnmarshalBSONValue accepts ObjectIDs, strings and null.
+func (id *ID) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	raw := bson.RawValue{Type: t, Value: data}
+	switch t {
+	case bson.TypeObjectID:
+		*id = ID(raw.ObjectID().Hex())
+	case bson.TypeString:
+		*id = ID(raw.StringValue())
+	case bson.TypeNull, bson.TypeUndefined:
+		*id = ""
+	default:
+		return fmt.Errorf("cannot decode %s into an ID", t)
+	}
+	return nil
+}
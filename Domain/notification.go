@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// NotificationKind is the event a notification tells its user about.
+type NotificationKind string
+
+const (
+	// NotificationMentioned: the user was mentioned in a comment.
+	NotificationMentioned NotificationKind = "mentioned"
+	// NotificationAssigned and NotificationUnassigned: the user was added
+	// to or removed from the task's assignees.
+	NotificationAssigned   NotificationKind = "assigned"
+	NotificationUnassigned NotificationKind = "unassigned"
+	// NotificationTaskChanged: a task the user watches was changed.
+	NotificationTaskChanged NotificationKind = "task_changed"
+)
+
+// Notification tells a user that someone else did something concerning
+// them on a task.
+type Notification struct {
+	ID      ID               `bson:"_id,omitempty"`
+	OrgID   ID               `bson:"org_id"`
+	UserID  ID               `bson:"user_id"`
+	Kind    NotificationKind `bson:"kind"`
+	TaskID  ID               `bson:"task_id"`
+	ActorID ID               `bson:"actor_id"`
+	// CommentID is set on notifications about a comment.
+	CommentID *ID        `bson:"comment_id,omitempty"`
+	CreatedAt time.Time  `bson:"created_at"`
+	ReadAt    *time.Time `bson:"read_at,omitempty"`
+}
+
+// NotificationQuery selects the notifications of a user, newest first.
+type NotificationQuery struct {
+	UserID ID
+	// Unread leaves out the notifications already read.
+	Unread bool
+	Cursor string // opaque, taken from a previous NotificationPage.NextCursor
+	Limit  int
+}
+
+// NotificationPage is one page of a NotificationQuery result.
+type NotificationPage struct {
+	Notifications []Notification
+	NextCursor    string // empty on the last page
+}
+
+type NotificationRepository interface {
+	Create(ctx context.Context, n Notification) (*Notification, error)
+	Find(ctx context.Context, q NotificationQuery) (*NotificationPage, error)
+	// MarkRead sets ReadAt on a notification of user unless it is already
+	// read. Notifications of other users are reported as not found.
+	MarkRead(ctx context.Context, user, id ID, at time.Time) (*Notification, error)
+}
+
+// EncodeNotificationCursor builds the opaque cursor pointing just after n
+// in newest-first order.
+func EncodeNotificationCursor(n Notification) string {
+	raw, _ := json.Marshal(TaskCursor{Value: n.CreatedAt.UTC().Format(time.RFC3339Nano), ID: n.ID})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeNotificationCursor returns the position encoded by
+// EncodeNotificationCursor.
+func DecodeNotificationCursor(s string) (time.Time, ID, error) {
+	c, err := DecodeTaskCursor(s)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	at, err := c.TimeValue()
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	return at, c.ID, nil
+}
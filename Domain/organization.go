@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Organization is a tenant: a team whose members share tasks and
+// projects and see nothing of other organizations. Every user has a
+// personal organization, created when they register, whose ID is their
+// user ID.
+type Organization struct {
+	ID        ID        `bson:"_id,omitempty"`
+	OwnerID   ID        `bson:"owner_id"`
+	Name      string    `bson:"name"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+// Personal reports whether o is the personal organization of its owner.
+func (o *Organization) Personal() bool {
+	return o.ID == o.OwnerID
+}
+
+type OrganizationRepository interface {
+	// Create stores o, keeping o.ID when it is set so a personal
+	// organization can share its owner's ID; a taken ID is reported as
+	// ErrOrganizationExists.
+	Create(ctx context.Context, o Organization) (*Organization, error)
+	GetByID(ctx context.Context, id ID) (*Organization, error)
+	// GetByIDs returns the organizations among ids that exist, ordered by
+	// ID.
+	GetByIDs(ctx context.Context, ids []ID) ([]Organization, error)
+}
+
+// InOrganization reports whether the user is a member of org.
+func (u *User) InOrganization(org ID) bool {
+	for _, id := range u.OrgIDs {
+		if id == org {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleIn is the user's role in org: "admin" in the organizations they
+// were promoted in and "user" everywhere else.
+func (u *User) RoleIn(org ID) string {
+	for _, id := range u.AdminOrgIDs {
+		if id == org {
+			return "admin"
+		}
+	}
+	return "user"
+}
+
+// DefaultOrganization is the organization a login without an explicit
+// one is scoped to: the user's personal organization if they still have
+// it, otherwise the first they joined.
+func (u *User) DefaultOrganization() (ID, bool) {
+	if u.InOrganization(u.UserID) {
+		return u.UserID, true
+	}
+	if len(u.OrgIDs) == 0 {
+		return "", false
+	}
+	return u.OrgIDs[0], true
+}
+
+type tenantKey struct{}
+
+// allTenants is the tenant of contexts that WithoutTenant unscoped.
+type allTenants struct{}
+
+// WithTenant scopes ctx to organization org. Repositories of tenant data
+// (tasks, projects, comments, history, notifications and users) only
+// read, change and create records of the tenant in ctx, so a request
+// cannot reach another organization's data even if a use case forgets
+// to filter.
+func WithTenant(ctx context.Context, org ID) context.Context {
+	return context.WithValue(ctx, tenantKey{}, org)
+}
+
+// WithoutTenant lifts the scope of ctx, for the few operations that must
+// look across organizations, such as logins, background jobs and adding
+// a user to an organization.
+func WithoutTenant(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tenantKey{}, allTenants{})
+}
+
+// TenantFrom returns the organization ctx is scoped to. A context that is
+// neither scoped nor passed through WithoutTenant has no tenant, and
+// repositories of tenant data treat it as seeing nothing.
+func TenantFrom(ctx context.Context) (ID, bool) {
+	org, _ := ctx.Value(tenantKey{}).(ID)
+	return org, org != ""
+}
+
+// AllTenants reports whether WithoutTenant lifted the scope of ctx.
+func AllTenants(ctx context.Context) bool {
+	_, ok := ctx.Value(tenantKey{}).(allTenants)
+	return ok
+}
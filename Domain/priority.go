@@ -0,0 +1,47 @@
+package domain
+
+import "strings"
+
+// Priority ranks how important a task is.
+type Priority string
+
+const (
+	PriorityLow      Priority = "low"
+	PriorityMedium   Priority = "medium"
+	PriorityHigh     Priority = "high"
+	PriorityCritical Priority = "critical"
+)
+
+// Valid reports whether p is a known priority.
+func (p Priority) Valid() bool {
+	switch p {
+	case PriorityLow, PriorityMedium, PriorityHigh, PriorityCritical:
+		return true
+	}
+	return false
+}
+
+// NormalizePriority lower-cases p and gives a blank priority the default,
+// PriorityMedium.
+func NormalizePriority(p Priority) Priority {
+	p = Priority(strings.ToLower(strings.TrimSpace(string(p))))
+	if p == "" {
+		return PriorityMedium
+	}
+	return p
+}
+
+// factor maps the priority onto (0, 1]. Tasks stored before priorities
+// existed count as medium.
+func (p Priority) factor() float64 {
+	switch p {
+	case PriorityLow:
+		return 0.25
+	case PriorityHigh:
+		return 0.75
+	case PriorityCritical:
+		return 1
+	default:
+		return 0.5
+	}
+}
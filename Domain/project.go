@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// ProjectRole is what a member may do in a project. Roles are ordered:
+// each one grants everything the previous one does.
+type ProjectRole string
+
+const (
+	// ProjectViewer may read the project and its tasks.
+	ProjectViewer ProjectRole = "viewer"
+	// ProjectEditor may also add tasks and change them like an assignee.
+	ProjectEditor ProjectRole = "editor"
+	// ProjectMaintainer may also change the project, its members and its
+	// tasks like their owner.
+	ProjectMaintainer ProjectRole = "maintainer"
+)
+
+var projectRoleRank = map[ProjectRole]int{ProjectViewer: 1, ProjectEditor: 2, ProjectMaintainer: 3}
+
+// ParseProjectRole maps a role name, in any case, onto a ProjectRole.
+func ParseProjectRole(s string) (ProjectRole, bool) {
+	r := ProjectRole(strings.ToLower(strings.TrimSpace(s)))
+	return r, projectRoleRank[r] > 0
+}
+
+// AtLeast reports whether r grants everything min does. The empty role
+// grants nothing.
+func (r ProjectRole) AtLeast(min ProjectRole) bool {
+	return projectRoleRank[r] > 0 && projectRoleRank[r] >= projectRoleRank[min]
+}
+
+// ProjectMember is a user given a role in a project.
+type ProjectMember struct {
+	UserID ID          `json:"user_id" bson:"user_id"`
+	Role   ProjectRole `json:"role" bson:"role"`
+}
+
+// Project groups tasks and the users working on them. Its owner is
+// always a maintainer and is not listed in Members.
+type Project struct {
+	ID          ID              `bson:"_id,omitempty"`
+	OrgID       ID              `bson:"org_id"`
+	OwnerID     ID              `bson:"owner_id"`
+	Name        string          `bson:"name"`
+	Description string          `bson:"description"`
+	Members     []ProjectMember `bson:"members,omitempty"`
+	// ArchivedAt is set while the project is archived; its tasks are
+	// read-only then.
+	ArchivedAt *time.Time `bson:"archived_at,omitempty"`
+	CreatedAt  time.Time  `bson:"created_at"`
+	UpdatedAt  time.Time  `bson:"updated_at"`
+}
+
+// Archived reports whether the project is archived.
+func (p *Project) Archived() bool {
+	return p.ArchivedAt != nil
+}
+
+// RoleOf returns the role user holds in the project, or "" for users who
+// are not members.
+func (p *Project) RoleOf(user ID) ProjectRole {
+	if p.OwnerID == user {
+		return ProjectMaintainer
+	}
+	for _, m := range p.Members {
+		if m.UserID == user {
+			return m.Role
+		}
+	}
+	return ""
+}
+
+// SetMember gives user role in the project, adding them if needed.
+// Members stay sorted by user ID.
+func (p *Project) SetMember(user ID, role ProjectRole) {
+	for i, m := range p.Members {
+		if m.UserID == user {
+			p.Members[i].Role = role
+			return
+		}
+	}
+	i := 0
+	for i < len(p.Members) && p.Members[i].UserID < user {
+		i++
+	}
+	p.Members = append(p.Members[:i], append([]ProjectMember{{UserID: user, Role: role}}, p.Members[i:]...)...)
+}
+
+// RemoveMember takes user out of the project and reports whether they
+// were a member.
+func (p *Project) RemoveMember(user ID) bool {
+	for i, m := range p.Members {
+		if m.UserID == user {
+			p.Members = append(p.Members[:i], p.Members[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// ProjectRole returns the actor's role in p. Admins are maintainers of
+// every project.
+func (a Actor) ProjectRole(p *Project) ProjectRole {
+	if a.IsAdmin() {
+		return ProjectMaintainer
+	}
+	return p.RoleOf(a.UserID)
+}
+
+// ProjectQuery selects projects, oldest first.
+type ProjectQuery struct {
+	MemberID *ID   // lists the projects a user owns or is a member of
+	Archived *bool // lists only archived or only active projects
+	Cursor   string
+	Limit    int
+}
+
+// ProjectPage is one page of a ProjectQuery result.
+type ProjectPage struct {
+	Projects   []Project
+	NextCursor string // empty on the last page
+}
+
+type ProjectRepository interface {
+	Create(ctx context.Context, p Project) (*Project, error)
+	GetByID(ctx context.Context, id ID) (*Project, error)
+	// Update stores the name, description, members, archive and update
+	// times of the project.
+	Update(ctx context.Context, p Project) (*Project, error)
+	Delete(ctx context.Context, id ID) error
+	Find(ctx context.Context, q ProjectQuery) (*ProjectPage, error)
+}
+
+// EncodeProjectCursor builds the opaque cursor pointing just after p, in
+// the same format as EncodeCommentCursor.
+func EncodeProjectCursor(p Project) string {
+	raw, _ := json.Marshal(TaskCursor{Value: p.CreatedAt.UTC().Format(time.RFC3339Nano), ID: p.ID})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeProjectCursor returns the position encoded by EncodeProjectCursor.
+func DecodeProjectCursor(s string) (time.Time, ID, error) {
+	return DecodeCommentCursor(s)
+}
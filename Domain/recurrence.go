@@ -0,0 +1,303 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is the base period of a recurrence rule.
+type Frequency string
+
+const (
+	FreqDaily   Frequency = "DAILY"
+	FreqWeekly  Frequency = "WEEKLY"
+	FreqMonthly Frequency = "MONTHLY"
+)
+
+// WeekdayNum is a BYDAY entry: a weekday, optionally limited to its nth
+// occurrence in the month (negative counts from the end, 0 means every).
+type WeekdayNum struct {
+	N   int
+	Day time.Weekday
+}
+
+// RecurrenceRule is the subset of an iCalendar RRULE (RFC 5545) that
+// tasks support: FREQ of DAILY, WEEKLY or MONTHLY with INTERVAL, BYDAY,
+// COUNT and UNTIL. Weeks start on Monday.
+type RecurrenceRule struct {
+	Freq     Frequency
+	Interval int // at least 1
+	ByDay    []WeekdayNum
+	Count    int        // total occurrences in the series; 0 is unlimited
+	Until    *time.Time // last possible occurrence, inclusive
+}
+
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// ParseRecurrenceRule reads an RRULE value such as
+// "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10", with or without the "RRULE:" prefix.
+func ParseRecurrenceRule(s string) (*RecurrenceRule, error) {
+	s = strings.TrimSpace(s)
+	if len(s) >= 6 && strings.EqualFold(s[:6], "RRULE:") {
+		s = s[6:]
+	}
+	r := &RecurrenceRule{Interval: 1}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(name)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("malformed part %q", part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%s is given twice", name)
+		}
+		seen[name] = true
+		var err error
+		switch name {
+		case "FREQ":
+			r.Freq = Frequency(strings.ToUpper(value))
+			if r.Freq != FreqDaily && r.Freq != FreqWeekly && r.Freq != FreqMonthly {
+				err = fmt.Errorf("FREQ must be DAILY, WEEKLY or MONTHLY")
+			}
+		case "INTERVAL":
+			r.Interval, err = positiveInt(name, value)
+		case "COUNT":
+			r.Count, err = positiveInt(name, value)
+		case "UNTIL":
+			r.Until, err = parseUntil(value)
+		case "BYDAY":
+			r.ByDay, err = parseByDay(value)
+		default:
+			err = fmt.Errorf("%s is not supported", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if r.Freq == "" {
+		return nil, errors.New("FREQ is required")
+	}
+	if r.Count > 0 && r.Until != nil {
+		return nil, errors.New("COUNT and UNTIL cannot be combined")
+	}
+	for _, d := range r.ByDay {
+		if d.N != 0 && r.Freq != FreqMonthly {
+			return nil, errors.New("numbered BYDAY values are only allowed with FREQ=MONTHLY")
+		}
+	}
+	return r, nil
+}
+
+func positiveInt(name, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s must be a positive integer", name)
+	}
+	return n, nil
+}
+
+// parseUntil accepts a UTC date-time or a date, which includes that day.
+func parseUntil(value string) (*time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return &t, nil
+	}
+	if t, err := time.Parse("20060102", value); err == nil {
+		end := t.Add(24*time.Hour - time.Second)
+		return &end, nil
+	}
+	return nil, errors.New("UNTIL must look like 20250131 or 20250131T170000Z")
+}
+
+func parseByDay(value string) ([]WeekdayNum, error) {
+	var days []WeekdayNum
+	for _, raw := range strings.Split(strings.ToUpper(value), ",") {
+		if len(raw) < 2 {
+			return nil, fmt.Errorf("invalid BYDAY value %q", raw)
+		}
+		day, ok := weekdayCodes[raw[len(raw)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid BYDAY value %q", raw)
+		}
+		n := 0
+		if prefix := raw[:len(raw)-2]; prefix != "" {
+			var err error
+			n, err = strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -5 || n > 5 {
+				return nil, fmt.Errorf("invalid BYDAY value %q", raw)
+			}
+		}
+		days = append(days, WeekdayNum{N: n, Day: day})
+	}
+	return days, nil
+}
+
+// String renders the rule in canonical RRULE form, without the prefix.
+func (r RecurrenceRule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, d := range r.ByDay {
+			days[i] = strings.ToUpper(d.Day.String()[:2])
+			if d.N != 0 {
+				days[i] = strconv.Itoa(d.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// maxRecurrenceSteps bounds the periods searched for the next occurrence,
+// so rules that rarely match (such as BYDAY=5FR) cannot loop for long.
+const maxRecurrenceSteps = 1000
+
+// Next returns the first occurrence after prev, where prev is itself an
+// occurrence of the rule, or false when UNTIL has passed. COUNT is not
+// applied here because it depends on the position in the series; see
+// Task.NextOccurrences. Occurrences keep the time of day of prev.
+func (r RecurrenceRule) Next(prev time.Time) (time.Time, bool) {
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+	for step := 0; step <= maxRecurrenceSteps; step++ {
+		var candidates []time.Time
+		switch r.Freq {
+		case FreqDaily:
+			day := prev.AddDate(0, 0, (step+1)*interval)
+			if r.matchesDay(day) {
+				candidates = []time.Time{day}
+			}
+		case FreqWeekly:
+			candidates = r.weekCandidates(prev, step*interval)
+		case FreqMonthly:
+			candidates = r.monthCandidates(prev, step*interval)
+		default:
+			return time.Time{}, false
+		}
+		for _, c := range candidates {
+			if !c.After(prev) {
+				continue
+			}
+			if r.Until != nil && c.After(*r.Until) {
+				return time.Time{}, false
+			}
+			return c, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (r RecurrenceRule) matchesDay(t time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, d := range r.ByDay {
+		if d.Day == t.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// weekCandidates lists the BYDAY days, or the weekday of anchor, in the
+// week that lies weeks weeks after anchor's, in order.
+func (r RecurrenceRule) weekCandidates(anchor time.Time, weeks int) []time.Time {
+	monday := anchor.AddDate(0, 0, -((int(anchor.Weekday())+6)%7)+7*weeks)
+	days := []time.Weekday{anchor.Weekday()}
+	if len(r.ByDay) > 0 {
+		days = days[:0]
+		for _, d := range r.ByDay {
+			days = append(days, d.Day)
+		}
+	}
+	out := make([]time.Time, 0, len(days))
+	for _, d := range days {
+		out = append(out, monday.AddDate(0, 0, (int(d)+6)%7))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+	return out
+}
+
+// monthCandidates lists the matching days of the month that lies months
+// months after anchor's, in order. Without BYDAY that is anchor's day of
+// the month, skipped in months too short to have it.
+func (r RecurrenceRule) monthCandidates(anchor time.Time, months int) []time.Time {
+	y, m, _ := anchor.Date()
+	hh, mm, ss := anchor.Clock()
+	first := time.Date(y, m+time.Month(months), 1, hh, mm, ss, anchor.Nanosecond(), anchor.Location())
+	length := first.AddDate(0, 1, -1).Day()
+	if len(r.ByDay) == 0 {
+		if anchor.Day() > length {
+			return nil
+		}
+		return []time.Time{first.AddDate(0, 0, anchor.Day()-1)}
+	}
+	var out []time.Time
+	for day := 1; day <= length; day++ {
+		t := first.AddDate(0, 0, day-1)
+		for _, d := range r.ByDay {
+			if d.Day != t.Weekday() {
+				continue
+			}
+			nth, fromEnd := (day-1)/7+1, -((length-day)/7 + 1)
+			if d.N == 0 || d.N == nth || d.N == fromEnd {
+				out = append(out, t)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// SeriesPosition is the 1-based position of the task in its recurring
+// series.
+func (t Task) SeriesPosition() int {
+	if t.Occurrence < 1 {
+		return 1
+	}
+	return t.Occurrence
+}
+
+// NextOccurrences returns up to n due dates that follow the task's own
+// under its recurrence rule, stopping early once COUNT or UNTIL is
+// reached. A task without a rule has none.
+func (t Task) NextOccurrences(n int) ([]time.Time, error) {
+	if t.Recurrence == "" {
+		return nil, nil
+	}
+	r, err := ParseRecurrenceRule(t.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+	if r.Count > 0 && n > r.Count-t.SeriesPosition() {
+		n = r.Count - t.SeriesPosition()
+	}
+	var out []time.Time
+	for prev := t.DueDate; len(out) < n; {
+		next, ok := r.Next(prev)
+		if !ok {
+			break
+		}
+		out = append(out, next)
+		prev = next
+	}
+	return out, nil
+}
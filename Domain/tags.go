@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Limits on the tags of a task.
+const (
+	MaxTagLength   = 32
+	MaxTagsPerTask = 20
+)
+
+// TagMatch says how the tags of a TaskQuery combine.
+type TagMatch string
+
+const (
+	TagMatchAny TagMatch = "any" // tasks with at least one of the tags
+	TagMatchAll TagMatch = "all" // tasks with every tag
+)
+
+// TagCount is a tag and the number of tasks carrying it.
+type TagCount struct {
+	Name  string
+	Count int
+}
+
+// NormalizeTag lower-cases a tag and joins its words with hyphens, so
+// " Bug Fix" and "bug-fix" are the same tag.
+func NormalizeTag(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), "-")
+}
+
+// NormalizeTags normalizes every tag, drops empty and repeated ones and
+// sorts the rest.
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	var out []string
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ValidTag reports whether a normalized tag is short enough and made only
+// of letters, digits, '-', '_' and '.'.
+func ValidTag(tag string) bool {
+	if tag == "" || utf8.RuneCountInString(tag) > MaxTagLength {
+		return false
+	}
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-_.", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// HasTag reports whether the task carries tag.
+func (t Task) HasTag(tag string) bool {
+	for _, have := range t.Tags {
+		if have == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesTags reports whether the task's tags satisfy tags under match.
+// No tags match every task.
+func (t Task) MatchesTags(tags []string, match TagMatch) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	for _, tag := range tags {
+		has := t.HasTag(tag)
+		if has && match != TagMatchAll {
+			return true
+		}
+		if !has && match == TagMatchAll {
+			return false
+		}
+	}
+	return match == TagMatchAll
+}
+
+// CountTags counts the tasks carrying each tag, most used first and ties
+// in name order.
+func CountTags(tasks []Task) []TagCount {
+	counts := map[string]int{}
+	for _, t := range tasks {
+		for _, tag := range t.Tags {
+			counts[tag]++
+		}
+	}
+	out := make([]TagCount, 0, len(counts))
+	for name, n := range counts {
+		out = append(out, TagCount{Name: name, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
@@ -0,0 +1,70 @@
+package domain
+
+import "sort"
+
+var ErrDependencyCycle = NewError(ErrConflict, "dependency_cycle", "dependencies would form a cycle")
+
+// Finished reports whether the task no longer holds up the tasks that
+// depend on it: it is completed, cancelled or in the trash.
+func (t Task) Finished() bool {
+	return t.DeletedAt != nil || !t.Status.IsOpen()
+}
+
+// HasDependency reports whether the task depends directly on id.
+func (t Task) HasDependency(id ID) bool {
+	for _, dep := range t.DependsOn {
+		if dep == id {
+			return true
+		}
+	}
+	return false
+}
+
+// PlanTasks orders tasks so that every task comes after the tasks it
+// depends on. Dependencies on tasks outside the slice are ignored. Tasks
+// that are free to go in either order keep the order of their creation.
+func PlanTasks(tasks []Task) ([]Task, error) {
+	index := make(map[ID]int, len(tasks))
+	for i, t := range tasks {
+		index[t.TaskID] = i
+	}
+	waiting := make([]int, len(tasks))
+	dependents := make([][]int, len(tasks))
+	for i, t := range tasks {
+		for _, dep := range t.DependsOn {
+			if j, ok := index[dep]; ok {
+				waiting[i]++
+				dependents[j] = append(dependents[j], i)
+			}
+		}
+	}
+
+	var ready []int
+	for i := range tasks {
+		if waiting[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	plan := make([]Task, 0, len(tasks))
+	for len(ready) > 0 {
+		sort.Slice(ready, func(a, b int) bool {
+			ta, tb := tasks[ready[a]], tasks[ready[b]]
+			if !ta.CreatedAt.Equal(tb.CreatedAt) {
+				return ta.CreatedAt.Before(tb.CreatedAt)
+			}
+			return ta.TaskID < tb.TaskID
+		})
+		next := ready[0]
+		ready = ready[1:]
+		plan = append(plan, tasks[next])
+		for _, d := range dependents[next] {
+			if waiting[d]--; waiting[d] == 0 {
+				ready = append(ready, d)
+			}
+		}
+	}
+	if len(plan) < len(tasks) {
+		return nil, ErrDependencyCycle
+	}
+	return plan, nil
+}
@@ -0,0 +1,62 @@
+package domain
+
+import "time"
+
+// TaskPatch is a partial update of a task. Nil fields are left unchanged;
+// a field pointing at its zero value clears it. Status is not applied by
+// Apply because status changes go through the workflow.
+type TaskPatch struct {
+	Title       *string
+	Description *string
+	DueDate     *time.Time
+	Status      *string
+	ParentID    *ID
+	ProjectID   *ID
+	Recurrence  *string
+	Tags        *[]string
+	Priority    *Priority
+	Assignees   *[]ID
+	Watchers    *[]ID
+}
+
+// Apply copies the set fields, except Status, onto t.
+func (p TaskPatch) Apply(t *Task) {
+	if p.Title != nil {
+		t.Title = *p.Title
+	}
+	if p.Description != nil {
+		t.Description = *p.Description
+	}
+	if p.DueDate != nil {
+		t.DueDate = *p.DueDate
+	}
+	if p.Recurrence != nil {
+		t.Recurrence = *p.Recurrence
+	}
+	if p.Tags != nil {
+		t.Tags = *p.Tags
+	}
+	if p.Priority != nil {
+		t.Priority = *p.Priority
+	}
+	if p.Assignees != nil {
+		t.Assignees = *p.Assignees
+	}
+	if p.Watchers != nil {
+		t.Watchers = *p.Watchers
+	}
+	if p.ParentID != nil {
+		t.ParentID = nil
+		if *p.ParentID != "" {
+			parent := *p.ParentID
+			t.ParentID = &parent
+		}
+	}
+	if p.ProjectID != nil {
+		t.ProjectID = nil
+		if *p.ProjectID != "" {
+			project := *p.ProjectID
+			t.ProjectID = &project
+		}
+	}
+}
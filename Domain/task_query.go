@@ -0,0 +1,136 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// TaskSortField names a task attribute usable as a sort key.
+type TaskSortField string
+
+const (
+	SortByCreatedAt TaskSortField = "created_at"
+	SortByUpdatedAt TaskSortField = "updated_at"
+	SortByDueDate   TaskSortField = "due_date"
+	SortByTitle     TaskSortField = "title"
+	// SortByUrgency is computed rather than stored, so repositories do
+	// not sort by it; the caller scores and sorts the tasks itself.
+	SortByUrgency TaskSortField = "urgency"
+)
+
+// Valid reports whether f is a known sort key.
+func (f TaskSortField) Valid() bool {
+	switch f {
+	case SortByCreatedAt, SortByUpdatedAt, SortByDueDate, SortByTitle, SortByUrgency:
+		return true
+	}
+	return false
+}
+
+// TaskQuery is a storage-neutral description of a task listing.
+// Zero-valued fields do not filter; range bounds are inclusive.
+type TaskQuery struct {
+	OwnerID       *ID
+	AssigneeID    *ID // lists the tasks assigned to a user
+	ParentID      *ID // lists the direct subtasks of a task
+	ProjectID     *ID // lists the tasks of a project
+	DependsOn     *ID // lists the tasks that depend on a task
+	Statuses      []TaskStatus
+	TitleContains string // case-insensitive substring match
+	Tags          []string
+	TagMatch      TagMatch // how Tags combine; defaults to TagMatchAny
+
+	DueFrom, DueTo         *time.Time
+	CreatedFrom, CreatedTo *time.Time
+	UpdatedFrom, UpdatedTo *time.Time
+
+	// Trashed lists the tasks in the trash instead of the live ones.
+	Trashed bool
+
+	SortBy   TaskSortField // defaults to SortByCreatedAt
+	SortDesc bool
+	Cursor   string // opaque, taken from a previous TaskPage.NextCursor
+	Limit    int
+}
+
+// TaskPage is one page of a TaskQuery result.
+type TaskPage struct {
+	Tasks      []Task
+	NextCursor string // empty on the last page
+}
+
+var ErrInvalidCursor = NewError(ErrValidation, "invalid_cursor", "invalid cursor")
+
+// TaskCursor is the decoded position after which the next page starts:
+// the sort key value of the last returned task and its ID as tiebreaker.
+// Task cursors also record the order they were made for, so a cursor
+// cannot be replayed against a listing sorted another way.
+type TaskCursor struct {
+	Value    string        `json:"v"`
+	ID       ID            `json:"id"`
+	SortBy   TaskSortField `json:"s,omitempty"`
+	SortDesc bool          `json:"d,omitempty"`
+}
+
+// SortValue returns the value of t for the given sort key in the form
+// stored inside cursors. Times use RFC 3339 so they survive the round trip.
+func (t Task) SortValue(f TaskSortField) string {
+	switch f {
+	case SortByUpdatedAt:
+		return t.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	case SortByDueDate:
+		return t.DueDate.UTC().Format(time.RFC3339Nano)
+	case SortByTitle:
+		return t.Title
+	case SortByUrgency:
+		return strconv.FormatFloat(t.UrgencyScore, 'f', -1, 64)
+	default:
+		return t.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+}
+
+// EncodeTaskCursor builds the opaque cursor pointing just after t in a
+// listing sorted by f, descending when desc is set.
+func EncodeTaskCursor(t Task, f TaskSortField, desc bool) string {
+	raw, _ := json.Marshal(TaskCursor{Value: t.SortValue(f), ID: t.TaskID, SortBy: f, SortDesc: desc})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeTaskCursor parses a cursor produced by EncodeTaskCursor.
+func DecodeTaskCursor(s string) (*TaskCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c TaskCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// SortedBy reports whether c was made for a listing sorted by f in the
+// given direction.
+func (c TaskCursor) SortedBy(f TaskSortField, desc bool) bool {
+	return c.SortBy == f && c.SortDesc == desc
+}
+
+// FloatValue parses the cursor value for SortByUrgency.
+func (c TaskCursor) FloatValue() (float64, error) {
+	f, err := strconv.ParseFloat(c.Value, 64)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	return f, nil
+}
+
+// TimeValue parses the cursor value for time-based sort keys.
+func (c TaskCursor) TimeValue() (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, c.Value)
+	if err != nil {
+		return time.Time{}, ErrInvalidCursor
+	}
+	return t, nil
+}
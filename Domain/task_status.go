@@ -0,0 +1,70 @@
+package domain
+
+import "strings"
+
+// TaskStatus is the lifecycle state of a task.
+type TaskStatus string
+
+const (
+	StatusPending    TaskStatus = "Pending"
+	StatusInProgress TaskStatus = "In Progress"
+	StatusBlocked    TaskStatus = "Blocked"
+	StatusCompleted  TaskStatus = "Completed"
+	StatusCancelled  TaskStatus = "Cancelled"
+)
+
+// TaskStatuses lists every defined status in lifecycle order.
+var TaskStatuses = []TaskStatus{
+	StatusPending,
+	StatusInProgress,
+	StatusBlocked,
+	StatusCompleted,
+	StatusCancelled,
+}
+
+// ParseTaskStatus maps user input onto a defined status. Matching ignores
+// case and treats "_" and "-" as spaces, so "in_progress" is accepted.
+func ParseTaskStatus(s string) (TaskStatus, bool) {
+	norm := strings.Join(strings.Fields(strings.NewReplacer("_", " ", "-", " ").Replace(s)), " ")
+	for _, st := range TaskStatuses {
+		if strings.EqualFold(norm, string(st)) {
+			return st, true
+		}
+	}
+	return "", false
+}
+
+// IsOpen reports whether a task in this status still has work left, that
+// is, whether it is neither completed nor cancelled.
+func (s TaskStatus) IsOpen() bool {
+	return s != StatusCompleted && s != StatusCancelled
+}
+
+// TransitionTable lists, for each status, the statuses a task may move to.
+type TransitionTable map[TaskStatus][]TaskStatus
+
+// Allows reports whether a task may move from one status to another.
+// Staying in the same status is always allowed.
+func (t TransitionTable) Allows(from, to TaskStatus) bool {
+	if from == to {
+		return true
+	}
+	for _, next := range t[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultTransitions is the workflow used unless another table is configured.
+// Completed and Cancelled tasks can only be reopened.
+func DefaultTransitions() TransitionTable {
+	return TransitionTable{
+		StatusPending:    {StatusInProgress, StatusBlocked, StatusCompleted, StatusCancelled},
+		StatusInProgress: {StatusPending, StatusBlocked, StatusCompleted, StatusCancelled},
+		StatusBlocked:    {StatusPending, StatusInProgress, StatusCancelled},
+		StatusCompleted:  {StatusInProgress},
+		StatusCancelled:  {StatusPending},
+	}
+}
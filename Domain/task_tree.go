@@ -0,0 +1,54 @@
+package domain
+
+// TaskNode is a task together with its subtasks, as far down as they were
+// loaded.
+type TaskNode struct {
+	Task     Task
+	Children []*TaskNode
+	// Truncated is set when the task has subtasks that were cut off by
+	// the depth limit.
+	Truncated bool
+	// Progress is the percentage of the task's work that is done; see
+	// RollUp.
+	Progress float64
+}
+
+// RollUp computes Progress for n and every node below it. A completed task
+// is 100% done. Any other task with subtasks is as far along as the mean of
+// its subtasks, not counting cancelled ones; without them it is 0% done.
+func (n *TaskNode) RollUp() float64 {
+	var sum float64
+	counted := 0
+	for _, c := range n.Children {
+		p := c.RollUp()
+		if c.Task.Status == StatusCancelled {
+			continue
+		}
+		sum += p
+		counted++
+	}
+	switch {
+	case n.Task.Status == StatusCompleted:
+		n.Progress = 100
+	case counted > 0:
+		n.Progress = sum / float64(counted)
+	default:
+		n.Progress = 0
+	}
+	return n.Progress
+}
+
+// Prune drops the nodes more than depth levels below n, marking the nodes
+// that lost their children as truncated. Depth 0 keeps only n.
+func (n *TaskNode) Prune(depth int) {
+	if depth <= 0 {
+		if len(n.Children) > 0 {
+			n.Children = nil
+			n.Truncated = true
+		}
+		return
+	}
+	for _, c := range n.Children {
+		c.Prune(depth - 1)
+	}
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// UrgencyWeights scale the factors that make up a task's urgency. Each
+// factor lies between 0 and 1, so a weight is the most its factor can add;
+// a negative weight lowers the urgency instead.
+type UrgencyWeights struct {
+	Priority float64
+	DueDate  float64
+	Age      float64
+	Blocked  float64
+}
+
+// DefaultUrgencyWeights favour the due date, then priority, and push
+// blocked tasks down since nobody can work on them.
+func DefaultUrgencyWeights() UrgencyWeights {
+	return UrgencyWeights{Priority: 6, DueDate: 12, Age: 2, Blocked: -5}
+}
+
+const (
+	// urgencyDueHorizon is how far ahead a due date starts to count more
+	// than a distant one.
+	urgencyDueHorizon = 14 * 24 * time.Hour
+	// urgencyAgeHorizon is the age at which the age factor is full.
+	urgencyAgeHorizon = 90 * 24 * time.Hour
+)
+
+// Urgency scores how pressing the task is at now, rounded to two decimals.
+// The due date factor is 1 once the task is due, falls linearly to 0.2 at
+// urgencyDueHorizon ahead and stays there, and is 0 without a due date.
+// The age factor grows with time since creation. Finished tasks score 0.
+func (t Task) Urgency(now time.Time, w UrgencyWeights) float64 {
+	if t.Finished() {
+		return 0
+	}
+	score := w.Priority * t.Priority.factor()
+	if !t.DueDate.IsZero() {
+		left := t.DueDate.Sub(now)
+		due := 0.2
+		switch {
+		case left <= 0:
+			due = 1
+		case left < urgencyDueHorizon:
+			due = 1 - 0.8*float64(left)/float64(urgencyDueHorizon)
+		}
+		score += w.DueDate * due
+	}
+	if !t.CreatedAt.IsZero() {
+		age := float64(now.Sub(t.CreatedAt)) / float64(urgencyAgeHorizon)
+		score += w.Age * math.Max(0, math.Min(age, 1))
+	}
+	if t.Status == StatusBlocked {
+		score += w.Blocked
+	}
+	return math.Round(score*100) / 100
+}
@@ -0,0 +1,166 @@
+package domain
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Input limits enforced by the validation rules below.
+const (
+	MaxTaskTitleLength       = 200
+	MaxTaskDescriptionLength = 10000
+	MaxUserNameLength        = 100
+	MinPasswordLength        = 8
+	// MaxPasswordBytes is bcrypt's input limit; longer passwords would be
+	// silently truncated.
+	MaxPasswordBytes = 72
+	MaxCommentLength = 5000
+
+	MaxProjectNameLength        = 100
+	MaxProjectDescriptionLength = 2000
+	MaxProjectMembers           = 100
+
+	MaxOrganizationNameLength = 100
+)
+
+// FieldError describes why one input field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every rejected field of an input, so clients can
+// fix them all in one round trip. It is of kind ErrValidation.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Field + " " + f.Message
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+// rule is a single declarative check: when ok is false, field is reported
+// with msg.
+type rule struct {
+	field string
+	ok    bool
+	msg   string
+}
+
+// check runs every rule and reports each field at most once, with the
+// message of its first failing rule.
+func check(rules ...rule) error {
+	var fields []FieldError
+	seen := map[string]bool{}
+	for _, r := range rules {
+		if r.ok || seen[r.field] {
+			continue
+		}
+		seen[r.field] = true
+		fields = append(fields, FieldError{Field: r.field, Message: r.msg})
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: fields}
+}
+
+// Validate checks a task's user-supplied fields. A due date is optional,
+// but when set it may not precede createdAt. A recurring task needs a due
+// date to count its occurrences from. Tags are expected to be normalized.
+func (t Task) Validate(createdAt time.Time) error {
+	tagsOK := true
+	for _, tag := range t.Tags {
+		tagsOK = tagsOK && ValidTag(tag)
+	}
+	recurrence := ""
+	if t.Recurrence != "" {
+		if _, err := ParseRecurrenceRule(t.Recurrence); err != nil {
+			recurrence = err.Error()
+		}
+	}
+	return check(
+		rule{"title", strings.TrimSpace(t.Title) != "", "is required"},
+		rule{"title", utf8.RuneCountInString(t.Title) <= MaxTaskTitleLength,
+			fmt.Sprintf("must be at most %d characters", MaxTaskTitleLength)},
+		rule{"description", utf8.RuneCountInString(t.Description) <= MaxTaskDescriptionLength,
+			fmt.Sprintf("must be at most %d characters", MaxTaskDescriptionLength)},
+		rule{"due_date", t.DueDate.IsZero() || !t.DueDate.Before(createdAt), "must not be before the task's creation"},
+		rule{"recurrence", recurrence == "", "must be a supported RRULE: " + recurrence},
+		rule{"recurrence", t.Recurrence == "" || !t.DueDate.IsZero(), "requires a due_date"},
+		rule{"priority", t.Priority == "" || t.Priority.Valid(), "must be low, medium, high or critical"},
+		rule{"tags", len(t.Tags) <= MaxTagsPerTask, fmt.Sprintf("must have at most %d entries", MaxTagsPerTask)},
+		rule{"assignees", len(t.Assignees) <= MaxAssignees, fmt.Sprintf("must have at most %d entries", MaxAssignees)},
+		rule{"watchers", len(t.Watchers) <= MaxWatchers, fmt.Sprintf("must have at most %d entries", MaxWatchers)},
+		rule{"tags", tagsOK, fmt.Sprintf("must be at most %d letters, digits, '-', '_' or '.' each", MaxTagLength)},
+	)
+}
+
+// Validate checks the body of a comment.
+func (c Comment) Validate() error {
+	return check(
+		rule{"body", strings.TrimSpace(c.Body) != "", "is required"},
+		rule{"body", utf8.RuneCountInString(c.Body) <= MaxCommentLength,
+			fmt.Sprintf("must be at most %d characters", MaxCommentLength)},
+	)
+}
+
+// Validate checks a project's user-supplied fields.
+func (p Project) Validate() error {
+	rolesOK := true
+	for _, m := range p.Members {
+		_, ok := ParseProjectRole(string(m.Role))
+		rolesOK = rolesOK && ok
+	}
+	return check(
+		rule{"name", strings.TrimSpace(p.Name) != "", "is required"},
+		rule{"name", utf8.RuneCountInString(p.Name) <= MaxProjectNameLength,
+			fmt.Sprintf("must be at most %d characters", MaxProjectNameLength)},
+		rule{"description", utf8.RuneCountInString(p.Description) <= MaxProjectDescriptionLength,
+			fmt.Sprintf("must be at most %d characters", MaxProjectDescriptionLength)},
+		rule{"members", len(p.Members) <= MaxProjectMembers, fmt.Sprintf("must have at most %d entries", MaxProjectMembers)},
+		rule{"role", rolesOK, "must be viewer, editor or maintainer"},
+	)
+}
+
+// Validate checks an organization's user-supplied fields.
+func (o Organization) Validate() error {
+	return check(
+		rule{"name", strings.TrimSpace(o.Name) != "", "is required"},
+		rule{"name", utf8.RuneCountInString(o.Name) <= MaxOrganizationNameLength,
+			fmt.Sprintf("must be at most %d characters", MaxOrganizationNameLength)},
+	)
+}
+
+// ValidateRegistration checks the input of a new account.
+func ValidateRegistration(name, email, password string) error {
+	return check(
+		rule{"name", strings.TrimSpace(name) != "", "is required"},
+		rule{"name", utf8.RuneCountInString(name) <= MaxUserNameLength,
+			fmt.Sprintf("must be at most %d characters", MaxUserNameLength)},
+		rule{"email", email != "", "is required"},
+		rule{"email", isEmail(email), "must be a valid email address"},
+		rule{"password", len(password) >= MinPasswordLength,
+			fmt.Sprintf("must be at least %d characters", MinPasswordLength)},
+		rule{"password", len(password) <= MaxPasswordBytes,
+			fmt.Sprintf("must be at most %d bytes", MaxPasswordBytes)},
+	)
+}
+
+// isEmail accepts a bare addr-spec such as "a@b.com", without display
+// name or angle brackets.
+func isEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s && strings.Contains(s[strings.LastIndex(s, "@"):], ".")
+}
@@ -1,36 +1,73 @@
 package Infrastructure
 
 import (
-	"net/http"
+	"errors"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
 )
 
-func AuthMiddleware(jwtSvc JWTServiceInterface, requiredRole string) gin.HandlerFunc {
+var (
+	errMissingAuthHeader = domain.NewError(domain.ErrUnauthorized, "missing_token", "Authorization header is required")
+	errMalformedAuth     = domain.NewError(domain.ErrUnauthorized, "malformed_token", "Authorization header format must be Bearer {token}")
+	errInvalidToken      = domain.NewError(domain.ErrUnauthorized, "invalid_token", "Invalid token")
+	errInsufficientRole  = domain.NewError(domain.ErrForbidden, "insufficient_role", "Insufficient permissions")
+)
+
+// abort stops the chain; ErrorMiddleware renders err.
+func abort(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// AuthMiddleware lets requests with a valid access token through, as long
+// as its user still belongs to the token's organization. The caller's
+// role is the one they hold in that organization now, read from their
+// user record rather than the token, so a promotion only counts in the
+// organization it was made in.
+func AuthMiddleware(jwtSvc JWTServiceInterface, users domain.UserRepository, requiredRole string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		auth := c.GetHeader("Authorization")
 		if auth == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
+			abort(c, errMissingAuthHeader)
 			return
 		}
 		parts := strings.SplitN(auth, " ", 2)
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header format must be Bearer {token}"})
+			abort(c, errMalformedAuth)
 			return
 		}
 		token := parts[1]
-		userID, role, err := jwtSvc.ValidateToken(token)
+		claims, err := jwtSvc.ValidateToken(c.Request.Context(), token)
+		if err != nil {
+			abort(c, errInvalidToken)
+			return
+		}
+		// looking the user up in the token's organization fails once they
+		// have been removed from it
+		user, err := users.GetByID(domain.WithTenant(c.Request.Context(), claims.OrgID), claims.UserID)
+		if errors.Is(err, domain.ErrUserNotFound) {
+			abort(c, errInvalidToken)
+			return
+		}
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			abort(c, err)
 			return
 		}
-		if requiredRole != "" && requiredRole != role {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		// admins satisfy every role requirement
+		role := user.RoleIn(claims.OrgID)
+		if requiredRole != "" && requiredRole != role && role != "admin" {
+			abort(c, errInsufficientRole)
 			return
 		}
-		c.Set("user_id", userID)
+		c.Set("user_id", &claims.UserID)
 		c.Set("user_role", role)
+		c.Set("org_id", claims.OrgID)
+		c.Set("token_claims", claims)
+		// scope every repository call of the request to the token's
+		// organization
+		c.Request = c.Request.WithContext(domain.WithTenant(c.Request.Context(), claims.OrgID))
 		c.Next()
 	}
 }
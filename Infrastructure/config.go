@@ -0,0 +1,452 @@
+package Infrastructure
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultJWTSecret is only acceptable in dev mode.
+const DefaultJWTSecret = "secret-key"
+
+// Config is the complete runtime configuration. Values are resolved in
+// increasing order of precedence: defaults, the config file, environment
+// variables (TASK_MANAGER_*), then command-line flags.
+type Config struct {
+	// Env is "dev" or "prod". Prod refuses insecure settings.
+	Env     string        `yaml:"env"`
+	Server  ServerConfig  `yaml:"server"`
+	Storage StorageConfig `yaml:"storage"`
+	Auth    AuthConfig    `yaml:"auth"`
+	Trash   TrashConfig   `yaml:"trash"`
+	Tasks   TasksConfig   `yaml:"tasks"`
+}
+
+type ServerConfig struct {
+	Addr string `yaml:"addr"`
+	// ShutdownTimeout is how long in-flight requests may take to finish
+	// after SIGINT or SIGTERM.
+	ShutdownTimeout Duration `yaml:"shutdown_timeout"`
+}
+
+type StorageConfig struct {
+	// Backend is "mongo", "sqlite", "postgres" or "memory".
+	Backend       string `yaml:"backend"`
+	SQLDSN        string `yaml:"sql_dsn"`
+	MongoURI      string `yaml:"mongo_uri"`
+	MongoDatabase string `yaml:"mongo_database"`
+	// QueryTimeout is the deadline for the storage work of one request.
+	QueryTimeout Duration `yaml:"query_timeout"`
+}
+
+type AuthConfig struct {
+	JWTSecret       string   `yaml:"jwt_secret"`
+	AccessTokenTTL  Duration `yaml:"access_token_ttl"`
+	RefreshTokenTTL Duration `yaml:"refresh_token_ttl"`
+}
+
+type TrashConfig struct {
+	// Retention is how long deleted tasks stay in the trash before the
+	// background job purges them; 0 keeps them forever.
+	Retention Duration `yaml:"retention"`
+	// PurgeInterval is how often the job looks for expired tasks.
+	PurgeInterval Duration `yaml:"purge_interval"`
+}
+
+type TasksConfig struct {
+	// AllowOpenSubtasks lets a task be completed while some of its
+	// subtasks are still open.
+	AllowOpenSubtasks bool              `yaml:"allow_completing_with_open_subtasks"`
+	Urgency           UrgencyConfig     `yaml:"urgency"`
+	Transitions       TransitionsConfig `yaml:"transitions"`
+}
+
+// TransitionsConfig is the status workflow: for each status, the statuses
+// a task may move to. Statuses are matched like in the API, so
+// "in_progress" names "In Progress". On the command line and in the
+// environment it is written "pending=in_progress,blocked;completed=".
+// A configured table replaces the default one as a whole.
+type TransitionsConfig map[string][]string
+
+func (t TransitionsConfig) String() string {
+	from := make([]string, 0, len(t))
+	for f := range t {
+		from = append(from, f)
+	}
+	sort.Strings(from)
+	rules := make([]string, len(from))
+	for i, f := range from {
+		rules[i] = f + "=" + strings.Join(t[f], ",")
+	}
+	return strings.Join(rules, ";")
+}
+
+// Set parses the command-line form.
+func (t *TransitionsConfig) Set(s string) error {
+	parsed := TransitionsConfig{}
+	for _, rule := range strings.Split(s, ";") {
+		if strings.TrimSpace(rule) == "" {
+			continue
+		}
+		from, to, ok := strings.Cut(rule, "=")
+		if !ok {
+			return fmt.Errorf("transition %q: want status=status,status", rule)
+		}
+		from = strings.TrimSpace(from)
+		parsed[from] = []string{}
+		for _, next := range strings.Split(to, ",") {
+			if next = strings.TrimSpace(next); next != "" {
+				parsed[from] = append(parsed[from], next)
+			}
+		}
+	}
+	*t = parsed
+	return nil
+}
+
+// UnmarshalYAML replaces the table instead of merging it into the default.
+func (t *TransitionsConfig) UnmarshalYAML(node *yaml.Node) error {
+	var m map[string][]string
+	if err := node.Decode(&m); err != nil {
+		return err
+	}
+	*t = m
+	return nil
+}
+
+// Table converts the configured workflow for the use case. It fails on
+// unknown statuses and on a status listed twice.
+func (t TransitionsConfig) Table() (domain.TransitionTable, error) {
+	table := domain.TransitionTable{}
+	for from, to := range t {
+		st, ok := domain.ParseTaskStatus(from)
+		if !ok {
+			return nil, fmt.Errorf("unknown status %q", from)
+		}
+		if _, dup := table[st]; dup {
+			return nil, fmt.Errorf("status %q is listed twice", st)
+		}
+		table[st] = make([]domain.TaskStatus, 0, len(to))
+		for _, next := range to {
+			nst, ok := domain.ParseTaskStatus(next)
+			if !ok {
+				return nil, fmt.Errorf("unknown status %q", next)
+			}
+			table[st] = append(table[st], nst)
+		}
+	}
+	return table, nil
+}
+
+func transitionsConfig(table domain.TransitionTable) TransitionsConfig {
+	t := make(TransitionsConfig, len(table))
+	for from, to := range table {
+		t[string(from)] = make([]string, len(to))
+		for i, next := range to {
+			t[string(from)][i] = string(next)
+		}
+	}
+	return t
+}
+
+// UrgencyConfig holds the weights of the urgency score; see
+// domain.UrgencyWeights.
+type UrgencyConfig struct {
+	Priority float64 `yaml:"priority"`
+	DueDate  float64 `yaml:"due_date"`
+	Age      float64 `yaml:"age"`
+	Blocked  float64 `yaml:"blocked"`
+}
+
+// Weights converts the configured weights for the use case.
+func (c UrgencyConfig) Weights() domain.UrgencyWeights {
+	return domain.UrgencyWeights{Priority: c.Priority, DueDate: c.DueDate, Age: c.Age, Blocked: c.Blocked}
+}
+
+// Duration is a time.Duration written as "15m" in config files.
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return d.String(), nil
+}
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	v, err := time.ParseDuration(node.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// DefaultConfig is a local development setup.
+func DefaultConfig() Config {
+	return Config{
+		Env:    "dev",
+		Server: ServerConfig{Addr: ":8080", ShutdownTimeout: Duration(15 * time.Second)},
+		Storage: StorageConfig{
+			Backend:       "mongo",
+			SQLDSN:        "task_manager.db",
+			MongoURI:      "mongodb://localhost:27017",
+			MongoDatabase: "task_manager",
+			QueryTimeout:  Duration(5 * time.Second),
+		},
+		Auth: AuthConfig{
+			JWTSecret:       DefaultJWTSecret,
+			AccessTokenTTL:  Duration(15 * time.Minute),
+			RefreshTokenTTL: Duration(7 * 24 * time.Hour),
+		},
+		Trash: TrashConfig{
+			Retention:     Duration(30 * 24 * time.Hour),
+			PurgeInterval: Duration(time.Hour),
+		},
+		Tasks: TasksConfig{
+			Urgency:     UrgencyConfig(domain.DefaultUrgencyWeights()),
+			Transitions: transitionsConfig(domain.DefaultTransitions()),
+		},
+	}
+}
+
+// LoadOptions are the flags that control loading rather than the
+// configuration itself.
+type LoadOptions struct {
+	ConfigFile  string
+	PrintConfig bool
+}
+
+// LoadConfig resolves the configuration from args (without the program
+// name) and getenv. The result is not validated.
+func LoadConfig(args []string, getenv func(string) string) (Config, LoadOptions, error) {
+	var opts LoadOptions
+	var fromFlags Config
+	fs := newConfigFlagSet(&fromFlags, &opts)
+	if err := fs.Parse(args); err != nil {
+		return Config{}, opts, err
+	}
+	if opts.ConfigFile == "" {
+		opts.ConfigFile = getenv("TASK_MANAGER_CONFIG")
+	}
+
+	cfg := DefaultConfig()
+	if opts.ConfigFile != "" {
+		if err := loadConfigFile(opts.ConfigFile, &cfg); err != nil {
+			return Config{}, opts, err
+		}
+	}
+	if err := applyConfigEnv(&cfg, getenv); err != nil {
+		return Config{}, opts, err
+	}
+
+	// replay only the flags given explicitly so their defaults do not
+	// clobber file and environment values
+	apply := newConfigFlagSet(&cfg, &LoadOptions{})
+	var setErr error
+	fs.Visit(func(f *flag.Flag) {
+		if err := apply.Set(f.Name, f.Value.String()); err != nil && setErr == nil {
+			setErr = err
+		}
+	})
+	return cfg, opts, setErr
+}
+
+func newConfigFlagSet(cfg *Config, opts *LoadOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("task-manager", flag.ContinueOnError)
+	fs.StringVar(&opts.ConfigFile, "config", "", "path to a YAML config file (env TASK_MANAGER_CONFIG)")
+	fs.BoolVar(&opts.PrintConfig, "print-config", false, "print the effective configuration with secrets redacted and exit")
+	fs.StringVar(&cfg.Env, "env", cfg.Env, `"dev" or "prod"`)
+	fs.StringVar(&cfg.Server.Addr, "addr", cfg.Server.Addr, "HTTP listen address")
+	fs.DurationVar((*time.Duration)(&cfg.Server.ShutdownTimeout), "shutdown-timeout", time.Duration(cfg.Server.ShutdownTimeout), "grace period for in-flight requests on shutdown")
+	fs.StringVar(&cfg.Storage.Backend, "storage", cfg.Storage.Backend, `repository backend: "mongo", "sqlite", "postgres" or "memory"`)
+	fs.StringVar(&cfg.Storage.SQLDSN, "sql-dsn", cfg.Storage.SQLDSN, "data source name for the sqlite and postgres backends")
+	fs.StringVar(&cfg.Storage.MongoURI, "mongo-uri", cfg.Storage.MongoURI, "MongoDB connection string")
+	fs.StringVar(&cfg.Storage.MongoDatabase, "mongo-database", cfg.Storage.MongoDatabase, "MongoDB database name")
+	fs.DurationVar((*time.Duration)(&cfg.Storage.QueryTimeout), "query-timeout", time.Duration(cfg.Storage.QueryTimeout), "deadline for the storage work of one request")
+	fs.StringVar(&cfg.Auth.JWTSecret, "jwt-secret", cfg.Auth.JWTSecret, "HMAC secret for access tokens (prefer TASK_MANAGER_JWT_SECRET)")
+	fs.DurationVar((*time.Duration)(&cfg.Auth.AccessTokenTTL), "access-token-ttl", time.Duration(cfg.Auth.AccessTokenTTL), "access token lifetime")
+	fs.DurationVar((*time.Duration)(&cfg.Auth.RefreshTokenTTL), "refresh-token-ttl", time.Duration(cfg.Auth.RefreshTokenTTL), "refresh token lifetime")
+	fs.DurationVar((*time.Duration)(&cfg.Trash.Retention), "trash-retention", time.Duration(cfg.Trash.Retention), "how long deleted tasks are kept before being purged (0 keeps them)")
+	fs.DurationVar((*time.Duration)(&cfg.Trash.PurgeInterval), "trash-purge-interval", time.Duration(cfg.Trash.PurgeInterval), "how often expired tasks are purged from the trash")
+	fs.BoolVar(&cfg.Tasks.AllowOpenSubtasks, "allow-completing-with-open-subtasks", cfg.Tasks.AllowOpenSubtasks, "let tasks be completed while some of their subtasks are still open")
+	fs.Float64Var(&cfg.Tasks.Urgency.Priority, "urgency-priority-weight", cfg.Tasks.Urgency.Priority, "weight of priority in the urgency score")
+	fs.Float64Var(&cfg.Tasks.Urgency.DueDate, "urgency-due-date-weight", cfg.Tasks.Urgency.DueDate, "weight of the due date in the urgency score")
+	fs.Float64Var(&cfg.Tasks.Urgency.Age, "urgency-age-weight", cfg.Tasks.Urgency.Age, "weight of task age in the urgency score")
+	fs.Float64Var(&cfg.Tasks.Urgency.Blocked, "urgency-blocked-weight", cfg.Tasks.Urgency.Blocked, "weight of the blocked status in the urgency score (negative lowers it)")
+	fs.Var(&cfg.Tasks.Transitions, "task-transitions", `allowed status changes, e.g. "pending=in_progress,cancelled;cancelled=pending"`)
+	return fs
+}
+
+func loadConfigFile(path string, cfg *Config) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func applyConfigEnv(cfg *Config, getenv func(string) string) error {
+	for name, dst := range map[string]*string{
+		"TASK_MANAGER_ENV":            &cfg.Env,
+		"TASK_MANAGER_ADDR":           &cfg.Server.Addr,
+		"TASK_MANAGER_STORAGE":        &cfg.Storage.Backend,
+		"TASK_MANAGER_SQL_DSN":        &cfg.Storage.SQLDSN,
+		"TASK_MANAGER_MONGO_URI":      &cfg.Storage.MongoURI,
+		"TASK_MANAGER_MONGO_DATABASE": &cfg.Storage.MongoDatabase,
+		"TASK_MANAGER_JWT_SECRET":     &cfg.Auth.JWTSecret,
+	} {
+		if v := getenv(name); v != "" {
+			*dst = v
+		}
+	}
+	for name, dst := range map[string]*Duration{
+		"TASK_MANAGER_SHUTDOWN_TIMEOUT":     &cfg.Server.ShutdownTimeout,
+		"TASK_MANAGER_QUERY_TIMEOUT":        &cfg.Storage.QueryTimeout,
+		"TASK_MANAGER_ACCESS_TOKEN_TTL":     &cfg.Auth.AccessTokenTTL,
+		"TASK_MANAGER_REFRESH_TOKEN_TTL":    &cfg.Auth.RefreshTokenTTL,
+		"TASK_MANAGER_TRASH_RETENTION":      &cfg.Trash.Retention,
+		"TASK_MANAGER_TRASH_PURGE_INTERVAL": &cfg.Trash.PurgeInterval,
+	} {
+		if v := getenv(name); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			*dst = Duration(d)
+		}
+	}
+	for name, dst := range map[string]*bool{
+		"TASK_MANAGER_ALLOW_COMPLETING_WITH_OPEN_SUBTASKS": &cfg.Tasks.AllowOpenSubtasks,
+	} {
+		if v := getenv(name); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			*dst = b
+		}
+	}
+	if v := getenv("TASK_MANAGER_TASK_TRANSITIONS"); v != "" {
+		if err := cfg.Tasks.Transitions.Set(v); err != nil {
+			return fmt.Errorf("TASK_MANAGER_TASK_TRANSITIONS: %w", err)
+		}
+	}
+	for name, dst := range map[string]*float64{
+		"TASK_MANAGER_URGENCY_PRIORITY_WEIGHT": &cfg.Tasks.Urgency.Priority,
+		"TASK_MANAGER_URGENCY_DUE_DATE_WEIGHT": &cfg.Tasks.Urgency.DueDate,
+		"TASK_MANAGER_URGENCY_AGE_WEIGHT":      &cfg.Tasks.Urgency.Age,
+		"TASK_MANAGER_URGENCY_BLOCKED_WEIGHT":  &cfg.Tasks.Urgency.Blocked,
+	} {
+		if v := getenv(name); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			*dst = f
+		}
+	}
+	return nil
+}
+
+// Validate reports every problem with the configuration at once.
+func (c Config) Validate() error {
+	var errs []error
+	if c.Env != "dev" && c.Env != "prod" {
+		errs = append(errs, fmt.Errorf("env must be \"dev\" or \"prod\", got %q", c.Env))
+	}
+	if c.Server.Addr == "" {
+		errs = append(errs, errors.New("server.addr is required"))
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("server.shutdown_timeout must be positive"))
+	}
+	switch c.Storage.Backend {
+	case "memory":
+	case "mongo":
+		if c.Storage.MongoURI == "" || c.Storage.MongoDatabase == "" {
+			errs = append(errs, errors.New("storage.mongo_uri and storage.mongo_database are required for the mongo backend"))
+		}
+	case "sqlite", "postgres":
+		if c.Storage.SQLDSN == "" {
+			errs = append(errs, errors.New("storage.sql_dsn is required for SQL backends"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown storage backend %q", c.Storage.Backend))
+	}
+	if c.Storage.QueryTimeout <= 0 {
+		errs = append(errs, errors.New("storage.query_timeout must be positive"))
+	}
+	if c.Auth.JWTSecret == "" {
+		errs = append(errs, errors.New("auth.jwt_secret is required"))
+	} else if c.Env != "dev" && c.Auth.JWTSecret == DefaultJWTSecret {
+		errs = append(errs, errors.New("auth.jwt_secret must be changed from the default outside dev mode"))
+	}
+	if c.Auth.AccessTokenTTL <= 0 || c.Auth.RefreshTokenTTL <= 0 {
+		errs = append(errs, errors.New("token lifetimes must be positive"))
+	} else if c.Auth.AccessTokenTTL >= c.Auth.RefreshTokenTTL {
+		errs = append(errs, errors.New("auth.access_token_ttl must be shorter than auth.refresh_token_ttl"))
+	}
+	if c.Trash.Retention < 0 {
+		errs = append(errs, errors.New("trash.retention must not be negative"))
+	}
+	if c.Trash.PurgeInterval <= 0 {
+		errs = append(errs, errors.New("trash.purge_interval must be positive"))
+	}
+	u := c.Tasks.Urgency
+	for _, w := range []float64{u.Priority, u.DueDate, u.Age, u.Blocked} {
+		if math.IsNaN(w) || math.IsInf(w, 0) {
+			errs = append(errs, errors.New("tasks.urgency weights must be finite numbers"))
+			break
+		}
+	}
+	if len(c.Tasks.Transitions) == 0 {
+		errs = append(errs, errors.New("tasks.transitions must list at least one status"))
+	} else if _, err := c.Tasks.Transitions.Table(); err != nil {
+		errs = append(errs, fmt.Errorf("tasks.transitions: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+const redacted = "REDACTED"
+
+var dsnPassword = regexp.MustCompile(`(?i)(password=)([^\s&]+)`)
+
+// Redacted returns a copy safe to print: the JWT secret and any password
+// embedded in a connection string are masked.
+func (c Config) Redacted() Config {
+	if c.Auth.JWTSecret != "" {
+		c.Auth.JWTSecret = redacted
+	}
+	c.Storage.MongoURI = redactDSN(c.Storage.MongoURI)
+	c.Storage.SQLDSN = redactDSN(c.Storage.SQLDSN)
+	return c
+}
+
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+			dsn = u.String()
+		}
+	}
+	return dsnPassword.ReplaceAllString(dsn, "${1}"+redacted)
+}
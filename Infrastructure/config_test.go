@@ -0,0 +1,192 @@
+package Infrastructure
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+func envMap(m map[string]string) func(string) string {
+	return func(k string) string { return m[k] }
+}
+
+func writeConfigFile(t *testing.T, body string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(body), 0o600))
+	return path
+}
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	cfg, opts, err := LoadConfig(nil, envMap(nil))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultConfig(), cfg)
+	assert.False(t, opts.PrintConfig)
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestLoadConfig_Precedence(t *testing.T) {
+	path := writeConfigFile(t, `
+env: prod
+server:
+  addr: ":9000"
+storage:
+  backend: sqlite
+  sql_dsn: file.db
+auth:
+  jwt_secret: from-file
+  access_token_ttl: 5m
+`)
+	env := envMap(map[string]string{
+		"TASK_MANAGER_CONFIG":     path,
+		"TASK_MANAGER_SQL_DSN":    "env.db",
+		"TASK_MANAGER_JWT_SECRET": "from-env",
+	})
+
+	cfg, opts, err := LoadConfig([]string{"-sql-dsn", "flag.db", "-print-config"}, env)
+	require.NoError(t, err)
+	assert.Equal(t, path, opts.ConfigFile)
+	assert.True(t, opts.PrintConfig)
+
+	assert.Equal(t, "prod", cfg.Env, "file overrides default")
+	assert.Equal(t, ":9000", cfg.Server.Addr)
+	assert.Equal(t, "sqlite", cfg.Storage.Backend)
+	assert.Equal(t, Duration(5*time.Minute), cfg.Auth.AccessTokenTTL)
+	assert.Equal(t, "from-env", cfg.Auth.JWTSecret, "env overrides file")
+	assert.Equal(t, "flag.db", cfg.Storage.SQLDSN, "flag overrides env")
+	assert.Equal(t, DefaultConfig().Auth.RefreshTokenTTL, cfg.Auth.RefreshTokenTTL, "unset values keep their default")
+}
+
+func TestLoadConfig_UnknownFileKey(t *testing.T) {
+	path := writeConfigFile(t, "auth:\n  jwt_secrte: typo\n")
+	_, _, err := LoadConfig([]string{"-config", path}, envMap(nil))
+	assert.Error(t, err)
+}
+
+func TestLoadConfig_BoolSettings(t *testing.T) {
+	cfg, _, err := LoadConfig(nil, envMap(map[string]string{"TASK_MANAGER_ALLOW_COMPLETING_WITH_OPEN_SUBTASKS": "true"}))
+	require.NoError(t, err)
+	assert.True(t, cfg.Tasks.AllowOpenSubtasks)
+
+	cfg, _, err = LoadConfig([]string{"-allow-completing-with-open-subtasks=false"},
+		envMap(map[string]string{"TASK_MANAGER_ALLOW_COMPLETING_WITH_OPEN_SUBTASKS": "true"}))
+	require.NoError(t, err)
+	assert.False(t, cfg.Tasks.AllowOpenSubtasks, "flag overrides env")
+
+	_, _, err = LoadConfig(nil, envMap(map[string]string{"TASK_MANAGER_ALLOW_COMPLETING_WITH_OPEN_SUBTASKS": "maybe"}))
+	assert.ErrorContains(t, err, "TASK_MANAGER_ALLOW_COMPLETING_WITH_OPEN_SUBTASKS")
+}
+
+func TestLoadConfig_UrgencyWeights(t *testing.T) {
+	cfg, _, err := LoadConfig([]string{"-urgency-blocked-weight=-1.5"},
+		envMap(map[string]string{"TASK_MANAGER_URGENCY_DUE_DATE_WEIGHT": "20", "TASK_MANAGER_URGENCY_BLOCKED_WEIGHT": "-8"}))
+	require.NoError(t, err)
+	w := cfg.Tasks.Urgency.Weights()
+	assert.Equal(t, domain.DefaultUrgencyWeights().Priority, w.Priority)
+	assert.Equal(t, 20.0, w.DueDate)
+	assert.Equal(t, -1.5, w.Blocked, "flag overrides env")
+
+	_, _, err = LoadConfig(nil, envMap(map[string]string{"TASK_MANAGER_URGENCY_AGE_WEIGHT": "high"}))
+	assert.ErrorContains(t, err, "TASK_MANAGER_URGENCY_AGE_WEIGHT")
+}
+
+func TestLoadConfig_Transitions(t *testing.T) {
+	cfg, _, err := LoadConfig(nil, envMap(nil))
+	require.NoError(t, err)
+	table, err := cfg.Tasks.Transitions.Table()
+	require.NoError(t, err)
+	assert.Equal(t, domain.DefaultTransitions(), table)
+
+	path := writeConfigFile(t, "tasks:\n  transitions:\n    pending: [in_progress]\n    in_progress: [completed]\n")
+	cfg, _, err = LoadConfig([]string{"-config", path}, envMap(nil))
+	require.NoError(t, err)
+	table, err = cfg.Tasks.Transitions.Table()
+	require.NoError(t, err)
+	assert.Equal(t, domain.TransitionTable{
+		domain.StatusPending:    {domain.StatusInProgress},
+		domain.StatusInProgress: {domain.StatusCompleted},
+	}, table, "the file replaces the default table")
+
+	cfg, _, err = LoadConfig([]string{"-task-transitions", "pending=blocked;blocked="},
+		envMap(map[string]string{"TASK_MANAGER_TASK_TRANSITIONS": "pending=cancelled"}))
+	require.NoError(t, err)
+	table, err = cfg.Tasks.Transitions.Table()
+	require.NoError(t, err)
+	assert.Equal(t, domain.TransitionTable{
+		domain.StatusPending: {domain.StatusBlocked},
+		domain.StatusBlocked: {},
+	}, table, "flag overrides env")
+
+	_, _, err = LoadConfig(nil, envMap(map[string]string{"TASK_MANAGER_TASK_TRANSITIONS": "pending"}))
+	assert.ErrorContains(t, err, "TASK_MANAGER_TASK_TRANSITIONS")
+}
+
+func TestConfigValidate(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Env = "prod"
+	assert.ErrorContains(t, cfg.Validate(), "jwt_secret must be changed")
+
+	cfg.Auth.JWTSecret = "a-real-secret"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Storage.Backend = "redis"
+	cfg.Auth.AccessTokenTTL = cfg.Auth.RefreshTokenTTL
+	cfg.Trash.PurgeInterval = 0
+	cfg.Tasks.Urgency.Age = math.NaN()
+	cfg.Tasks.Transitions = TransitionsConfig{"pending": {"done"}}
+	err := cfg.Validate()
+	assert.ErrorContains(t, err, "unknown storage backend")
+	assert.ErrorContains(t, err, "shorter than")
+	assert.ErrorContains(t, err, "trash.purge_interval")
+	assert.ErrorContains(t, err, "tasks.urgency")
+	assert.ErrorContains(t, err, `tasks.transitions: unknown status "done"`)
+
+	cfg = DefaultConfig()
+	cfg.Tasks.Transitions = TransitionsConfig{"Pending": {}, "pending": {"blocked"}}
+	assert.ErrorContains(t, cfg.Validate(), "listed twice")
+}
+
+func TestConfigRedacted(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Auth.JWTSecret = "top-secret"
+	cfg.Storage.MongoURI = "mongodb://app:hunter2@db:27017/?authSource=admin"
+	cfg.Storage.SQLDSN = "host=db user=app password=hunter2 sslmode=disable"
+
+	r := cfg.Redacted()
+	assert.Equal(t, "REDACTED", r.Auth.JWTSecret)
+	assert.Equal(t, "mongodb://app:REDACTED@db:27017/?authSource=admin", r.Storage.MongoURI)
+	assert.Equal(t, "host=db user=app password=REDACTED sslmode=disable", r.Storage.SQLDSN)
+	assert.Equal(t, "top-secret", cfg.Auth.JWTSecret, "original is untouched")
+}
+
+func TestRunPeriodically(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := make(chan struct{}, 3)
+	done := make(chan struct{})
+	go func() {
+		RunPeriodically(ctx, "test", time.Millisecond, func(ctx context.Context) error {
+			_, hasDeadline := ctx.Deadline()
+			assert.True(t, hasDeadline)
+			select {
+			case runs <- struct{}{}:
+			default:
+				cancel()
+			}
+			return errors.New("logged and retried")
+		})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunPeriodically did not stop after ctx was cancelled")
+	}
+	assert.Len(t, runs, 3)
+}
@@ -0,0 +1,20 @@
+package Infrastructure
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeadlineMiddleware bounds the request context, and with it every
+// repository call made while serving the request. The context is also
+// cancelled when the client disconnects.
+func DeadlineMiddleware(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,103 @@
+package Infrastructure
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+// Problem is an RFC 7807 problem details body. Code is a stable
+// identifier clients can switch on; Detail is meant for humans.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+	// Errors lists the rejected fields of a validation failure.
+	Errors []domain.FieldError `json:"errors,omitempty"`
+}
+
+const problemContentType = "application/problem+json"
+
+var problemKinds = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{domain.ErrNotFound, http.StatusNotFound, "not_found"},
+	{domain.ErrInvalidID, http.StatusBadRequest, "invalid_id"},
+	{domain.ErrValidation, http.StatusBadRequest, "validation_failed"},
+	{domain.ErrConflict, http.StatusConflict, "conflict"},
+	{domain.ErrPrecondition, http.StatusPreconditionFailed, "precondition_failed"},
+	{domain.ErrForbidden, http.StatusForbidden, "forbidden"},
+	{domain.ErrUnauthorized, http.StatusUnauthorized, "unauthorized"},
+}
+
+// NewProblem describes err for a client. Errors that do not wrap one of
+// the domain error kinds are reported as an opaque 500.
+func NewProblem(err error) Problem {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(http.StatusServiceUnavailable),
+			Status: http.StatusServiceUnavailable,
+			Detail: "the request did not complete within its deadline",
+			Code:   "timeout",
+		}
+	}
+	for _, k := range problemKinds {
+		if !errors.Is(err, k.kind) {
+			continue
+		}
+		p := Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(k.status),
+			Status: k.status,
+			Detail: err.Error(),
+			Code:   k.code,
+		}
+		var de *domain.Error
+		if errors.As(err, &de) {
+			p.Code = de.Code
+		}
+		var ve *domain.ValidationError
+		if errors.As(err, &ve) {
+			p.Detail = "one or more fields are invalid"
+			p.Errors = ve.Fields
+		}
+		return p
+	}
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(http.StatusInternalServerError),
+		Status: http.StatusInternalServerError,
+		Detail: "an unexpected error occurred",
+		Code:   "internal",
+	}
+}
+
+// ErrorMiddleware renders the last error a handler recorded with c.Error
+// as an application/problem+json response. It is the only place errors
+// are turned into HTTP responses.
+func ErrorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+		p := NewProblem(err)
+		if p.Status == http.StatusInternalServerError {
+			log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		}
+		p.Instance = c.Request.URL.Path
+		c.Header("Content-Type", problemContentType)
+		c.JSON(p.Status, p)
+	}
+}
@@ -0,0 +1,27 @@
+package Infrastructure
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// RunPeriodically calls job every interval until ctx is done. Each run
+// gets the interval as its deadline so runs never overlap; failures are
+// logged and the job simply runs again on the next tick.
+func RunPeriodically(ctx context.Context, name string, interval time.Duration, job func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			runCtx, cancel := context.WithTimeout(ctx, interval)
+			if err := job(runCtx); err != nil && ctx.Err() == nil {
+				log.Printf("%s: %v", name, err)
+			}
+			cancel()
+		}
+	}
+}
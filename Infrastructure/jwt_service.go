@@ -1,40 +1,60 @@
 package Infrastructure
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
-	"go.mongodb.org/mongo-driver/bson/primitive"
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
 )
 
 type JWTService struct {
 	secretKey string
 	expiry    time.Duration
+	denylist  domain.TokenDenylist
 }
 type JWTServiceInterface interface {
-	GenerateToken(userID primitive.ObjectID, role string) (string, error)
-	ValidateToken(tokenStr string) (*primitive.ObjectID, string, error)
+	GenerateToken(userID domain.ID, role string, org domain.ID) (string, time.Time, error)
+	ValidateToken(ctx context.Context, tokenStr string) (*domain.AccessClaims, error)
 }
 
-func NewJWTService(secret string, duration time.Duration) JWTServiceInterface {
+// NewJWTService issues access tokens valid for duration. Tokens whose ID is
+// on denylist are rejected; denylist may be nil to disable revocation.
+func NewJWTService(secret string, duration time.Duration, denylist domain.TokenDenylist) JWTServiceInterface {
 	return &JWTService{
 		secretKey: secret,
 		expiry:    duration,
+		denylist:  denylist,
 	}
 }
 
-func (j *JWTService) GenerateToken(userID primitive.ObjectID, role string) (string, error) {
+// GenerateToken signs an access token for userID acting with role in
+// organization org, the tenant every request made with it is scoped to.
+func (j *JWTService) GenerateToken(userID domain.ID, role string, org domain.ID) (string, time.Time, error) {
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", time.Time{}, err
+	}
+	exp := time.Now().Add(j.expiry)
 	claims := jwt.MapClaims{
-		"user_id": userID.Hex(),
+		"user_id": userID.String(),
 		"role":    role,
-		"exp":     time.Now().Add(j.expiry).Unix(),
+		"org_id":  org.String(),
+		"jti":     hex.EncodeToString(jti),
+		"exp":     exp.Unix(),
 	}
 	t := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return t.SignedString([]byte(j.secretKey))
+	signed, err := t.SignedString([]byte(j.secretKey))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, time.Unix(exp.Unix(), 0), nil
 }
 
-func (j *JWTService) ValidateToken(tokenStr string) (*primitive.ObjectID, string, error) {
+func (j *JWTService) ValidateToken(ctx context.Context, tokenStr string) (*domain.AccessClaims, error) {
 	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
@@ -42,28 +62,57 @@ func (j *JWTService) ValidateToken(tokenStr string) (*primitive.ObjectID, string
 		return []byte(j.secretKey), nil
 	})
 	if err != nil || !token.Valid {
-		return nil, "", errors.New("invalid token")
+		return nil, errors.New("invalid token")
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return nil, "", errors.New("invalid claims")
+		return nil, errors.New("invalid claims")
 	}
 
 	idStr, ok := claims["user_id"].(string)
 	if !ok {
-		return nil, "", errors.New("user ID missing in token")
+		return nil, errors.New("user ID missing in token")
 	}
 
 	role, ok := claims["role"].(string)
 	if !ok {
-		return nil, "", errors.New("role missing in token")
+		return nil, errors.New("role missing in token")
+	}
+
+	orgStr, ok := claims["org_id"].(string)
+	if !ok {
+		return nil, errors.New("organization missing in token")
+	}
+
+	jti, ok := claims["jti"].(string)
+	if !ok || jti == "" {
+		return nil, errors.New("token ID missing in token")
 	}
 
-	id, err := primitive.ObjectIDFromHex(idStr)
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return nil, errors.New("expiry missing in token")
+	}
+
+	id, err := domain.ParseID(idStr)
+	if err != nil {
+		return nil, errors.New("invalid user ID in token")
+	}
+	org, err := domain.ParseID(orgStr)
 	if err != nil {
-		return nil, "", errors.New("invalid user ID in token")
+		return nil, errors.New("invalid organization in token")
+	}
+
+	if j.denylist != nil {
+		revoked, err := j.denylist.IsRevoked(ctx, jti)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, errors.New("token has been revoked")
+		}
 	}
 
-	return &id, role, nil
+	return &domain.AccessClaims{UserID: id, Role: role, OrgID: org, TokenID: jti, ExpiresAt: exp.Time}, nil
 }
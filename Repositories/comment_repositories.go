@@ -0,0 +1,199 @@
+package Repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// compile‑time checks for the Mongo comment and notification stores
+var (
+	_ domain.CommentRepository      = (*CommentRepository)(nil)
+	_ domain.NotificationRepository = (*NotificationRepository)(nil)
+)
+
+type CommentRepository struct {
+	Coll *mongo.Collection
+}
+
+func NewCommentRepository(c *mongo.Collection) *CommentRepository {
+	return &CommentRepository{Coll: c}
+}
+
+// EnsureIndexes creates the index behind the per-task comment listing.
+func (r *CommentRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.Coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}},
+	})
+	return err
+}
+
+func (r *CommentRepository) Create(ctx context.Context, c domain.Comment) (*domain.Comment, error) {
+	c.ID = domain.NewID()
+	org, err := tenantOf(ctx, c.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	c.OrgID = org
+	if _, err := r.Coll.InsertOne(ctx, c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *CommentRepository) GetByID(ctx context.Context, id domain.ID) (*domain.Comment, error) {
+	var c domain.Comment
+	if err := r.Coll.FindOne(ctx, tenantFilter(ctx, bson.M{"_id": id})).Decode(&c); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrCommentNotFound
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *CommentRepository) Update(ctx context.Context, c domain.Comment) (*domain.Comment, error) {
+	res, err := r.Coll.UpdateOne(ctx, tenantFilter(ctx, bson.M{"_id": c.ID}), bson.M{"$set": bson.M{
+		"body": c.Body, "updated_at": c.UpdatedAt, "edits": c.Edits,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	if res.MatchedCount == 0 {
+		return nil, domain.ErrCommentNotFound
+	}
+	return r.GetByID(ctx, c.ID)
+}
+
+func (r *CommentRepository) Delete(ctx context.Context, id domain.ID) error {
+	res, err := r.Coll.DeleteOne(ctx, tenantFilter(ctx, bson.M{"_id": id}))
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return domain.ErrCommentNotFound
+	}
+	return nil
+}
+
+func (r *CommentRepository) Find(ctx context.Context, q domain.CommentQuery) (*domain.CommentPage, error) {
+	filter := tenantFilter(ctx, bson.M{"task_id": q.TaskID})
+	if q.Cursor != "" {
+		at, id, err := domain.DecodeCommentCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter["$or"] = []bson.M{
+			{"created_at": bson.M{"$gt": at}},
+			{"created_at": at, "_id": bson.M{"$gt": id}},
+		}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	if q.Limit > 0 {
+		// fetch one extra document to learn whether another page exists
+		opts.SetLimit(int64(q.Limit) + 1)
+	}
+
+	cur, err := r.Coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var comments []domain.Comment
+	if err := cur.All(ctx, &comments); err != nil {
+		return nil, err
+	}
+	page := &domain.CommentPage{Comments: comments}
+	if q.Limit > 0 && len(comments) > q.Limit {
+		page.Comments = comments[:q.Limit]
+		page.NextCursor = domain.EncodeCommentCursor(page.Comments[q.Limit-1])
+	}
+	return page, nil
+}
+
+type NotificationRepository struct {
+	Coll *mongo.Collection
+}
+
+func NewNotificationRepository(c *mongo.Collection) *NotificationRepository {
+	return &NotificationRepository{Coll: c}
+}
+
+// EnsureIndexes creates the index behind a user's notification listing.
+func (r *NotificationRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.Coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+	})
+	return err
+}
+
+func (r *NotificationRepository) Create(ctx context.Context, n domain.Notification) (*domain.Notification, error) {
+	n.ID = domain.NewID()
+	org, err := tenantOf(ctx, n.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	n.OrgID = org
+	if _, err := r.Coll.InsertOne(ctx, n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+func (r *NotificationRepository) Find(ctx context.Context, q domain.NotificationQuery) (*domain.NotificationPage, error) {
+	filter := tenantFilter(ctx, bson.M{"user_id": q.UserID})
+	if q.Unread {
+		filter["read_at"] = nil
+	}
+	if q.Cursor != "" {
+		at, id, err := domain.DecodeNotificationCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter["$or"] = []bson.M{
+			{"created_at": bson.M{"$lt": at}},
+			{"created_at": at, "_id": bson.M{"$lt": id}},
+		}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+	if q.Limit > 0 {
+		// fetch one extra document to learn whether another page exists
+		opts.SetLimit(int64(q.Limit) + 1)
+	}
+
+	cur, err := r.Coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var notifications []domain.Notification
+	if err := cur.All(ctx, &notifications); err != nil {
+		return nil, err
+	}
+	page := &domain.NotificationPage{Notifications: notifications}
+	if q.Limit > 0 && len(notifications) > q.Limit {
+		page.Notifications = notifications[:q.Limit]
+		page.NextCursor = domain.EncodeNotificationCursor(page.Notifications[q.Limit-1])
+	}
+	return page, nil
+}
+
+func (r *NotificationRepository) MarkRead(ctx context.Context, user, id domain.ID, at time.Time) (*domain.Notification, error) {
+	if _, err := r.Coll.UpdateOne(ctx,
+		tenantFilter(ctx, bson.M{"_id": id, "user_id": user, "read_at": nil}),
+		bson.M{"$set": bson.M{"read_at": at}},
+	); err != nil {
+		return nil, err
+	}
+	var n domain.Notification
+	if err := r.Coll.FindOne(ctx, tenantFilter(ctx, bson.M{"_id": id, "user_id": user})).Decode(&n); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrNotificationNotFound
+		}
+		return nil, err
+	}
+	return &n, nil
+}
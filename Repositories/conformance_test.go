@@ -0,0 +1,1455 @@
+package Repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
+)
+
+// Every repository implementation runs the same suite. The Mongo and
+// Postgres variants only run when TEST_MONGO_URI / TEST_POSTGRES_DSN
+// point at a reachable server.
+
+func TestInMemoryTaskRepository(t *testing.T) {
+	testTaskRepository(t, func(t *testing.T) domain.TaskRepository {
+		return NewInMemoryTaskRepository()
+	})
+}
+
+func TestInMemoryUserRepository(t *testing.T) {
+	testUserRepository(t, func(t *testing.T) domain.UserRepository {
+		return NewInMemoryUserRepository()
+	})
+}
+
+func TestMongoTaskRepository(t *testing.T) {
+	testTaskRepository(t, func(t *testing.T) domain.TaskRepository {
+		repo := NewTaskRepository(mongoTestDB(t).Collection("tasks"))
+		require.NoError(t, repo.EnsureIndexes(context.Background()))
+		return repo
+	})
+}
+
+func TestMongoUserRepository(t *testing.T) {
+	testUserRepository(t, func(t *testing.T) domain.UserRepository {
+		repo := NewUserRepository(mongoTestDB(t).Collection("users"))
+		require.NoError(t, repo.EnsureIndexes(context.Background()))
+		return repo
+	})
+}
+
+func TestSQLiteTaskRepository(t *testing.T) {
+	testTaskRepository(t, func(t *testing.T) domain.TaskRepository {
+		return NewSQLTaskRepository(sqliteTestDB(t), DialectSQLite)
+	})
+}
+
+func TestSQLiteUserRepository(t *testing.T) {
+	testUserRepository(t, func(t *testing.T) domain.UserRepository {
+		return NewSQLUserRepository(sqliteTestDB(t), DialectSQLite)
+	})
+}
+
+func TestPostgresTaskRepository(t *testing.T) {
+	testTaskRepository(t, func(t *testing.T) domain.TaskRepository {
+		return NewSQLTaskRepository(postgresTestDB(t), DialectPostgres)
+	})
+}
+
+func TestPostgresUserRepository(t *testing.T) {
+	testUserRepository(t, func(t *testing.T) domain.UserRepository {
+		return NewSQLUserRepository(postgresTestDB(t), DialectPostgres)
+	})
+}
+
+func TestTokenStores(t *testing.T) {
+	backends := []struct {
+		name     string
+		refresh  func(t *testing.T) domain.RefreshTokenRepository
+		denylist func(t *testing.T) domain.TokenDenylist
+	}{
+		{
+			"InMemory",
+			func(t *testing.T) domain.RefreshTokenRepository { return NewInMemoryRefreshTokenRepository() },
+			func(t *testing.T) domain.TokenDenylist { return NewInMemoryTokenDenylist() },
+		},
+		{
+			"SQLite",
+			func(t *testing.T) domain.RefreshTokenRepository {
+				return NewSQLRefreshTokenRepository(sqliteTestDB(t), DialectSQLite)
+			},
+			func(t *testing.T) domain.TokenDenylist { return NewSQLTokenDenylist(sqliteTestDB(t), DialectSQLite) },
+		},
+		{
+			"Postgres",
+			func(t *testing.T) domain.RefreshTokenRepository {
+				return NewSQLRefreshTokenRepository(postgresTestDB(t), DialectPostgres)
+			},
+			func(t *testing.T) domain.TokenDenylist { return NewSQLTokenDenylist(postgresTestDB(t), DialectPostgres) },
+		},
+		{
+			"Mongo",
+			func(t *testing.T) domain.RefreshTokenRepository {
+				repo := NewRefreshTokenRepository(mongoTestDB(t).Collection("refresh_tokens"))
+				require.NoError(t, repo.EnsureIndexes(context.Background()))
+				return repo
+			},
+			func(t *testing.T) domain.TokenDenylist {
+				return NewTokenDenylist(mongoTestDB(t).Collection("revoked_access_tokens"))
+			},
+		},
+	}
+	for _, b := range backends {
+		t.Run(b.name+"RefreshTokens", func(t *testing.T) { testRefreshTokenRepository(t, b.refresh) })
+		t.Run(b.name+"Denylist", func(t *testing.T) { testTokenDenylist(t, b.denylist) })
+	}
+}
+
+func TestHistoryStores(t *testing.T) {
+	backends := map[string]func(t *testing.T) domain.HistoryRepository{
+		"InMemory": func(t *testing.T) domain.HistoryRepository { return NewInMemoryHistoryRepository() },
+		"SQLite": func(t *testing.T) domain.HistoryRepository {
+			return NewSQLHistoryRepository(sqliteTestDB(t), DialectSQLite)
+		},
+		"Postgres": func(t *testing.T) domain.HistoryRepository {
+			return NewSQLHistoryRepository(postgresTestDB(t), DialectPostgres)
+		},
+		"Mongo": func(t *testing.T) domain.HistoryRepository {
+			repo := NewHistoryRepository(mongoTestDB(t).Collection("task_history"))
+			require.NoError(t, repo.EnsureIndexes(context.Background()))
+			return repo
+		},
+	}
+	for name, newRepo := range backends {
+		t.Run(name, func(t *testing.T) { testHistoryRepository(t, newRepo) })
+	}
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	db := sqliteTestDB(t)
+	require.NoError(t, Migrate(context.Background(), db, DialectSQLite))
+
+	var n int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&n))
+	assert.Equal(t, len(migrations), n)
+}
+
+// sqliteTestDB returns a migrated database in a temporary file.
+func sqliteTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	require.NoError(t, Migrate(context.Background(), db, DialectSQLite))
+	return db
+}
+
+// postgresTestDB returns a migrated database in a throwaway schema.
+func postgresTestDB(t *testing.T) *sql.DB {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN not set")
+	}
+	ctx := domain.WithoutTenant(context.Background())
+	admin, err := sql.Open("pgx", dsn)
+	require.NoError(t, err)
+	schema := "task_manager_test_" + domain.NewID().String()
+	_, err = admin.ExecContext(ctx, "CREATE SCHEMA "+schema)
+	require.NoError(t, err)
+
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	db, err := sql.Open("pgx", dsn+sep+"search_path="+schema)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+		_, _ = admin.ExecContext(ctx, "DROP SCHEMA "+schema+" CASCADE")
+		_ = admin.Close()
+	})
+	require.NoError(t, Migrate(ctx, db, DialectPostgres))
+	return db
+}
+
+// mongoTestDB returns a throwaway database that is dropped after the test.
+func mongoTestDB(t *testing.T) *mongo.Database {
+	uri := os.Getenv("TEST_MONGO_URI")
+	if uri == "" {
+		t.Skip("TEST_MONGO_URI not set")
+	}
+	ctx := domain.WithoutTenant(context.Background())
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	require.NoError(t, err)
+	db := client.Database(fmt.Sprintf("task_manager_test_%s", domain.NewID()))
+	t.Cleanup(func() {
+		_ = db.Drop(ctx)
+		_ = client.Disconnect(ctx)
+	})
+	return db
+}
+
+func testTaskRepository(t *testing.T, newRepo func(t *testing.T) domain.TaskRepository) {
+	ctx := domain.WithoutTenant(context.Background())
+	ownerA, ownerB := domain.NewID(), domain.NewID()
+	base := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Ping", func(t *testing.T) {
+		assert.NoError(t, newRepo(t).Ping(ctx))
+	})
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+		created, err := repo.Create(ctx, domain.Task{Title: "A", OwnerID: ownerA, Status: domain.StatusPending, DueDate: base})
+		require.NoError(t, err)
+		assert.False(t, created.TaskID.IsZero())
+		assert.False(t, created.CreatedAt.IsZero())
+
+		got, err := repo.GetByID(ctx, created.TaskID)
+		require.NoError(t, err)
+		assert.Equal(t, "A", got.Title)
+		assert.Equal(t, ownerA, got.OwnerID)
+		assert.Equal(t, domain.StatusPending, got.Status)
+		assert.True(t, got.DueDate.Equal(base))
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := newRepo(t)
+		id := domain.NewID()
+		_, err := repo.GetByID(ctx, id)
+		assert.ErrorIs(t, err, domain.ErrTaskNotFound)
+		_, err = repo.Update(ctx, id, domain.Task{Title: "x"})
+		assert.ErrorIs(t, err, domain.ErrTaskNotFound)
+		assert.ErrorIs(t, repo.Delete(ctx, id, 1), domain.ErrTaskNotFound)
+	})
+
+	t.Run("UpdateKeepsOwnerAndCreatedAt", func(t *testing.T) {
+		repo := newRepo(t)
+		created, err := repo.Create(ctx, domain.Task{Title: "A", OwnerID: ownerA, Status: domain.StatusPending})
+		require.NoError(t, err)
+		done := base
+		updated, err := repo.Update(ctx, created.TaskID, domain.Task{
+			Title:       "B",
+			OwnerID:     ownerB,
+			Status:      domain.StatusCompleted,
+			CompletedAt: &done,
+			Version:     created.Version,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "B", updated.Title)
+		assert.Equal(t, ownerA, updated.OwnerID)
+		assert.Equal(t, domain.StatusCompleted, updated.Status)
+		require.NotNil(t, updated.CompletedAt)
+		assert.True(t, updated.CompletedAt.Equal(done))
+		assert.WithinDuration(t, created.CreatedAt, updated.CreatedAt, time.Millisecond)
+	})
+
+	t.Run("UpdateChecksVersion", func(t *testing.T) {
+		repo := newRepo(t)
+		created, err := repo.Create(ctx, domain.Task{Title: "A", OwnerID: ownerA})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), created.Version)
+
+		first := *created
+		first.Title = "B"
+		updated, err := repo.Update(ctx, created.TaskID, first)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), updated.Version)
+
+		stale := *created
+		stale.Title = "C"
+		_, err = repo.Update(ctx, created.TaskID, stale)
+		assert.ErrorIs(t, err, domain.ErrVersionConflict)
+		got, err := repo.GetByID(ctx, created.TaskID)
+		require.NoError(t, err)
+		assert.Equal(t, "B", got.Title, "a stale update is not applied")
+		assert.Equal(t, int64(2), got.Version)
+	})
+
+	t.Run("DeleteChecksVersion", func(t *testing.T) {
+		repo := newRepo(t)
+		created, err := repo.Create(ctx, domain.Task{Title: "A", OwnerID: ownerA})
+		require.NoError(t, err)
+		edit := *created
+		edit.Title = "B"
+		updated, err := repo.Update(ctx, created.TaskID, edit)
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, repo.Delete(ctx, created.TaskID, created.Version), domain.ErrVersionConflict)
+		got, err := repo.GetByID(ctx, created.TaskID)
+		require.NoError(t, err)
+		assert.Nil(t, got.DeletedAt, "a stale delete is not applied")
+		assert.Equal(t, updated.Version, got.Version)
+		require.NoError(t, repo.Delete(ctx, created.TaskID, updated.Version))
+	})
+
+	t.Run("DeleteMovesToTrash", func(t *testing.T) {
+		repo := newRepo(t)
+		created, err := repo.Create(ctx, domain.Task{Title: "A", OwnerID: ownerA})
+		require.NoError(t, err)
+		require.NoError(t, repo.Delete(ctx, created.TaskID, created.Version))
+
+		trashed, err := repo.GetByID(ctx, created.TaskID)
+		require.NoError(t, err)
+		require.NotNil(t, trashed.DeletedAt)
+		assert.Equal(t, created.Version+1, trashed.Version)
+		assert.ErrorIs(t, repo.Delete(ctx, created.TaskID, trashed.Version), domain.ErrTaskNotFound, "already in the trash")
+
+		live, err := repo.Find(ctx, domain.TaskQuery{})
+		require.NoError(t, err)
+		assert.Empty(t, live.Tasks)
+		trash, err := repo.Find(ctx, domain.TaskQuery{Trashed: true, OwnerID: &ownerA})
+		require.NoError(t, err)
+		require.Len(t, trash.Tasks, 1)
+		assert.Equal(t, created.TaskID, trash.Tasks[0].TaskID)
+	})
+
+	t.Run("RestoreAndPurge", func(t *testing.T) {
+		repo := newRepo(t)
+		created, err := repo.Create(ctx, domain.Task{Title: "A", OwnerID: ownerA})
+		require.NoError(t, err)
+
+		_, err = repo.Restore(ctx, created.TaskID)
+		assert.ErrorIs(t, err, domain.ErrTaskNotInTrash)
+		assert.ErrorIs(t, repo.Purge(ctx, created.TaskID), domain.ErrTaskNotInTrash)
+		_, err = repo.Restore(ctx, domain.NewID())
+		assert.ErrorIs(t, err, domain.ErrTaskNotFound)
+		assert.ErrorIs(t, repo.Purge(ctx, domain.NewID()), domain.ErrTaskNotFound)
+
+		require.NoError(t, repo.Delete(ctx, created.TaskID, created.Version))
+		restored, err := repo.Restore(ctx, created.TaskID)
+		require.NoError(t, err)
+		assert.Nil(t, restored.DeletedAt)
+		assert.Equal(t, "A", restored.Title)
+		live, err := repo.Find(ctx, domain.TaskQuery{})
+		require.NoError(t, err)
+		assert.Len(t, live.Tasks, 1)
+
+		require.NoError(t, repo.Delete(ctx, created.TaskID, restored.Version))
+		require.NoError(t, repo.Purge(ctx, created.TaskID))
+		_, err = repo.GetByID(ctx, created.TaskID)
+		assert.ErrorIs(t, err, domain.ErrTaskNotFound)
+	})
+
+	t.Run("PurgeDeletedBefore", func(t *testing.T) {
+		repo := newRepo(t)
+		org := domain.NewID()
+		var ids []domain.ID
+		for _, title := range []string{"old", "live"} {
+			created, err := repo.Create(ctx, domain.Task{Title: title, OwnerID: ownerA, OrgID: org})
+			require.NoError(t, err)
+			ids = append(ids, created.TaskID)
+		}
+		require.NoError(t, repo.Delete(ctx, ids[0], 1))
+
+		purged, err := repo.PurgeDeletedBefore(ctx, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, purged, "recently trashed tasks are kept")
+
+		purged, err = repo.PurgeDeletedBefore(ctx, time.Now().Add(time.Second))
+		require.NoError(t, err)
+		require.Len(t, purged, 1)
+		assert.Equal(t, ids[0], purged[0].TaskID)
+		assert.Equal(t, "old", purged[0].Title)
+		assert.Equal(t, org, purged[0].OrgID, "purged tasks keep their organization")
+		_, err = repo.GetByID(ctx, ids[0])
+		assert.ErrorIs(t, err, domain.ErrTaskNotFound)
+		_, err = repo.GetByID(ctx, ids[1])
+		assert.NoError(t, err, "live tasks are never purged")
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		repo := newRepo(t)
+		for i, spec := range []struct {
+			title  string
+			owner  domain.ID
+			status domain.TaskStatus
+		}{
+			{"Write report", ownerA, domain.StatusPending},
+			{"Review REPORT", ownerA, domain.StatusInProgress},
+			{"Buy milk", ownerA, domain.StatusCompleted},
+			{"Report bug", ownerB, domain.StatusPending},
+		} {
+			_, err := repo.Create(ctx, domain.Task{
+				Title:   spec.title,
+				OwnerID: spec.owner,
+				Status:  spec.status,
+				DueDate: base.AddDate(0, 0, i),
+			})
+			require.NoError(t, err)
+		}
+
+		titles := func(q domain.TaskQuery) []string {
+			q.SortBy = domain.SortByDueDate
+			page, err := repo.Find(ctx, q)
+			require.NoError(t, err)
+			var out []string
+			for _, task := range page.Tasks {
+				out = append(out, task.Title)
+			}
+			return out
+		}
+		from, to := base.AddDate(0, 0, 1), base.AddDate(0, 0, 2)
+
+		assert.Len(t, titles(domain.TaskQuery{}), 4)
+		assert.Equal(t, []string{"Write report", "Review REPORT", "Buy milk"}, titles(domain.TaskQuery{OwnerID: &ownerA}))
+		assert.Equal(t, []string{"Write report", "Report bug"}, titles(domain.TaskQuery{Statuses: []domain.TaskStatus{domain.StatusPending}}))
+		assert.Equal(t, []string{"Write report", "Review REPORT"}, titles(domain.TaskQuery{OwnerID: &ownerA, TitleContains: "report"}))
+		assert.Equal(t, []string{"Review REPORT", "Buy milk"}, titles(domain.TaskQuery{DueFrom: &from, DueTo: &to}))
+		assert.Empty(t, titles(domain.TaskQuery{TitleContains: "(.*"}))
+	})
+
+	t.Run("Subtasks", func(t *testing.T) {
+		repo := newRepo(t)
+		parent, err := repo.Create(ctx, domain.Task{Title: "parent", OwnerID: ownerA, DueDate: base})
+		require.NoError(t, err)
+		child, err := repo.Create(ctx, domain.Task{Title: "child", OwnerID: ownerA, DueDate: base, ParentID: &parent.TaskID})
+		require.NoError(t, err)
+		other, err := repo.Create(ctx, domain.Task{Title: "other", OwnerID: ownerA, DueDate: base})
+		require.NoError(t, err)
+
+		got, err := repo.GetByID(ctx, child.TaskID)
+		require.NoError(t, err)
+		require.NotNil(t, got.ParentID)
+		assert.Equal(t, parent.TaskID, *got.ParentID)
+
+		page, err := repo.Find(ctx, domain.TaskQuery{ParentID: &parent.TaskID})
+		require.NoError(t, err)
+		require.Len(t, page.Tasks, 1)
+		assert.Equal(t, child.TaskID, page.Tasks[0].TaskID)
+
+		// moving the subtask under another parent and detaching it again
+		got.ParentID = &other.TaskID
+		moved, err := repo.Update(ctx, child.TaskID, *got)
+		require.NoError(t, err)
+		assert.Equal(t, other.TaskID, *moved.ParentID)
+		page, err = repo.Find(ctx, domain.TaskQuery{ParentID: &parent.TaskID})
+		require.NoError(t, err)
+		assert.Empty(t, page.Tasks)
+
+		moved.ParentID = nil
+		detached, err := repo.Update(ctx, child.TaskID, *moved)
+		require.NoError(t, err)
+		assert.Nil(t, detached.ParentID)
+	})
+
+	t.Run("Dependencies", func(t *testing.T) {
+		repo := newRepo(t)
+		first, err := repo.Create(ctx, domain.Task{Title: "first", OwnerID: ownerA, DueDate: base})
+		require.NoError(t, err)
+		second, err := repo.Create(ctx, domain.Task{Title: "second", OwnerID: ownerA, DueDate: base})
+		require.NoError(t, err)
+		third, err := repo.Create(ctx, domain.Task{
+			Title: "third", OwnerID: ownerA, DueDate: base, DependsOn: []domain.ID{first.TaskID, second.TaskID},
+		})
+		require.NoError(t, err)
+
+		got, err := repo.GetByID(ctx, third.TaskID)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.ID{first.TaskID, second.TaskID}, got.DependsOn)
+		got, err = repo.GetByID(ctx, first.TaskID)
+		require.NoError(t, err)
+		assert.Empty(t, got.DependsOn)
+
+		dependents := func(id domain.ID) []string {
+			page, err := repo.Find(ctx, domain.TaskQuery{DependsOn: &id})
+			require.NoError(t, err)
+			var out []string
+			for _, task := range page.Tasks {
+				out = append(out, task.Title)
+			}
+			return out
+		}
+		assert.Equal(t, []string{"third"}, dependents(second.TaskID))
+
+		third.DependsOn = []domain.ID{first.TaskID}
+		updated, err := repo.Update(ctx, third.TaskID, *third)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.ID{first.TaskID}, updated.DependsOn)
+		assert.Empty(t, dependents(second.TaskID))
+		assert.Equal(t, []string{"third"}, dependents(first.TaskID))
+
+		updated.DependsOn = nil
+		updated, err = repo.Update(ctx, third.TaskID, *updated)
+		require.NoError(t, err)
+		assert.Empty(t, updated.DependsOn)
+		assert.Empty(t, dependents(first.TaskID))
+	})
+
+	t.Run("Tags", func(t *testing.T) {
+		repo := newRepo(t)
+		for _, task := range []domain.Task{
+			{Title: "both", OwnerID: ownerA, Tags: []string{"bug", "ui"}},
+			{Title: "bug", OwnerID: ownerA, Tags: []string{"bug"}},
+			{Title: "underscore", OwnerID: ownerA, Tags: []string{"a_b"}},
+			{Title: "lookalike", OwnerID: ownerA, Tags: []string{"axb"}},
+			{Title: "other owner", OwnerID: ownerB, Tags: []string{"bug"}},
+			{Title: "untagged", OwnerID: ownerA},
+		} {
+			task.DueDate = base
+			_, err := repo.Create(ctx, task)
+			require.NoError(t, err)
+		}
+
+		titles := func(match domain.TagMatch, tags ...string) []string {
+			page, err := repo.Find(ctx, domain.TaskQuery{OwnerID: &ownerA, Tags: tags, TagMatch: match, SortBy: domain.SortByTitle})
+			require.NoError(t, err)
+			var out []string
+			for _, task := range page.Tasks {
+				out = append(out, task.Title)
+			}
+			return out
+		}
+		assert.Equal(t, []string{"both", "bug"}, titles("", "bug"))
+		assert.Equal(t, []string{"both", "bug"}, titles(domain.TagMatchAny, "bug", "ui"))
+		assert.Equal(t, []string{"both"}, titles(domain.TagMatchAll, "bug", "ui"))
+		assert.Equal(t, []string{"underscore"}, titles(domain.TagMatchAny, "a_b"))
+
+		counts, err := repo.CountTags(ctx, &ownerA)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.TagCount{{Name: "bug", Count: 2}, {Name: "a_b", Count: 1}, {Name: "axb", Count: 1}, {Name: "ui", Count: 1}}, counts)
+		counts, err = repo.CountTags(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, domain.TagCount{Name: "bug", Count: 3}, counts[0])
+
+		page, err := repo.Find(ctx, domain.TaskQuery{OwnerID: &ownerA, Tags: []string{"ui"}})
+		require.NoError(t, err)
+		both := page.Tasks[0]
+		assert.Equal(t, []string{"bug", "ui"}, both.Tags)
+		both.Tags = nil
+		updated, err := repo.Update(ctx, both.TaskID, both)
+		require.NoError(t, err)
+		assert.Empty(t, updated.Tags)
+		assert.Empty(t, titles("", "ui"))
+
+		require.Equal(t, []string{"bug"}, titles("", "bug"))
+		page, err = repo.Find(ctx, domain.TaskQuery{OwnerID: &ownerA, Tags: []string{"bug"}})
+		require.NoError(t, err)
+		require.NoError(t, repo.Delete(ctx, page.Tasks[0].TaskID, page.Tasks[0].Version))
+		counts, err = repo.CountTags(ctx, &ownerA)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.TagCount{{Name: "a_b", Count: 1}, {Name: "axb", Count: 1}}, counts)
+	})
+
+	t.Run("Priority", func(t *testing.T) {
+		repo := newRepo(t)
+		created, err := repo.Create(ctx, domain.Task{Title: "A", OwnerID: ownerA, DueDate: base, Priority: domain.PriorityHigh})
+		require.NoError(t, err)
+		got, err := repo.GetByID(ctx, created.TaskID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.PriorityHigh, got.Priority)
+
+		got.Priority = domain.PriorityLow
+		updated, err := repo.Update(ctx, got.TaskID, *got)
+		require.NoError(t, err)
+		assert.Equal(t, domain.PriorityLow, updated.Priority)
+	})
+
+	t.Run("Assignees", func(t *testing.T) {
+		repo := newRepo(t)
+		carol := domain.NewID()
+		a, err := repo.Create(ctx, domain.Task{
+			Title: "A", OwnerID: ownerA, DueDate: base, Assignees: []domain.ID{ownerB, carol}, Watchers: []domain.ID{carol},
+		})
+		require.NoError(t, err)
+		_, err = repo.Create(ctx, domain.Task{Title: "B", OwnerID: ownerA, DueDate: base, Assignees: []domain.ID{carol}})
+		require.NoError(t, err)
+		_, err = repo.Create(ctx, domain.Task{Title: "C", OwnerID: ownerB, DueDate: base, Watchers: []domain.ID{ownerB}})
+		require.NoError(t, err)
+
+		got, err := repo.GetByID(ctx, a.TaskID)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.ID{ownerB, carol}, got.Assignees)
+		assert.Equal(t, []domain.ID{carol}, got.Watchers)
+
+		titles := func(user domain.ID) []string {
+			page, err := repo.Find(ctx, domain.TaskQuery{AssigneeID: &user, SortBy: domain.SortByTitle})
+			require.NoError(t, err)
+			var out []string
+			for _, task := range page.Tasks {
+				out = append(out, task.Title)
+			}
+			return out
+		}
+		assert.Equal(t, []string{"A", "B"}, titles(carol))
+		assert.Equal(t, []string{"A"}, titles(ownerB), "watching is not being assigned")
+
+		got.Assignees, got.Watchers = []domain.ID{carol}, nil
+		updated, err := repo.Update(ctx, got.TaskID, *got)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.ID{carol}, updated.Assignees)
+		assert.Empty(t, updated.Watchers)
+		assert.Empty(t, titles(ownerB))
+	})
+
+	t.Run("Projects", func(t *testing.T) {
+		repo := newRepo(t)
+		project := domain.NewID()
+		a, err := repo.Create(ctx, domain.Task{Title: "A", OwnerID: ownerA, DueDate: base, ProjectID: &project})
+		require.NoError(t, err)
+		_, err = repo.Create(ctx, domain.Task{Title: "B", OwnerID: ownerB, DueDate: base, ProjectID: &project})
+		require.NoError(t, err)
+		_, err = repo.Create(ctx, domain.Task{Title: "C", OwnerID: ownerA, DueDate: base})
+		require.NoError(t, err)
+
+		got, err := repo.GetByID(ctx, a.TaskID)
+		require.NoError(t, err)
+		require.NotNil(t, got.ProjectID)
+		assert.Equal(t, project, *got.ProjectID)
+
+		titles := func() []string {
+			page, err := repo.Find(ctx, domain.TaskQuery{ProjectID: &project, SortBy: domain.SortByTitle})
+			require.NoError(t, err)
+			var out []string
+			for _, task := range page.Tasks {
+				out = append(out, task.Title)
+			}
+			return out
+		}
+		assert.Equal(t, []string{"A", "B"}, titles())
+
+		got.ProjectID = nil
+		updated, err := repo.Update(ctx, got.TaskID, *got)
+		require.NoError(t, err)
+		assert.Nil(t, updated.ProjectID)
+		assert.Equal(t, []string{"B"}, titles())
+	})
+
+	t.Run("Recurrence", func(t *testing.T) {
+		repo := newRepo(t)
+		created, err := repo.Create(ctx, domain.Task{
+			Title: "standup", OwnerID: ownerA, DueDate: base, Recurrence: "FREQ=DAILY;COUNT=3", Occurrence: 2,
+		})
+		require.NoError(t, err)
+
+		got, err := repo.GetByID(ctx, created.TaskID)
+		require.NoError(t, err)
+		assert.Equal(t, "FREQ=DAILY;COUNT=3", got.Recurrence)
+		assert.Equal(t, 2, got.Occurrence)
+
+		got.Recurrence = ""
+		updated, err := repo.Update(ctx, got.TaskID, *got)
+		require.NoError(t, err)
+		assert.Empty(t, updated.Recurrence)
+		got, err = repo.GetByID(ctx, created.TaskID)
+		require.NoError(t, err)
+		assert.Empty(t, got.Recurrence)
+		assert.Equal(t, 2, got.Occurrence)
+	})
+
+	t.Run("CursorPagination", func(t *testing.T) {
+		repo := newRepo(t)
+		// pairs share a due date so the ID tiebreaker is exercised
+		for i := 0; i < 7; i++ {
+			_, err := repo.Create(ctx, domain.Task{
+				Title:   fmt.Sprintf("T%d", i),
+				OwnerID: ownerA,
+				DueDate: base.AddDate(0, 0, i/2),
+			})
+			require.NoError(t, err)
+		}
+		for _, desc := range []bool{false, true} {
+			var seen []domain.ID
+			q := domain.TaskQuery{SortBy: domain.SortByDueDate, SortDesc: desc, Limit: 3}
+			for pages := 0; ; pages++ {
+				require.Less(t, pages, 5, "pagination does not terminate")
+				page, err := repo.Find(ctx, q)
+				require.NoError(t, err)
+				for i, task := range page.Tasks {
+					if len(seen) > 0 && i == 0 {
+						prev, _ := repo.GetByID(ctx, seen[len(seen)-1])
+						if desc {
+							assert.False(t, task.DueDate.After(prev.DueDate))
+						} else {
+							assert.False(t, task.DueDate.Before(prev.DueDate))
+						}
+					}
+					seen = append(seen, task.TaskID)
+				}
+				if page.NextCursor == "" {
+					break
+				}
+				q.Cursor = page.NextCursor
+			}
+			assert.Len(t, seen, 7)
+			unique := map[domain.ID]bool{}
+			for _, id := range seen {
+				unique[id] = true
+			}
+			assert.Len(t, unique, 7, "a task was returned twice")
+		}
+	})
+
+	t.Run("InvalidCursor", func(t *testing.T) {
+		repo := newRepo(t)
+		_, err := repo.Find(ctx, domain.TaskQuery{Cursor: "garbage"})
+		assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+	})
+	t.Run("Tenants", func(t *testing.T) {
+		repo := newRepo(t)
+		acme, globex := domain.WithTenant(ctx, domain.NewID()), domain.WithTenant(ctx, domain.NewID())
+		created, err := repo.Create(acme, domain.Task{Title: "A", OwnerID: ownerA, Tags: []string{"secret"}})
+		require.NoError(t, err)
+		id := created.TaskID
+
+		_, err = repo.GetByID(globex, id)
+		assert.ErrorIs(t, err, domain.ErrTaskNotFound)
+		_, err = repo.Update(globex, id, *created)
+		assert.ErrorIs(t, err, domain.ErrTaskNotFound)
+		assert.ErrorIs(t, repo.Delete(globex, id, created.Version), domain.ErrTaskNotFound)
+		page, err := repo.Find(globex, domain.TaskQuery{OwnerID: &ownerA})
+		require.NoError(t, err)
+		assert.Empty(t, page.Tasks)
+		tags, err := repo.CountTags(globex, nil)
+		require.NoError(t, err)
+		assert.Empty(t, tags)
+
+		require.NoError(t, repo.Delete(acme, id, created.Version))
+		_, err = repo.Restore(globex, id)
+		assert.ErrorIs(t, err, domain.ErrTaskNotFound)
+		assert.ErrorIs(t, repo.Purge(globex, id), domain.ErrTaskNotFound)
+		purged, err := repo.PurgeDeletedBefore(globex, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, purged)
+		page, err = repo.Find(acme, domain.TaskQuery{Trashed: true})
+		require.NoError(t, err)
+		assert.Len(t, page.Tasks, 1)
+
+		// contexts unscoped with WithoutTenant, as used by background
+		// jobs, see every tenant
+		got, err := repo.GetByID(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "A", got.Title)
+
+		// a context with no tenant at all sees nothing and creates nothing
+		none := context.Background()
+		_, err = repo.GetByID(none, id)
+		assert.ErrorIs(t, err, domain.ErrTaskNotFound)
+		page, err = repo.Find(none, domain.TaskQuery{Trashed: true})
+		require.NoError(t, err)
+		assert.Empty(t, page.Tasks)
+		purged, err = repo.PurgeDeletedBefore(none, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, purged)
+		_, err = repo.Create(none, domain.Task{Title: "B", OwnerID: ownerA})
+		assert.ErrorIs(t, err, domain.ErrNoTenant)
+	})
+}
+
+func testUserRepository(t *testing.T, newRepo func(t *testing.T) domain.UserRepository) {
+	ctx := domain.WithoutTenant(context.Background())
+	t.Run("Ping", func(t *testing.T) {
+		assert.NoError(t, newRepo(t).Ping(ctx))
+	})
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+		created, err := repo.Create(ctx, domain.User{Name: "Alice", Email: "a@x.com", Password: "hash"})
+		require.NoError(t, err)
+		assert.False(t, created.UserID.IsZero())
+
+		byID, err := repo.GetByID(ctx, created.UserID)
+		require.NoError(t, err)
+		assert.Equal(t, "a@x.com", byID.Email)
+
+		byEmail, err := repo.GetByEmail(ctx, "a@x.com")
+		require.NoError(t, err)
+		assert.Equal(t, created.UserID, byEmail.UserID)
+		assert.Equal(t, "hash", byEmail.Password)
+	})
+
+	t.Run("DuplicateEmail", func(t *testing.T) {
+		repo := newRepo(t)
+		_, err := repo.Create(ctx, domain.User{Email: "a@x.com"})
+		require.NoError(t, err)
+		_, err = repo.Create(ctx, domain.User{Email: "a@x.com"})
+		assert.ErrorIs(t, err, domain.ErrDuplicateEmail)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := newRepo(t)
+		_, err := repo.GetByID(ctx, domain.NewID())
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+		_, err = repo.GetByEmail(ctx, "nobody@x.com")
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+		_, err = repo.PromoteUser(ctx, domain.NewID(), domain.NewID())
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	})
+
+	t.Run("GetAllAndPromote", func(t *testing.T) {
+		repo := newRepo(t)
+		a, err := repo.Create(ctx, domain.User{Email: "a@x.com"})
+		require.NoError(t, err)
+		_, err = repo.Create(ctx, domain.User{Email: "b@x.com"})
+		require.NoError(t, err)
+
+		all, err := repo.GetAll(ctx)
+		require.NoError(t, err)
+		assert.Len(t, all, 2)
+
+		acme := domain.NewID()
+		_, err = repo.PromoteUser(ctx, a.UserID, acme)
+		assert.ErrorIs(t, err, domain.ErrUserNotFound, "only members are promoted")
+		_, err = repo.AddOrganization(ctx, a.UserID, acme)
+		require.NoError(t, err)
+		promoted, err := repo.PromoteUser(ctx, a.UserID, acme)
+		require.NoError(t, err)
+		assert.Equal(t, "admin", promoted.RoleIn(acme))
+		assert.Equal(t, "user", promoted.RoleIn(a.UserID), "the role only holds in the organization")
+		promoted, err = repo.PromoteUser(ctx, a.UserID, acme)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.ID{acme}, promoted.AdminOrgIDs, "promoting twice changes nothing")
+	})
+	t.Run("Organizations", func(t *testing.T) {
+		repo := newRepo(t)
+		acme, globex := domain.NewID(), domain.NewID()
+		a, err := repo.Create(ctx, domain.User{Email: "a@x.com"})
+		require.NoError(t, err)
+		b, err := repo.Create(ctx, domain.User{Email: "b@x.com"})
+		require.NoError(t, err)
+
+		joined, err := repo.AddOrganization(ctx, a.UserID, acme)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.ID{acme}, joined.OrgIDs)
+		joined, err = repo.AddOrganization(ctx, a.UserID, acme)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.ID{acme}, joined.OrgIDs, "joining twice changes nothing")
+		_, err = repo.AddOrganization(ctx, a.UserID, globex)
+		require.NoError(t, err)
+		_, err = repo.AddOrganization(ctx, b.UserID, globex)
+		require.NoError(t, err)
+		_, err = repo.AddOrganization(ctx, domain.NewID(), acme)
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+
+		inAcme := domain.WithTenant(ctx, acme)
+		all, err := repo.GetAll(inAcme)
+		require.NoError(t, err)
+		require.Len(t, all, 1)
+		assert.Equal(t, a.UserID, all[0].UserID)
+		_, err = repo.GetByID(inAcme, b.UserID)
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+		_, err = repo.GetByEmail(inAcme, "b@x.com")
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+		_, err = repo.PromoteUser(inAcme, b.UserID, acme)
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+		_, err = repo.PromoteUser(inAcme, a.UserID, acme)
+		require.NoError(t, err)
+		_, err = repo.AddOrganization(inAcme, b.UserID, acme)
+		assert.ErrorIs(t, err, domain.ErrUserNotFound, "members are added from outside the organization")
+
+		left, err := repo.RemoveOrganization(ctx, a.UserID, acme)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.ID{globex}, left.OrgIDs)
+		assert.Empty(t, left.AdminOrgIDs, "leaving drops the role")
+		all, err = repo.GetAll(inAcme)
+		require.NoError(t, err)
+		assert.Empty(t, all)
+		all, err = repo.GetAll(domain.WithTenant(ctx, globex))
+		require.NoError(t, err)
+		assert.Len(t, all, 2)
+
+		none := context.Background()
+		all, err = repo.GetAll(none)
+		require.NoError(t, err)
+		assert.Empty(t, all, "a context without a tenant sees no users")
+		_, err = repo.GetByID(none, a.UserID)
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+		_, err = repo.GetByEmail(none, "b@x.com")
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	})
+}
+
+func testRefreshTokenRepository(t *testing.T, newRepo func(t *testing.T) domain.RefreshTokenRepository) {
+	ctx := domain.WithoutTenant(context.Background())
+	repo := newRepo(t)
+	family, user := domain.NewID(), domain.NewID()
+	exp := time.Now().Add(time.Hour).UTC().Truncate(time.Millisecond)
+
+	first, err := repo.Create(ctx, domain.RefreshToken{FamilyID: family, UserID: user, TokenHash: "h1", ExpiresAt: exp})
+	require.NoError(t, err)
+	second, err := repo.Create(ctx, domain.RefreshToken{FamilyID: family, UserID: user, TokenHash: "h2", ExpiresAt: exp})
+	require.NoError(t, err)
+	other, err := repo.Create(ctx, domain.RefreshToken{FamilyID: domain.NewID(), UserID: user, TokenHash: "h3", ExpiresAt: exp})
+	require.NoError(t, err)
+
+	got, err := repo.GetByHash(ctx, "h1")
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, got.ID)
+	assert.Equal(t, family, got.FamilyID)
+	assert.Equal(t, user, got.UserID)
+	assert.True(t, got.ExpiresAt.Equal(exp))
+	assert.Nil(t, got.RevokedAt)
+
+	_, err = repo.GetByHash(ctx, "missing")
+	assert.ErrorIs(t, err, domain.ErrRefreshTokenNotFound)
+
+	ok, err := repo.MarkRotated(ctx, first.ID, second.ID)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = repo.MarkRotated(ctx, first.ID, other.ID)
+	require.NoError(t, err)
+	assert.False(t, ok, "a token can only be rotated once")
+
+	got, err = repo.GetByHash(ctx, "h1")
+	require.NoError(t, err)
+	assert.NotNil(t, got.RevokedAt)
+	assert.Equal(t, second.ID, got.ReplacedBy)
+
+	require.NoError(t, repo.RevokeFamily(ctx, family))
+	got, err = repo.GetByHash(ctx, "h2")
+	require.NoError(t, err)
+	assert.NotNil(t, got.RevokedAt)
+	got, err = repo.GetByHash(ctx, "h3")
+	require.NoError(t, err)
+	assert.Nil(t, got.RevokedAt, "other families are untouched")
+}
+
+func testTokenDenylist(t *testing.T, newDenylist func(t *testing.T) domain.TokenDenylist) {
+	ctx := domain.WithoutTenant(context.Background())
+	d := newDenylist(t)
+
+	revoked, err := d.IsRevoked(ctx, "jti-1")
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	require.NoError(t, d.Revoke(ctx, "jti-1", time.Now().Add(time.Hour)))
+	require.NoError(t, d.Revoke(ctx, "jti-1", time.Now().Add(2*time.Hour)), "revoking twice is allowed")
+	require.NoError(t, d.Revoke(ctx, "jti-old", time.Now().Add(-time.Minute)))
+
+	revoked, err = d.IsRevoked(ctx, "jti-1")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = d.IsRevoked(ctx, "jti-old")
+	require.NoError(t, err)
+	assert.False(t, revoked, "expired entries no longer matter")
+}
+
+func testHistoryRepository(t *testing.T, newRepo func(t *testing.T) domain.HistoryRepository) {
+	ctx := domain.WithoutTenant(context.Background())
+	repo := newRepo(t)
+	taskA, taskB := domain.NewID(), domain.NewID()
+	alice, bob := domain.NewID(), domain.NewID()
+	base := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	for i, spec := range []struct {
+		task, actor domain.ID
+		action      domain.HistoryAction
+	}{
+		{taskA, alice, domain.HistoryCreated},
+		{taskB, bob, domain.HistoryCreated},
+		{taskA, bob, domain.HistoryUpdated},
+		{taskA, alice, domain.HistoryTransitioned},
+		{taskB, bob, domain.HistoryDeleted},
+	} {
+		// appended out of order to prove Find sorts by time
+		at := base.Add(time.Duration(5-i) * time.Hour)
+		entry, err := repo.Append(ctx, domain.HistoryEntry{
+			TaskID: spec.task, ActorID: spec.actor, Action: spec.action, At: at,
+			Changes: []domain.FieldChange{{Field: "title", Before: "", After: fmt.Sprint(i)}},
+		})
+		require.NoError(t, err)
+		assert.False(t, entry.ID.IsZero())
+	}
+
+	page, err := repo.Find(ctx, domain.HistoryQuery{TaskID: &taskA})
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 3)
+	assert.Equal(t, domain.HistoryTransitioned, page.Entries[0].Action, "oldest first")
+	assert.Equal(t, domain.HistoryCreated, page.Entries[2].Action)
+	assert.Equal(t, []domain.FieldChange{{Field: "title", Before: "", After: "3"}}, page.Entries[0].Changes)
+	assert.True(t, page.Entries[0].At.Equal(base.Add(2*time.Hour)))
+
+	from, to := base.Add(2*time.Hour), base.Add(4*time.Hour)
+	page, err = repo.Find(ctx, domain.HistoryQuery{ActorID: &bob, From: &from, To: &to})
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 2)
+	assert.Equal(t, taskA, page.Entries[0].TaskID)
+	assert.Equal(t, taskB, page.Entries[1].TaskID)
+
+	var seen []domain.HistoryAction
+	q := domain.HistoryQuery{Limit: 2}
+	for {
+		page, err := repo.Find(ctx, q)
+		require.NoError(t, err)
+		for _, e := range page.Entries {
+			seen = append(seen, e.Action)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+	}
+	assert.Equal(t, []domain.HistoryAction{
+		domain.HistoryDeleted, domain.HistoryTransitioned, domain.HistoryUpdated,
+		domain.HistoryCreated, domain.HistoryCreated,
+	}, seen)
+
+	_, err = repo.Find(ctx, domain.HistoryQuery{Cursor: "garbage"})
+	assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+
+	// entries appended for a tenant are only found from it
+	acme, globex := domain.WithTenant(ctx, domain.NewID()), domain.WithTenant(ctx, domain.NewID())
+	_, err = repo.Append(acme, domain.HistoryEntry{TaskID: taskA, ActorID: alice, Action: domain.HistoryUpdated, At: base})
+	require.NoError(t, err)
+	page, err = repo.Find(acme, domain.HistoryQuery{TaskID: &taskA})
+	require.NoError(t, err)
+	assert.Len(t, page.Entries, 1)
+	page, err = repo.Find(globex, domain.HistoryQuery{})
+	require.NoError(t, err)
+	assert.Empty(t, page.Entries)
+}
+
+func TestCommentStores(t *testing.T) {
+	backends := []struct {
+		name          string
+		comments      func(t *testing.T) domain.CommentRepository
+		notifications func(t *testing.T) domain.NotificationRepository
+	}{
+		{
+			"InMemory",
+			func(t *testing.T) domain.CommentRepository { return NewInMemoryCommentRepository() },
+			func(t *testing.T) domain.NotificationRepository { return NewInMemoryNotificationRepository() },
+		},
+		{
+			"SQLite",
+			func(t *testing.T) domain.CommentRepository {
+				return NewSQLCommentRepository(sqliteTestDB(t), DialectSQLite)
+			},
+			func(t *testing.T) domain.NotificationRepository {
+				return NewSQLNotificationRepository(sqliteTestDB(t), DialectSQLite)
+			},
+		},
+		{
+			"Postgres",
+			func(t *testing.T) domain.CommentRepository {
+				return NewSQLCommentRepository(postgresTestDB(t), DialectPostgres)
+			},
+			func(t *testing.T) domain.NotificationRepository {
+				return NewSQLNotificationRepository(postgresTestDB(t), DialectPostgres)
+			},
+		},
+		{
+			"Mongo",
+			func(t *testing.T) domain.CommentRepository {
+				repo := NewCommentRepository(mongoTestDB(t).Collection("comments"))
+				require.NoError(t, repo.EnsureIndexes(context.Background()))
+				return repo
+			},
+			func(t *testing.T) domain.NotificationRepository {
+				repo := NewNotificationRepository(mongoTestDB(t).Collection("notifications"))
+				require.NoError(t, repo.EnsureIndexes(context.Background()))
+				return repo
+			},
+		},
+	}
+	for _, b := range backends {
+		t.Run(b.name+"Comments", func(t *testing.T) { testCommentRepository(t, b.comments) })
+		t.Run(b.name+"Notifications", func(t *testing.T) { testNotificationRepository(t, b.notifications) })
+	}
+}
+
+func testCommentRepository(t *testing.T, newRepo func(t *testing.T) domain.CommentRepository) {
+	ctx := domain.WithoutTenant(context.Background())
+	repo := newRepo(t)
+	taskA, taskB := domain.NewID(), domain.NewID()
+	alice := domain.NewID()
+	base := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	var first *domain.Comment
+	for i, task := range []domain.ID{taskA, taskB, taskA, taskA} {
+		// created out of order to prove Find sorts by time
+		at := base.Add(time.Duration(4-i) * time.Hour)
+		c, err := repo.Create(ctx, domain.Comment{
+			TaskID: task, AuthorID: alice, Body: fmt.Sprint(i), CreatedAt: at, UpdatedAt: at,
+		})
+		require.NoError(t, err)
+		assert.False(t, c.ID.IsZero())
+		if i == 0 {
+			first = c
+		}
+	}
+
+	got, err := repo.GetByID(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "0", got.Body)
+	assert.Equal(t, taskA, got.TaskID)
+	assert.Equal(t, alice, got.AuthorID)
+	assert.Empty(t, got.Edits)
+
+	edited := base.Add(10 * time.Hour)
+	got.Edit("zero", edited)
+	updated, err := repo.Update(ctx, *got)
+	require.NoError(t, err)
+	assert.Equal(t, "zero", updated.Body)
+	assert.True(t, updated.UpdatedAt.Equal(edited))
+	require.Len(t, updated.Edits, 1)
+	assert.Equal(t, "0", updated.Edits[0].Body)
+	assert.True(t, updated.Edits[0].EditedAt.Equal(edited))
+	assert.True(t, updated.CreatedAt.Equal(base.Add(4*time.Hour)), "update keeps the creation time")
+
+	var bodies []string
+	q := domain.CommentQuery{TaskID: taskA, Limit: 2}
+	for {
+		page, err := repo.Find(ctx, q)
+		require.NoError(t, err)
+		for _, c := range page.Comments {
+			bodies = append(bodies, c.Body)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+	}
+	assert.Equal(t, []string{"3", "2", "zero"}, bodies, "oldest first")
+
+	require.NoError(t, repo.Delete(ctx, first.ID))
+	_, err = repo.GetByID(ctx, first.ID)
+	assert.ErrorIs(t, err, domain.ErrCommentNotFound)
+	assert.ErrorIs(t, repo.Delete(ctx, first.ID), domain.ErrCommentNotFound)
+	_, err = repo.Update(ctx, *got)
+	assert.ErrorIs(t, err, domain.ErrCommentNotFound)
+
+	page, err := repo.Find(ctx, domain.CommentQuery{TaskID: taskA})
+	require.NoError(t, err)
+	assert.Len(t, page.Comments, 2)
+
+	_, err = repo.Find(ctx, domain.CommentQuery{TaskID: taskA, Cursor: "garbage"})
+	assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+
+	// comments created for a tenant are only found from it
+	acme, globex := domain.WithTenant(ctx, domain.NewID()), domain.WithTenant(ctx, domain.NewID())
+	scoped, err := repo.Create(acme, domain.Comment{TaskID: taskB, AuthorID: alice, Body: "acme", CreatedAt: base, UpdatedAt: base})
+	require.NoError(t, err)
+	_, err = repo.GetByID(acme, scoped.ID)
+	require.NoError(t, err)
+	_, err = repo.GetByID(globex, scoped.ID)
+	assert.ErrorIs(t, err, domain.ErrCommentNotFound)
+	_, err = repo.Update(globex, *scoped)
+	assert.ErrorIs(t, err, domain.ErrCommentNotFound)
+	assert.ErrorIs(t, repo.Delete(globex, scoped.ID), domain.ErrCommentNotFound)
+	page, err = repo.Find(globex, domain.CommentQuery{TaskID: taskB})
+	require.NoError(t, err)
+	assert.Empty(t, page.Comments)
+	page, err = repo.Find(acme, domain.CommentQuery{TaskID: taskB})
+	require.NoError(t, err)
+	assert.Len(t, page.Comments, 1)
+}
+
+func testNotificationRepository(t *testing.T, newRepo func(t *testing.T) domain.NotificationRepository) {
+	ctx := domain.WithoutTenant(context.Background())
+	repo := newRepo(t)
+	alice, bob := domain.NewID(), domain.NewID()
+	task, comment := domain.NewID(), domain.NewID()
+	base := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	var ids []domain.ID
+	for i, user := range []domain.ID{alice, bob, alice, alice} {
+		n, err := repo.Create(ctx, domain.Notification{
+			UserID: user, Kind: domain.NotificationMentioned, TaskID: task, ActorID: bob,
+			CommentID: &comment, CreatedAt: base.Add(time.Duration(i) * time.Hour),
+		})
+		require.NoError(t, err)
+		ids = append(ids, n.ID)
+	}
+
+	var seen []domain.ID
+	q := domain.NotificationQuery{UserID: alice, Limit: 2}
+	for {
+		page, err := repo.Find(ctx, q)
+		require.NoError(t, err)
+		for _, n := range page.Notifications {
+			seen = append(seen, n.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+	}
+	assert.Equal(t, []domain.ID{ids[3], ids[2], ids[0]}, seen, "newest first")
+
+	readAt := base.Add(24 * time.Hour)
+	n, err := repo.MarkRead(ctx, alice, ids[2], readAt)
+	require.NoError(t, err)
+	require.NotNil(t, n.ReadAt)
+	assert.True(t, n.ReadAt.Equal(readAt))
+	assert.Equal(t, domain.NotificationMentioned, n.Kind)
+	require.NotNil(t, n.CommentID)
+	assert.Equal(t, comment, *n.CommentID)
+
+	n, err = repo.MarkRead(ctx, alice, ids[2], readAt.Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, n.ReadAt.Equal(readAt), "marking again keeps the first read time")
+
+	_, err = repo.MarkRead(ctx, alice, ids[1], readAt)
+	assert.ErrorIs(t, err, domain.ErrNotificationNotFound, "bob's notification")
+	_, err = repo.MarkRead(ctx, alice, domain.NewID(), readAt)
+	assert.ErrorIs(t, err, domain.ErrNotificationNotFound)
+
+	page, err := repo.Find(ctx, domain.NotificationQuery{UserID: alice, Unread: true})
+	require.NoError(t, err)
+	require.Len(t, page.Notifications, 2)
+	assert.Equal(t, ids[3], page.Notifications[0].ID)
+	assert.Nil(t, page.Notifications[0].ReadAt)
+
+	_, err = repo.Find(ctx, domain.NotificationQuery{UserID: alice, Cursor: "garbage"})
+	assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+
+	// notifications created for a tenant are only found from it
+	acme, globex := domain.WithTenant(ctx, domain.NewID()), domain.WithTenant(ctx, domain.NewID())
+	scoped, err := repo.Create(acme, domain.Notification{
+		UserID: bob, Kind: domain.NotificationAssigned, TaskID: task, ActorID: alice, CreatedAt: base,
+	})
+	require.NoError(t, err)
+	page, err = repo.Find(globex, domain.NotificationQuery{UserID: bob})
+	require.NoError(t, err)
+	assert.Empty(t, page.Notifications)
+	_, err = repo.MarkRead(globex, bob, scoped.ID, base)
+	assert.ErrorIs(t, err, domain.ErrNotificationNotFound)
+	page, err = repo.Find(acme, domain.NotificationQuery{UserID: bob})
+	require.NoError(t, err)
+	require.Len(t, page.Notifications, 1)
+	assert.Equal(t, scoped.ID, page.Notifications[0].ID)
+}
+
+func TestProjectStores(t *testing.T) {
+	backends := []struct {
+		name     string
+		projects func(t *testing.T) domain.ProjectRepository
+	}{
+		{"InMemory", func(t *testing.T) domain.ProjectRepository {
+			return NewInMemoryProjectRepository()
+		}},
+		{"SQLite", func(t *testing.T) domain.ProjectRepository {
+			return NewSQLProjectRepository(sqliteTestDB(t), DialectSQLite)
+		}},
+		{"Postgres", func(t *testing.T) domain.ProjectRepository {
+			return NewSQLProjectRepository(postgresTestDB(t), DialectPostgres)
+		}},
+		{"Mongo", func(t *testing.T) domain.ProjectRepository {
+			repo := NewProjectRepository(mongoTestDB(t).Collection("projects"))
+			require.NoError(t, repo.EnsureIndexes(context.Background()))
+			return repo
+		}},
+	}
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) { testProjectRepository(t, b.projects) })
+	}
+}
+
+func testProjectRepository(t *testing.T, newRepo func(t *testing.T) domain.ProjectRepository) {
+	ctx := domain.WithoutTenant(context.Background())
+	repo := newRepo(t)
+	alice, bob, carol := domain.NewID(), domain.NewID(), domain.NewID()
+	base := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	var first *domain.Project
+	for i, owner := range []domain.ID{alice, bob, alice, bob} {
+		// created out of order to prove Find sorts by time
+		at := base.Add(time.Duration(4-i) * time.Hour)
+		p, err := repo.Create(ctx, domain.Project{OwnerID: owner, Name: fmt.Sprint(i), CreatedAt: at, UpdatedAt: at})
+		require.NoError(t, err)
+		assert.False(t, p.ID.IsZero())
+		if i == 0 {
+			first = p
+		}
+	}
+
+	got, err := repo.GetByID(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "0", got.Name)
+	assert.Equal(t, alice, got.OwnerID)
+	assert.Empty(t, got.Members)
+	assert.False(t, got.Archived())
+
+	archived := base.Add(10 * time.Hour)
+	got.Name, got.Description = "zero", "the first"
+	got.SetMember(carol, domain.ProjectEditor)
+	got.ArchivedAt, got.UpdatedAt = &archived, archived
+	updated, err := repo.Update(ctx, *got)
+	require.NoError(t, err)
+	assert.Equal(t, "zero", updated.Name)
+	assert.Equal(t, "the first", updated.Description)
+	assert.Equal(t, []domain.ProjectMember{{UserID: carol, Role: domain.ProjectEditor}}, updated.Members)
+	require.NotNil(t, updated.ArchivedAt)
+	assert.True(t, updated.ArchivedAt.Equal(archived))
+	assert.True(t, updated.UpdatedAt.Equal(archived))
+	assert.True(t, updated.CreatedAt.Equal(base.Add(4*time.Hour)), "update keeps the creation time")
+
+	names := func(q domain.ProjectQuery) []string {
+		q.Limit = 2
+		var out []string
+		for {
+			page, err := repo.Find(ctx, q)
+			require.NoError(t, err)
+			for _, p := range page.Projects {
+				out = append(out, p.Name)
+			}
+			if page.NextCursor == "" {
+				return out
+			}
+			q.Cursor = page.NextCursor
+		}
+	}
+	yes, no := true, false
+	assert.Equal(t, []string{"3", "2", "1", "zero"}, names(domain.ProjectQuery{}), "oldest first")
+	assert.Equal(t, []string{"2", "zero"}, names(domain.ProjectQuery{MemberID: &alice}))
+	assert.Equal(t, []string{"zero"}, names(domain.ProjectQuery{MemberID: &carol}), "members are found too")
+	assert.Equal(t, []string{"zero"}, names(domain.ProjectQuery{Archived: &yes}))
+	assert.Equal(t, []string{"2"}, names(domain.ProjectQuery{MemberID: &alice, Archived: &no}))
+
+	updated.RemoveMember(carol)
+	updated.ArchivedAt = nil
+	updated, err = repo.Update(ctx, *updated)
+	require.NoError(t, err)
+	assert.Empty(t, updated.Members)
+	assert.Nil(t, updated.ArchivedAt)
+	assert.Empty(t, names(domain.ProjectQuery{MemberID: &carol}))
+
+	require.NoError(t, repo.Delete(ctx, first.ID))
+	_, err = repo.GetByID(ctx, first.ID)
+	assert.ErrorIs(t, err, domain.ErrProjectNotFound)
+	assert.ErrorIs(t, repo.Delete(ctx, first.ID), domain.ErrProjectNotFound)
+	_, err = repo.Update(ctx, *got)
+	assert.ErrorIs(t, err, domain.ErrProjectNotFound)
+
+	_, err = repo.Find(ctx, domain.ProjectQuery{Cursor: "garbage"})
+	assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+
+	// projects created for a tenant are only found from it
+	acme, globex := domain.WithTenant(ctx, domain.NewID()), domain.WithTenant(ctx, domain.NewID())
+	scoped, err := repo.Create(acme, domain.Project{OwnerID: carol, Name: "acme", CreatedAt: base, UpdatedAt: base})
+	require.NoError(t, err)
+	_, err = repo.GetByID(globex, scoped.ID)
+	assert.ErrorIs(t, err, domain.ErrProjectNotFound)
+	_, err = repo.Update(globex, *scoped)
+	assert.ErrorIs(t, err, domain.ErrProjectNotFound)
+	assert.ErrorIs(t, repo.Delete(globex, scoped.ID), domain.ErrProjectNotFound)
+	page, err := repo.Find(globex, domain.ProjectQuery{})
+	require.NoError(t, err)
+	assert.Empty(t, page.Projects)
+	page, err = repo.Find(acme, domain.ProjectQuery{})
+	require.NoError(t, err)
+	require.Len(t, page.Projects, 1)
+	assert.Equal(t, scoped.ID, page.Projects[0].ID)
+}
+
+func TestOrganizationStores(t *testing.T) {
+	backends := []struct {
+		name string
+		orgs func(t *testing.T) domain.OrganizationRepository
+	}{
+		{"InMemory", func(t *testing.T) domain.OrganizationRepository {
+			return NewInMemoryOrganizationRepository()
+		}},
+		{"SQLite", func(t *testing.T) domain.OrganizationRepository {
+			return NewSQLOrganizationRepository(sqliteTestDB(t), DialectSQLite)
+		}},
+		{"Postgres", func(t *testing.T) domain.OrganizationRepository {
+			return NewSQLOrganizationRepository(postgresTestDB(t), DialectPostgres)
+		}},
+		{"Mongo", func(t *testing.T) domain.OrganizationRepository {
+			return NewOrganizationRepository(mongoTestDB(t).Collection("organizations"))
+		}},
+	}
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) { testOrganizationRepository(t, b.orgs) })
+	}
+}
+
+func testOrganizationRepository(t *testing.T, newRepo func(t *testing.T) domain.OrganizationRepository) {
+	ctx := domain.WithoutTenant(context.Background())
+	repo := newRepo(t)
+	alice := domain.NewID()
+
+	acme, err := repo.Create(ctx, domain.Organization{OwnerID: alice, Name: "Acme"})
+	require.NoError(t, err)
+	assert.False(t, acme.ID.IsZero())
+	assert.False(t, acme.CreatedAt.IsZero())
+	assert.False(t, acme.Personal())
+
+	personal, err := repo.Create(ctx, domain.Organization{ID: alice, OwnerID: alice, Name: "Alice"})
+	require.NoError(t, err)
+	assert.Equal(t, alice, personal.ID, "a preset ID is kept")
+	assert.True(t, personal.Personal())
+	_, err = repo.Create(ctx, domain.Organization{ID: alice, OwnerID: alice, Name: "again"})
+	assert.ErrorIs(t, err, domain.ErrOrganizationExists)
+
+	got, err := repo.GetByID(ctx, acme.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Acme", got.Name)
+	assert.Equal(t, alice, got.OwnerID)
+	_, err = repo.GetByID(ctx, domain.NewID())
+	assert.ErrorIs(t, err, domain.ErrOrganizationNotFound)
+
+	orgs, err := repo.GetByIDs(ctx, []domain.ID{acme.ID, domain.NewID(), alice})
+	require.NoError(t, err)
+	require.Len(t, orgs, 2, "unknown IDs are skipped")
+	assert.ElementsMatch(t, []domain.ID{acme.ID, alice}, []domain.ID{orgs[0].ID, orgs[1].ID})
+	orgs, err = repo.GetByIDs(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, orgs)
+}
+
+func TestMigrateMovesDataIntoPersonalOrganizations(t *testing.T) {
+	ctx := domain.WithoutTenant(context.Background())
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	// a database from before organizations existed
+	_, err = db.Exec(DialectSQLite.ddl(`CREATE TABLE schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL
+	)`))
+	require.NoError(t, err)
+	for _, m := range migrations {
+		if m.version < 14 {
+			require.NoError(t, applyMigration(ctx, db, DialectSQLite, m))
+		}
+	}
+	user, admin, task := domain.NewID(), domain.NewID(), domain.NewID()
+	now := time.Now().UTC()
+	_, err = db.Exec(`INSERT INTO users (id, name, email, password, role, created_at) VALUES (?, 'Alice', 'a@x.com', 'hash', 'user', ?)`, string(user), now)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO users (id, name, email, password, role, created_at) VALUES (?, 'Bob', 'b@x.com', 'hash', 'admin', ?)`, string(admin), now)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO tasks (id, owner_id, title, description, due_date, status, created_at, updated_at) VALUES (?, ?, 'A', '', ?, 'Pending', ?, ?)`,
+		string(task), string(user), now, now, now)
+	require.NoError(t, err)
+
+	require.NoError(t, Migrate(ctx, db, DialectSQLite))
+
+	org, err := NewSQLOrganizationRepository(db, DialectSQLite).GetByID(ctx, user)
+	require.NoError(t, err)
+	assert.True(t, org.Personal())
+	assert.Equal(t, "Alice", org.Name)
+	personal := domain.WithTenant(ctx, user)
+	users := NewSQLUserRepository(db, DialectSQLite)
+	got, err := users.GetByID(personal, user)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.ID{user}, got.OrgIDs)
+	assert.Equal(t, "user", got.RoleIn(user))
+	got, err = users.GetByID(ctx, admin)
+	require.NoError(t, err)
+	assert.Equal(t, "admin", got.RoleIn(admin), "admins stay admins of their organizations")
+	tasks := NewSQLTaskRepository(db, DialectSQLite)
+	_, err = tasks.GetByID(personal, task)
+	require.NoError(t, err)
+	_, err = tasks.GetByID(domain.WithTenant(ctx, domain.NewID()), task)
+	assert.ErrorIs(t, err, domain.ErrTaskNotFound)
+}
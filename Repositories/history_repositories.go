@@ -0,0 +1,100 @@
+package Repositories
+
+import (
+	"context"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// compile‑time check that HistoryRepository implements domain.HistoryRepository
+var _ domain.HistoryRepository = (*HistoryRepository)(nil)
+
+type HistoryRepository struct {
+	Coll *mongo.Collection
+}
+
+func NewHistoryRepository(c *mongo.Collection) *HistoryRepository {
+	return &HistoryRepository{Coll: c}
+}
+
+// EnsureIndexes creates the indexes behind the per-task history and the
+// audit queries by organization, actor and time.
+func (r *HistoryRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.Coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "org_id", Value: 1}, {Key: "at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "at", Value: 1}, {Key: "_id", Value: 1}}},
+	})
+	return err
+}
+
+func (r *HistoryRepository) Append(ctx context.Context, entry domain.HistoryEntry) (*domain.HistoryEntry, error) {
+	entry.ID = domain.NewID()
+	org, err := tenantOf(ctx, entry.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	entry.OrgID = org
+	if _, err := r.Coll.InsertOne(ctx, entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *HistoryRepository) Find(ctx context.Context, q domain.HistoryQuery) (*domain.HistoryPage, error) {
+	var and []bson.M
+	if q.TaskID != nil {
+		and = append(and, bson.M{"task_id": *q.TaskID})
+	}
+	if q.ActorID != nil {
+		and = append(and, bson.M{"actor_id": *q.ActorID})
+	}
+	rng := bson.M{}
+	if q.From != nil {
+		rng["$gte"] = *q.From
+	}
+	if q.To != nil {
+		rng["$lte"] = *q.To
+	}
+	if len(rng) > 0 {
+		and = append(and, bson.M{"at": rng})
+	}
+	if q.Cursor != "" {
+		at, id, err := domain.DecodeHistoryCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, bson.M{"$or": []bson.M{
+			{"at": bson.M{"$gt": at}},
+			{"at": at, "_id": bson.M{"$gt": id}},
+		}})
+	}
+	filter := tenantFilter(ctx, bson.M{})
+	if len(and) > 0 {
+		filter["$and"] = and
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "at", Value: 1}, {Key: "_id", Value: 1}})
+	if q.Limit > 0 {
+		// fetch one extra document to learn whether another page exists
+		opts.SetLimit(int64(q.Limit) + 1)
+	}
+
+	cur, err := r.Coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var entries []domain.HistoryEntry
+	if err := cur.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	page := &domain.HistoryPage{Entries: entries}
+	if q.Limit > 0 && len(entries) > q.Limit {
+		page.Entries = entries[:q.Limit]
+		page.NextCursor = domain.EncodeHistoryCursor(page.Entries[q.Limit-1])
+	}
+	return page, nil
+}
@@ -0,0 +1,115 @@
+package Repositories
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+// compile‑time check that InMemoryCommentRepository implements domain.CommentRepository
+var _ domain.CommentRepository = (*InMemoryCommentRepository)(nil)
+
+type InMemoryCommentRepository struct {
+	mu       sync.RWMutex
+	comments map[domain.ID]domain.Comment
+}
+
+func NewInMemoryCommentRepository() *InMemoryCommentRepository {
+	return &InMemoryCommentRepository{comments: make(map[domain.ID]domain.Comment)}
+}
+
+func cloneComment(c domain.Comment) domain.Comment {
+	c.Edits = append([]domain.CommentEdit(nil), c.Edits...)
+	return c
+}
+
+func (r *InMemoryCommentRepository) Create(ctx context.Context, c domain.Comment) (*domain.Comment, error) {
+	c.ID = domain.NewID()
+	org, err := tenantOf(ctx, c.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	c.OrgID = org
+	c = cloneComment(c)
+	r.mu.Lock()
+	r.comments[c.ID] = c
+	r.mu.Unlock()
+	out := cloneComment(c)
+	return &out, nil
+}
+
+func (r *InMemoryCommentRepository) GetByID(ctx context.Context, id domain.ID) (*domain.Comment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.comments[id]
+	if !ok || !inTenant(ctx, c.OrgID) {
+		return nil, domain.ErrCommentNotFound
+	}
+	out := cloneComment(c)
+	return &out, nil
+}
+
+func (r *InMemoryCommentRepository) Update(ctx context.Context, c domain.Comment) (*domain.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.comments[c.ID]
+	if !ok || !inTenant(ctx, stored.OrgID) {
+		return nil, domain.ErrCommentNotFound
+	}
+	stored.Body, stored.UpdatedAt = c.Body, c.UpdatedAt
+	stored.Edits = append([]domain.CommentEdit(nil), c.Edits...)
+	r.comments[c.ID] = stored
+	out := cloneComment(stored)
+	return &out, nil
+}
+
+func (r *InMemoryCommentRepository) Delete(ctx context.Context, id domain.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.comments[id]; !ok || !inTenant(ctx, c.OrgID) {
+		return domain.ErrCommentNotFound
+	}
+	delete(r.comments, id)
+	return nil
+}
+
+func (r *InMemoryCommentRepository) Find(ctx context.Context, q domain.CommentQuery) (*domain.CommentPage, error) {
+	var after *domain.Comment
+	if q.Cursor != "" {
+		at, id, err := domain.DecodeCommentCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		after = &domain.Comment{ID: id, CreatedAt: at}
+	}
+
+	r.mu.RLock()
+	var comments []domain.Comment
+	for _, c := range r.comments {
+		if c.TaskID == q.TaskID && inTenant(ctx, c.OrgID) && (after == nil || compareComments(c, *after) > 0) {
+			comments = append(comments, cloneComment(c))
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(comments, func(i, j int) bool {
+		return compareComments(comments[i], comments[j]) < 0
+	})
+	page := &domain.CommentPage{Comments: comments}
+	if q.Limit > 0 && len(comments) > q.Limit {
+		page.Comments = comments[:q.Limit]
+		page.NextCursor = domain.EncodeCommentCursor(page.Comments[q.Limit-1])
+	}
+	return page, nil
+}
+
+// compareComments orders comments by creation time, then by ID.
+func compareComments(a, b domain.Comment) int {
+	if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+		return c
+	}
+	return strings.Compare(string(a.ID), string(b.ID))
+}
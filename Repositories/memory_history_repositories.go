@@ -0,0 +1,85 @@
+package Repositories
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+// compile‑time check that InMemoryHistoryRepository implements domain.HistoryRepository
+var _ domain.HistoryRepository = (*InMemoryHistoryRepository)(nil)
+
+type InMemoryHistoryRepository struct {
+	mu      sync.RWMutex
+	entries []domain.HistoryEntry
+}
+
+func NewInMemoryHistoryRepository() *InMemoryHistoryRepository {
+	return &InMemoryHistoryRepository{}
+}
+
+func (r *InMemoryHistoryRepository) Append(ctx context.Context, entry domain.HistoryEntry) (*domain.HistoryEntry, error) {
+	entry.ID = domain.NewID()
+	org, err := tenantOf(ctx, entry.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	entry.OrgID = org
+	entry.Changes = append([]domain.FieldChange(nil), entry.Changes...)
+	r.mu.Lock()
+	r.entries = append(r.entries, entry)
+	r.mu.Unlock()
+	return &entry, nil
+}
+
+func (r *InMemoryHistoryRepository) Find(ctx context.Context, q domain.HistoryQuery) (*domain.HistoryPage, error) {
+	var after *domain.HistoryEntry
+	if q.Cursor != "" {
+		at, id, err := domain.DecodeHistoryCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		after = &domain.HistoryEntry{ID: id, At: at}
+	}
+
+	r.mu.RLock()
+	var entries []domain.HistoryEntry
+	for _, e := range r.entries {
+		if inTenant(ctx, e.OrgID) && matchesHistoryQuery(e, q) && (after == nil || compareHistory(e, *after) > 0) {
+			e.Changes = append([]domain.FieldChange(nil), e.Changes...)
+			entries = append(entries, e)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return compareHistory(entries[i], entries[j]) < 0
+	})
+	page := &domain.HistoryPage{Entries: entries}
+	if q.Limit > 0 && len(entries) > q.Limit {
+		page.Entries = entries[:q.Limit]
+		page.NextCursor = domain.EncodeHistoryCursor(page.Entries[q.Limit-1])
+	}
+	return page, nil
+}
+
+func matchesHistoryQuery(e domain.HistoryEntry, q domain.HistoryQuery) bool {
+	if q.TaskID != nil && e.TaskID != *q.TaskID {
+		return false
+	}
+	if q.ActorID != nil && e.ActorID != *q.ActorID {
+		return false
+	}
+	return inRange(e.At, q.From, q.To)
+}
+
+// compareHistory orders entries by time, then by ID.
+func compareHistory(a, b domain.HistoryEntry) int {
+	if c := a.At.Compare(b.At); c != 0 {
+		return c
+	}
+	return strings.Compare(string(a.ID), string(b.ID))
+}
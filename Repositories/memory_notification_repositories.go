@@ -0,0 +1,91 @@
+package Repositories
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+// compile‑time check that InMemoryNotificationRepository implements domain.NotificationRepository
+var _ domain.NotificationRepository = (*InMemoryNotificationRepository)(nil)
+
+type InMemoryNotificationRepository struct {
+	mu            sync.RWMutex
+	notifications map[domain.ID]domain.Notification
+}
+
+func NewInMemoryNotificationRepository() *InMemoryNotificationRepository {
+	return &InMemoryNotificationRepository{notifications: make(map[domain.ID]domain.Notification)}
+}
+
+func (r *InMemoryNotificationRepository) Create(ctx context.Context, n domain.Notification) (*domain.Notification, error) {
+	n.ID = domain.NewID()
+	org, err := tenantOf(ctx, n.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	n.OrgID = org
+	r.mu.Lock()
+	r.notifications[n.ID] = n
+	r.mu.Unlock()
+	return &n, nil
+}
+
+func (r *InMemoryNotificationRepository) Find(ctx context.Context, q domain.NotificationQuery) (*domain.NotificationPage, error) {
+	var before *domain.Notification
+	if q.Cursor != "" {
+		at, id, err := domain.DecodeNotificationCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		before = &domain.Notification{ID: id, CreatedAt: at}
+	}
+
+	r.mu.RLock()
+	var notifications []domain.Notification
+	for _, n := range r.notifications {
+		if n.UserID != q.UserID || !inTenant(ctx, n.OrgID) || (q.Unread && n.ReadAt != nil) {
+			continue
+		}
+		if before == nil || compareNotifications(n, *before) < 0 {
+			notifications = append(notifications, n)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(notifications, func(i, j int) bool {
+		return compareNotifications(notifications[i], notifications[j]) > 0
+	})
+	page := &domain.NotificationPage{Notifications: notifications}
+	if q.Limit > 0 && len(notifications) > q.Limit {
+		page.Notifications = notifications[:q.Limit]
+		page.NextCursor = domain.EncodeNotificationCursor(page.Notifications[q.Limit-1])
+	}
+	return page, nil
+}
+
+func (r *InMemoryNotificationRepository) MarkRead(ctx context.Context, user, id domain.ID, at time.Time) (*domain.Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n, ok := r.notifications[id]
+	if !ok || n.UserID != user || !inTenant(ctx, n.OrgID) {
+		return nil, domain.ErrNotificationNotFound
+	}
+	if n.ReadAt == nil {
+		n.ReadAt = &at
+		r.notifications[id] = n
+	}
+	return &n, nil
+}
+
+// compareNotifications orders notifications by creation time, then by ID.
+func compareNotifications(a, b domain.Notification) int {
+	if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+		return c
+	}
+	return strings.Compare(string(a.ID), string(b.ID))
+}
@@ -0,0 +1,59 @@
+package Repositories
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+// compile‑time check that InMemoryOrganizationRepository implements domain.OrganizationRepository
+var _ domain.OrganizationRepository = (*InMemoryOrganizationRepository)(nil)
+
+type InMemoryOrganizationRepository struct {
+	mu   sync.RWMutex
+	orgs map[domain.ID]domain.Organization
+}
+
+func NewInMemoryOrganizationRepository() *InMemoryOrganizationRepository {
+	return &InMemoryOrganizationRepository{orgs: make(map[domain.ID]domain.Organization)}
+}
+
+func (r *InMemoryOrganizationRepository) Create(ctx context.Context, o domain.Organization) (*domain.Organization, error) {
+	if o.ID.IsZero() {
+		o.ID = domain.NewID()
+	}
+	o.CreatedAt = time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, taken := r.orgs[o.ID]; taken {
+		return nil, domain.ErrOrganizationExists
+	}
+	r.orgs[o.ID] = o
+	return &o, nil
+}
+
+func (r *InMemoryOrganizationRepository) GetByID(ctx context.Context, id domain.ID) (*domain.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	o, ok := r.orgs[id]
+	if !ok {
+		return nil, domain.ErrOrganizationNotFound
+	}
+	return &o, nil
+}
+
+func (r *InMemoryOrganizationRepository) GetByIDs(ctx context.Context, ids []domain.ID) ([]domain.Organization, error) {
+	r.mu.RLock()
+	var orgs []domain.Organization
+	for _, id := range ids {
+		if o, ok := r.orgs[id]; ok {
+			orgs = append(orgs, o)
+		}
+	}
+	r.mu.RUnlock()
+	sort.Slice(orgs, func(i, j int) bool { return orgs[i].ID < orgs[j].ID })
+	return orgs, nil
+}
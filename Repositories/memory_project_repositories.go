@@ -0,0 +1,129 @@
+package Repositories
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+// compile‑time check that InMemoryProjectRepository implements domain.ProjectRepository
+var _ domain.ProjectRepository = (*InMemoryProjectRepository)(nil)
+
+type InMemoryProjectRepository struct {
+	mu       sync.RWMutex
+	projects map[domain.ID]domain.Project
+}
+
+func NewInMemoryProjectRepository() *InMemoryProjectRepository {
+	return &InMemoryProjectRepository{projects: make(map[domain.ID]domain.Project)}
+}
+
+func cloneProject(p domain.Project) domain.Project {
+	p.Members = append([]domain.ProjectMember(nil), p.Members...)
+	if p.ArchivedAt != nil {
+		archived := *p.ArchivedAt
+		p.ArchivedAt = &archived
+	}
+	return p
+}
+
+func (r *InMemoryProjectRepository) Create(ctx context.Context, p domain.Project) (*domain.Project, error) {
+	p.ID = domain.NewID()
+	org, err := tenantOf(ctx, p.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	p.OrgID = org
+	p = cloneProject(p)
+	r.mu.Lock()
+	r.projects[p.ID] = p
+	r.mu.Unlock()
+	out := cloneProject(p)
+	return &out, nil
+}
+
+func (r *InMemoryProjectRepository) GetByID(ctx context.Context, id domain.ID) (*domain.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.projects[id]
+	if !ok || !inTenant(ctx, p.OrgID) {
+		return nil, domain.ErrProjectNotFound
+	}
+	out := cloneProject(p)
+	return &out, nil
+}
+
+func (r *InMemoryProjectRepository) Update(ctx context.Context, p domain.Project) (*domain.Project, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.projects[p.ID]
+	if !ok || !inTenant(ctx, stored.OrgID) {
+		return nil, domain.ErrProjectNotFound
+	}
+	stored.Name, stored.Description = p.Name, p.Description
+	stored.Members, stored.ArchivedAt, stored.UpdatedAt = p.Members, p.ArchivedAt, p.UpdatedAt
+	stored = cloneProject(stored)
+	r.projects[p.ID] = stored
+	out := cloneProject(stored)
+	return &out, nil
+}
+
+func (r *InMemoryProjectRepository) Delete(ctx context.Context, id domain.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p, ok := r.projects[id]; !ok || !inTenant(ctx, p.OrgID) {
+		return domain.ErrProjectNotFound
+	}
+	delete(r.projects, id)
+	return nil
+}
+
+func (r *InMemoryProjectRepository) Find(ctx context.Context, q domain.ProjectQuery) (*domain.ProjectPage, error) {
+	var after *domain.Project
+	if q.Cursor != "" {
+		at, id, err := domain.DecodeProjectCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		after = &domain.Project{ID: id, CreatedAt: at}
+	}
+
+	r.mu.RLock()
+	var projects []domain.Project
+	for _, p := range r.projects {
+		if !inTenant(ctx, p.OrgID) {
+			continue
+		}
+		if q.MemberID != nil && p.RoleOf(*q.MemberID) == "" {
+			continue
+		}
+		if q.Archived != nil && p.Archived() != *q.Archived {
+			continue
+		}
+		if after == nil || compareProjects(p, *after) > 0 {
+			projects = append(projects, cloneProject(p))
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(projects, func(i, j int) bool {
+		return compareProjects(projects[i], projects[j]) < 0
+	})
+	page := &domain.ProjectPage{Projects: projects}
+	if q.Limit > 0 && len(projects) > q.Limit {
+		page.Projects = projects[:q.Limit]
+		page.NextCursor = domain.EncodeProjectCursor(page.Projects[q.Limit-1])
+	}
+	return page, nil
+}
+
+// compareProjects orders projects by creation time, then by ID.
+func compareProjects(a, b domain.Project) int {
+	if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+		return c
+	}
+	return strings.Compare(string(a.ID), string(b.ID))
+}
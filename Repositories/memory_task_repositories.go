@@ -0,0 +1,344 @@
+package Repositories
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+// compile‑time check that InMemoryTaskRepository implements domain.TaskRepository
+var _ domain.TaskRepository = (*InMemoryTaskRepository)(nil)
+
+// InMemoryTaskRepository keeps tasks in process memory. It mirrors the
+// Mongo repository's behaviour and is meant for demos and tests.
+type InMemoryTaskRepository struct {
+	mu    sync.RWMutex
+	tasks map[domain.ID]domain.Task
+}
+
+func NewInMemoryTaskRepository() *InMemoryTaskRepository {
+	return &InMemoryTaskRepository{tasks: make(map[domain.ID]domain.Task)}
+}
+
+func (r *InMemoryTaskRepository) Find(ctx context.Context, q domain.TaskQuery) (*domain.TaskPage, error) {
+	sortBy := q.SortBy
+	if sortBy == "" {
+		sortBy = domain.SortByCreatedAt
+	}
+	var after *domain.TaskCursor
+	if q.Cursor != "" {
+		c, err := domain.DecodeTaskCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if sortBy != domain.SortByTitle {
+			if _, err := c.TimeValue(); err != nil {
+				return nil, err
+			}
+		}
+		after = c
+	}
+
+	r.mu.RLock()
+	var tasks []domain.Task
+	for _, t := range r.tasks {
+		if inTenant(ctx, t.OrgID) && matchesTaskQuery(t, q) {
+			tasks = append(tasks, cloneTask(t))
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(tasks, func(i, j int) bool {
+		c := compareTasks(tasks[i], tasks[j], sortBy)
+		if q.SortDesc {
+			return c > 0
+		}
+		return c < 0
+	})
+	if after != nil {
+		pivot := domain.Task{TaskID: after.ID}
+		setSortValue(&pivot, sortBy, after.Value)
+		start := sort.Search(len(tasks), func(i int) bool {
+			c := compareTasks(tasks[i], pivot, sortBy)
+			if q.SortDesc {
+				return c < 0
+			}
+			return c > 0
+		})
+		tasks = tasks[start:]
+	}
+
+	page := &domain.TaskPage{Tasks: tasks}
+	if q.Limit > 0 && len(tasks) > q.Limit {
+		page.Tasks = tasks[:q.Limit]
+		page.NextCursor = domain.EncodeTaskCursor(page.Tasks[q.Limit-1], sortBy, q.SortDesc)
+	}
+	return page, nil
+}
+
+// Ping only fails when ctx is done; memory is always reachable.
+func (r *InMemoryTaskRepository) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+func (r *InMemoryTaskRepository) GetByID(ctx context.Context, id domain.ID) (*domain.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.tasks[id]
+	if !ok || !inTenant(ctx, t.OrgID) {
+		return nil, domain.ErrTaskNotFound
+	}
+	t = cloneTask(t)
+	return &t, nil
+}
+
+func (r *InMemoryTaskRepository) Create(ctx context.Context, task domain.Task) (*domain.Task, error) {
+	task.TaskID = domain.NewID()
+	org, err := tenantOf(ctx, task.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	task.OrgID = org
+	task.CreatedAt = time.Now()
+	task.UpdatedAt = task.CreatedAt
+	task.Version = 1
+
+	r.mu.Lock()
+	r.tasks[task.TaskID] = cloneTask(task)
+	r.mu.Unlock()
+	return &task, nil
+}
+
+// Update overwrites the same fields the Mongo repository $sets; owner and
+// creation time are never changed.
+func (r *InMemoryTaskRepository) Update(ctx context.Context, id domain.ID, task domain.Task) (*domain.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	existing, ok := r.tasks[id]
+	if !ok || !inTenant(ctx, existing.OrgID) {
+		return nil, domain.ErrTaskNotFound
+	}
+	if existing.Version != task.Version {
+		return nil, domain.ErrVersionConflict
+	}
+	existing.Title = task.Title
+	existing.Description = task.Description
+	existing.DueDate = task.DueDate
+	existing.Status = task.Status
+	existing.CompletedAt = task.CompletedAt
+	existing.ParentID = task.ParentID
+	existing.ProjectID = task.ProjectID
+	existing.DependsOn = task.DependsOn
+	existing.Recurrence = task.Recurrence
+	existing.Tags = task.Tags
+	existing.Priority = task.Priority
+	existing.Assignees = task.Assignees
+	existing.Watchers = task.Watchers
+	existing.UpdatedAt = time.Now()
+	existing.Version++
+	existing = cloneTask(existing)
+	r.tasks[id] = existing
+
+	updated := cloneTask(existing)
+	return &updated, nil
+}
+
+func (r *InMemoryTaskRepository) Delete(ctx context.Context, id domain.ID, version int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.tasks[id]
+	if !ok || t.DeletedAt != nil || !inTenant(ctx, t.OrgID) {
+		return domain.ErrTaskNotFound
+	}
+	if t.Version != version {
+		return domain.ErrVersionConflict
+	}
+	now := time.Now()
+	t.DeletedAt = &now
+	t.Version++
+	r.tasks[id] = t
+	return nil
+}
+
+func (r *InMemoryTaskRepository) Restore(ctx context.Context, id domain.ID) (*domain.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.tasks[id]
+	if !ok || !inTenant(ctx, t.OrgID) {
+		return nil, domain.ErrTaskNotFound
+	}
+	if t.DeletedAt == nil {
+		return nil, domain.ErrTaskNotInTrash
+	}
+	t.DeletedAt = nil
+	t.UpdatedAt = time.Now()
+	t.Version++
+	r.tasks[id] = t
+	restored := cloneTask(t)
+	return &restored, nil
+}
+
+func (r *InMemoryTaskRepository) Purge(ctx context.Context, id domain.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.tasks[id]
+	if !ok || !inTenant(ctx, t.OrgID) {
+		return domain.ErrTaskNotFound
+	}
+	if t.DeletedAt == nil {
+		return domain.ErrTaskNotInTrash
+	}
+	delete(r.tasks, id)
+	return nil
+}
+
+func (r *InMemoryTaskRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) ([]domain.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var purged []domain.Task
+	for id, t := range r.tasks {
+		if t.DeletedAt != nil && t.DeletedAt.Before(cutoff) && inTenant(ctx, t.OrgID) {
+			delete(r.tasks, id)
+			purged = append(purged, t)
+		}
+	}
+	return purged, nil
+}
+
+func (r *InMemoryTaskRepository) CountTags(ctx context.Context, owner *domain.ID) ([]domain.TagCount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var tasks []domain.Task
+	for _, t := range r.tasks {
+		if t.DeletedAt == nil && inTenant(ctx, t.OrgID) && (owner == nil || t.OwnerID == *owner) {
+			tasks = append(tasks, t)
+		}
+	}
+	return domain.CountTags(tasks), nil
+}
+
+// cloneTask copies t so callers cannot mutate stored state through pointers.
+func cloneTask(t domain.Task) domain.Task {
+	if t.CompletedAt != nil {
+		completed := *t.CompletedAt
+		t.CompletedAt = &completed
+	}
+	if t.DeletedAt != nil {
+		deleted := *t.DeletedAt
+		t.DeletedAt = &deleted
+	}
+	if t.ParentID != nil {
+		parent := *t.ParentID
+		t.ParentID = &parent
+	}
+	if t.ProjectID != nil {
+		project := *t.ProjectID
+		t.ProjectID = &project
+	}
+	if t.DependsOn != nil {
+		t.DependsOn = append([]domain.ID(nil), t.DependsOn...)
+	}
+	if t.Tags != nil {
+		t.Tags = append([]string(nil), t.Tags...)
+	}
+	if t.Assignees != nil {
+		t.Assignees = append([]domain.ID(nil), t.Assignees...)
+	}
+	if t.Watchers != nil {
+		t.Watchers = append([]domain.ID(nil), t.Watchers...)
+	}
+	return t
+}
+
+func matchesTaskQuery(t domain.Task, q domain.TaskQuery) bool {
+	if (t.DeletedAt != nil) != q.Trashed {
+		return false
+	}
+	if q.OwnerID != nil && t.OwnerID != *q.OwnerID {
+		return false
+	}
+	if q.ParentID != nil && (t.ParentID == nil || *t.ParentID != *q.ParentID) {
+		return false
+	}
+	if q.ProjectID != nil && (t.ProjectID == nil || *t.ProjectID != *q.ProjectID) {
+		return false
+	}
+	if q.DependsOn != nil && !t.HasDependency(*q.DependsOn) {
+		return false
+	}
+	if q.AssigneeID != nil && !t.IsAssignee(*q.AssigneeID) {
+		return false
+	}
+	if !t.MatchesTags(q.Tags, q.TagMatch) {
+		return false
+	}
+	if len(q.Statuses) > 0 {
+		found := false
+		for _, s := range q.Statuses {
+			if t.Status == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if q.TitleContains != "" && !strings.Contains(strings.ToLower(t.Title), strings.ToLower(q.TitleContains)) {
+		return false
+	}
+	return inRange(t.DueDate, q.DueFrom, q.DueTo) &&
+		inRange(t.CreatedAt, q.CreatedFrom, q.CreatedTo) &&
+		inRange(t.UpdatedAt, q.UpdatedFrom, q.UpdatedTo)
+}
+
+func inRange(v time.Time, from, to *time.Time) bool {
+	if from != nil && v.Before(*from) {
+		return false
+	}
+	if to != nil && v.After(*to) {
+		return false
+	}
+	return true
+}
+
+// compareTasks orders tasks by the sort key, then by ID, like the
+// compound sort used by the Mongo repository.
+func compareTasks(a, b domain.Task, f domain.TaskSortField) int {
+	var c int
+	switch f {
+	case domain.SortByTitle:
+		c = strings.Compare(a.Title, b.Title)
+	case domain.SortByDueDate:
+		c = a.DueDate.Compare(b.DueDate)
+	case domain.SortByUpdatedAt:
+		c = a.UpdatedAt.Compare(b.UpdatedAt)
+	default:
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	if c != 0 {
+		return c
+	}
+	return strings.Compare(string(a.TaskID), string(b.TaskID))
+}
+
+// setSortValue writes a cursor value back onto t so it can be compared.
+func setSortValue(t *domain.Task, f domain.TaskSortField, v string) {
+	if f == domain.SortByTitle {
+		t.Title = v
+		return
+	}
+	ts, _ := time.Parse(time.RFC3339Nano, v)
+	switch f {
+	case domain.SortByDueDate:
+		t.DueDate = ts
+	case domain.SortByUpdatedAt:
+		t.UpdatedAt = ts
+	default:
+		t.CreatedAt = ts
+	}
+}
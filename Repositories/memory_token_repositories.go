@@ -0,0 +1,100 @@
+package Repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+// compile‑time checks for the in-memory token stores
+var (
+	_ domain.RefreshTokenRepository = (*InMemoryRefreshTokenRepository)(nil)
+	_ domain.TokenDenylist          = (*InMemoryTokenDenylist)(nil)
+)
+
+type InMemoryRefreshTokenRepository struct {
+	mu     sync.Mutex
+	tokens map[domain.ID]domain.RefreshToken
+}
+
+func NewInMemoryRefreshTokenRepository() *InMemoryRefreshTokenRepository {
+	return &InMemoryRefreshTokenRepository{tokens: make(map[domain.ID]domain.RefreshToken)}
+}
+
+func (r *InMemoryRefreshTokenRepository) Create(ctx context.Context, token domain.RefreshToken) (*domain.RefreshToken, error) {
+	token.ID = domain.NewID()
+	token.CreatedAt = time.Now()
+	r.mu.Lock()
+	r.tokens[token.ID] = token
+	r.mu.Unlock()
+	return &token, nil
+}
+
+func (r *InMemoryRefreshTokenRepository) GetByHash(ctx context.Context, hash string) (*domain.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.tokens {
+		if t.TokenHash == hash {
+			return &t, nil
+		}
+	}
+	return nil, domain.ErrRefreshTokenNotFound
+}
+
+func (r *InMemoryRefreshTokenRepository) MarkRotated(ctx context.Context, id domain.ID, replacedBy domain.ID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.tokens[id]
+	if !ok || t.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	t.RevokedAt, t.ReplacedBy = &now, replacedBy
+	r.tokens[id] = t
+	return true, nil
+}
+
+func (r *InMemoryRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID domain.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for id, t := range r.tokens {
+		if t.FamilyID == familyID && t.RevokedAt == nil {
+			t.RevokedAt = &now
+			r.tokens[id] = t
+		}
+	}
+	return nil
+}
+
+type InMemoryTokenDenylist struct {
+	mu      sync.Mutex
+	revoked map[string]time.Time
+}
+
+func NewInMemoryTokenDenylist() *InMemoryTokenDenylist {
+	return &InMemoryTokenDenylist{revoked: make(map[string]time.Time)}
+}
+
+// Revoke also drops entries that have expired so the map stays small.
+func (d *InMemoryTokenDenylist) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	for id, exp := range d.revoked {
+		if exp.Before(now) {
+			delete(d.revoked, id)
+		}
+	}
+	d.revoked[tokenID] = expiresAt
+	return nil
+}
+
+func (d *InMemoryTokenDenylist) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	exp, ok := d.revoked[tokenID]
+	return ok && exp.After(time.Now()), nil
+}
@@ -0,0 +1,165 @@
+package Repositories
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+// compile‑time check that InMemoryUserRepository implements domain.UserRepository
+var _ domain.UserRepository = (*InMemoryUserRepository)(nil)
+
+// InMemoryUserRepository keeps users in process memory. Emails are unique,
+// matching the index the Mongo repository creates. A context scoped to a
+// tenant only sees the users of that organization.
+type InMemoryUserRepository struct {
+	mu      sync.RWMutex
+	users   map[domain.ID]domain.User
+	byEmail map[string]domain.ID
+}
+
+func NewInMemoryUserRepository() *InMemoryUserRepository {
+	return &InMemoryUserRepository{
+		users:   make(map[domain.ID]domain.User),
+		byEmail: make(map[string]domain.ID),
+	}
+}
+
+func (r *InMemoryUserRepository) Create(ctx context.Context, user domain.User) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, taken := r.byEmail[user.Email]; taken {
+		return nil, domain.ErrDuplicateEmail
+	}
+	user.UserID = domain.NewID()
+	user.CreatedAt = time.Now()
+	r.users[user.UserID] = cloneUser(user)
+	r.byEmail[user.Email] = user.UserID
+	return &user, nil
+}
+
+// Ping only fails when ctx is done; memory is always reachable.
+func (r *InMemoryUserRepository) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+func (r *InMemoryUserRepository) GetByID(ctx context.Context, id domain.ID) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	u, ok := r.users[id]
+	if !ok || !userInTenant(ctx, &u) {
+		return nil, domain.ErrUserNotFound
+	}
+	u = cloneUser(u)
+	return &u, nil
+}
+
+func (r *InMemoryUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	id, ok := r.byEmail[email]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	u := r.users[id]
+	if !userInTenant(ctx, &u) {
+		return nil, domain.ErrUserNotFound
+	}
+	u = cloneUser(u)
+	return &u, nil
+}
+
+// GetAll returns users in creation order.
+func (r *InMemoryUserRepository) GetAll(ctx context.Context) ([]*domain.User, error) {
+	r.mu.RLock()
+	users := make([]*domain.User, 0, len(r.users))
+	for _, u := range r.users {
+		if userInTenant(ctx, &u) {
+			u := cloneUser(u)
+			users = append(users, &u)
+		}
+	}
+	r.mu.RUnlock()
+	sort.Slice(users, func(i, j int) bool { return users[i].UserID < users[j].UserID })
+	return users, nil
+}
+
+func (r *InMemoryUserRepository) PromoteUser(ctx context.Context, id, org domain.ID) (*domain.User, error) {
+	return r.changeOrganizations(ctx, id, func(u *domain.User) error {
+		if !u.InOrganization(org) {
+			return domain.ErrUserNotFound
+		}
+		if u.RoleIn(org) != "admin" {
+			u.AdminOrgIDs = append(u.AdminOrgIDs, org)
+		}
+		return nil
+	})
+}
+
+func (r *InMemoryUserRepository) AddOrganization(ctx context.Context, id, org domain.ID) (*domain.User, error) {
+	return r.changeOrganizations(ctx, id, func(u *domain.User) error {
+		if !u.InOrganization(org) {
+			u.OrgIDs = append(u.OrgIDs, org)
+		}
+		return nil
+	})
+}
+
+func (r *InMemoryUserRepository) RemoveOrganization(ctx context.Context, id, org domain.ID) (*domain.User, error) {
+	return r.changeOrganizations(ctx, id, func(u *domain.User) error {
+		u.OrgIDs = withoutID(u.OrgIDs, org)
+		u.AdminOrgIDs = withoutID(u.AdminOrgIDs, org)
+		return nil
+	})
+}
+
+func (r *InMemoryUserRepository) changeOrganizations(ctx context.Context, id domain.ID, change func(*domain.User) error) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok || !userInTenant(ctx, &u) {
+		return nil, domain.ErrUserNotFound
+	}
+	u = cloneUser(u)
+	if err := change(&u); err != nil {
+		return nil, err
+	}
+	r.users[id] = u
+	u = cloneUser(u)
+	return &u, nil
+}
+
+// userInTenant reports whether u is visible from ctx: unscoped contexts
+// see every user, scoped ones the members of their organization.
+func userInTenant(ctx context.Context, u *domain.User) bool {
+	if tenant, ok := domain.TenantFrom(ctx); ok {
+		return u.InOrganization(tenant)
+	}
+	return domain.AllTenants(ctx)
+}
+
+// cloneUser copies u so callers cannot mutate stored state through its
+// organization lists.
+func cloneUser(u domain.User) domain.User {
+	if u.OrgIDs != nil {
+		u.OrgIDs = append([]domain.ID(nil), u.OrgIDs...)
+	}
+	if u.AdminOrgIDs != nil {
+		u.AdminOrgIDs = append([]domain.ID(nil), u.AdminOrgIDs...)
+	}
+	return u
+}
+
+// withoutID returns ids without id, keeping their order.
+func withoutID(ids []domain.ID, id domain.ID) []domain.ID {
+	var kept []domain.ID
+	for _, o := range ids {
+		if o != id {
+			kept = append(kept, o)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,125 @@
+package Repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// compile‑time check that OrganizationRepository implements domain.OrganizationRepository
+var _ domain.OrganizationRepository = (*OrganizationRepository)(nil)
+
+type OrganizationRepository struct {
+	Coll *mongo.Collection
+}
+
+func NewOrganizationRepository(c *mongo.Collection) *OrganizationRepository {
+	return &OrganizationRepository{Coll: c}
+}
+
+func (r *OrganizationRepository) Create(ctx context.Context, o domain.Organization) (*domain.Organization, error) {
+	if o.ID.IsZero() {
+		o.ID = domain.NewID()
+	}
+	o.CreatedAt = time.Now()
+	if _, err := r.Coll.InsertOne(ctx, o); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, domain.ErrOrganizationExists
+		}
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (r *OrganizationRepository) GetByID(ctx context.Context, id domain.ID) (*domain.Organization, error) {
+	var o domain.Organization
+	if err := r.Coll.FindOne(ctx, bson.M{"_id": id}).Decode(&o); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrOrganizationNotFound
+		}
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (r *OrganizationRepository) GetByIDs(ctx context.Context, ids []domain.ID) ([]domain.Organization, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	cur, err := r.Coll.Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var orgs []domain.Organization
+	if err := cur.All(ctx, &orgs); err != nil {
+		return nil, err
+	}
+	return orgs, nil
+}
+
+// MigrateTenants moves the documents written before organizations existed
+// into the personal organization of the user they belong to, like SQL
+// migration 14 does, and turns global admins into admins of their
+// organizations. It only touches documents not migrated yet, so it is
+// safe to call on every startup. db must hold the collections
+// under the names the server uses.
+func MigrateTenants(ctx context.Context, db *mongo.Database) error {
+	noOrg := bson.M{"org_ids": bson.M{"$exists": false}}
+	if err := aggregate(ctx, db.Collection("users"), mongo.Pipeline{
+		{{Key: "$match", Value: noOrg}},
+		{{Key: "$project", Value: bson.M{"owner_id": "$_id", "name": 1, "created_at": 1}}},
+		{{Key: "$merge", Value: bson.M{"into": "organizations", "whenMatched": "keepExisting"}}},
+	}); err != nil {
+		return err
+	}
+	if _, err := db.Collection("users").UpdateMany(ctx, noOrg,
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{"org_ids": bson.A{"$_id"}}}}}); err != nil {
+		return err
+	}
+	// like SQL migration 15, admins keep their role in the organizations
+	// they belong to, and the global role goes away
+	if _, err := db.Collection("users").UpdateMany(ctx, bson.M{"role": "admin"},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{"admin_org_ids": "$org_ids"}}}}); err != nil {
+		return err
+	}
+	if _, err := db.Collection("users").UpdateMany(ctx, bson.M{"role": bson.M{"$exists": true}},
+		bson.M{"$unset": bson.M{"role": ""}}); err != nil {
+		return err
+	}
+
+	// tasks and projects belong to the organization of their owner, the
+	// records of a task to the one of the task
+	for coll, owner := range map[string]string{"tasks": "owner_id", "projects": "owner_id", "refresh_tokens": "user_id"} {
+		if _, err := db.Collection(coll).UpdateMany(ctx, bson.M{"org_id": bson.M{"$exists": false}},
+			mongo.Pipeline{{{Key: "$set", Value: bson.M{"org_id": "$" + owner}}}}); err != nil {
+			return err
+		}
+	}
+	for coll, fallback := range map[string]string{"comments": "author_id", "task_history": "actor_id", "notifications": "user_id"} {
+		if err := aggregate(ctx, db.Collection(coll), mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"org_id": bson.M{"$exists": false}}}},
+			{{Key: "$lookup", Value: bson.M{"from": "tasks", "localField": "task_id", "foreignField": "_id", "as": "task"}}},
+			{{Key: "$project", Value: bson.M{"org_id": bson.M{"$ifNull": bson.A{
+				bson.M{"$arrayElemAt": bson.A{"$task.owner_id", 0}}, "$" + fallback,
+			}}}}},
+			{{Key: "$merge", Value: bson.M{"into": coll, "whenMatched": "merge", "whenNotMatched": "discard"}}},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// aggregate runs a pipeline for its side effects, such as a $merge stage.
+func aggregate(ctx context.Context, coll *mongo.Collection, pipeline mongo.Pipeline) error {
+	cur, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	return cur.Close(ctx)
+}
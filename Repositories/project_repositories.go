@@ -0,0 +1,146 @@
+package Repositories
+
+import (
+	"context"
+	"errors"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// compile‑time check for the Mongo project store
+var _ domain.ProjectRepository = (*ProjectRepository)(nil)
+
+type ProjectRepository struct {
+	Coll *mongo.Collection
+}
+
+func NewProjectRepository(c *mongo.Collection) *ProjectRepository {
+	return &ProjectRepository{Coll: c}
+}
+
+// EnsureIndexes creates the indexes behind an organization's and a
+// user's project listing.
+func (r *ProjectRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.Coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "org_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "members.user_id", Value: 1}}},
+	})
+	return err
+}
+
+func (r *ProjectRepository) Create(ctx context.Context, p domain.Project) (*domain.Project, error) {
+	p.ID = domain.NewID()
+	org, err := tenantOf(ctx, p.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	p.OrgID = org
+	if _, err := r.Coll.InsertOne(ctx, p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *ProjectRepository) GetByID(ctx context.Context, id domain.ID) (*domain.Project, error) {
+	var p domain.Project
+	if err := r.Coll.FindOne(ctx, tenantFilter(ctx, bson.M{"_id": id})).Decode(&p); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrProjectNotFound
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *ProjectRepository) Update(ctx context.Context, p domain.Project) (*domain.Project, error) {
+	set := bson.M{"name": p.Name, "description": p.Description, "updated_at": p.UpdatedAt}
+	unset := bson.M{}
+	if len(p.Members) > 0 {
+		set["members"] = p.Members
+	} else {
+		unset["members"] = ""
+	}
+	if p.ArchivedAt != nil {
+		set["archived_at"] = *p.ArchivedAt
+	} else {
+		unset["archived_at"] = ""
+	}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	res, err := r.Coll.UpdateOne(ctx, tenantFilter(ctx, bson.M{"_id": p.ID}), update)
+	if err != nil {
+		return nil, err
+	}
+	if res.MatchedCount == 0 {
+		return nil, domain.ErrProjectNotFound
+	}
+	return r.GetByID(ctx, p.ID)
+}
+
+func (r *ProjectRepository) Delete(ctx context.Context, id domain.ID) error {
+	res, err := r.Coll.DeleteOne(ctx, tenantFilter(ctx, bson.M{"_id": id}))
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return domain.ErrProjectNotFound
+	}
+	return nil
+}
+
+func (r *ProjectRepository) Find(ctx context.Context, q domain.ProjectQuery) (*domain.ProjectPage, error) {
+	and := []bson.M{}
+	if q.MemberID != nil {
+		and = append(and, bson.M{"$or": []bson.M{
+			{"owner_id": *q.MemberID},
+			{"members.user_id": *q.MemberID},
+		}})
+	}
+	if q.Archived != nil {
+		if *q.Archived {
+			and = append(and, bson.M{"archived_at": bson.M{"$ne": nil}})
+		} else {
+			and = append(and, bson.M{"archived_at": nil})
+		}
+	}
+	if q.Cursor != "" {
+		at, id, err := domain.DecodeProjectCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, bson.M{"$or": []bson.M{
+			{"created_at": bson.M{"$gt": at}},
+			{"created_at": at, "_id": bson.M{"$gt": id}},
+		}})
+	}
+	filter := tenantFilter(ctx, bson.M{})
+	if len(and) > 0 {
+		filter["$and"] = and
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	if q.Limit > 0 {
+		// fetch one extra document to learn whether another page exists
+		opts.SetLimit(int64(q.Limit) + 1)
+	}
+
+	cur, err := r.Coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var projects []domain.Project
+	if err := cur.All(ctx, &projects); err != nil {
+		return nil, err
+	}
+	page := &domain.ProjectPage{Projects: projects}
+	if q.Limit > 0 && len(projects) > q.Limit {
+		page.Projects = projects[:q.Limit]
+		page.NextCursor = domain.EncodeProjectCursor(page.Projects[q.Limit-1])
+	}
+	return page, nil
+}
@@ -0,0 +1,271 @@
+package Repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+// compile‑time checks for the SQL comment and notification stores
+var (
+	_ domain.CommentRepository      = (*SQLCommentRepository)(nil)
+	_ domain.NotificationRepository = (*SQLNotificationRepository)(nil)
+)
+
+// SQLCommentRepository stores comments with their earlier bodies encoded
+// as a JSON array.
+type SQLCommentRepository struct {
+	db      *sql.DB
+	dialect SQLDialect
+}
+
+func NewSQLCommentRepository(db *sql.DB, d SQLDialect) *SQLCommentRepository {
+	return &SQLCommentRepository{db: db, dialect: d}
+}
+
+const commentColumns = "id, task_id, author_id, body, created_at, updated_at, edits, org_id"
+
+func (r *SQLCommentRepository) Create(ctx context.Context, c domain.Comment) (*domain.Comment, error) {
+	c.ID = domain.NewID()
+	org, err := tenantOf(ctx, c.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	c.OrgID = org
+	edits, err := encodeEdits(c.Edits)
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.db.ExecContext(ctx, r.dialect.rebind(
+		`INSERT INTO comments (`+commentColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`),
+		string(c.ID), string(c.TaskID), string(c.AuthorID), c.Body, c.CreatedAt.UTC(), c.UpdatedAt.UTC(), edits,
+		string(c.OrgID),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *SQLCommentRepository) GetByID(ctx context.Context, id domain.ID) (*domain.Comment, error) {
+	cond, args := tenantWhere(ctx, "id = ?", string(id))
+	c, err := scanComment(r.db.QueryRowContext(ctx, r.dialect.rebind(
+		`SELECT `+commentColumns+` FROM comments WHERE `+cond), args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrCommentNotFound
+	}
+	return c, err
+}
+
+func (r *SQLCommentRepository) Update(ctx context.Context, c domain.Comment) (*domain.Comment, error) {
+	edits, err := encodeEdits(c.Edits)
+	if err != nil {
+		return nil, err
+	}
+	cond, args := tenantWhere(ctx, "id = ?", c.Body, c.UpdatedAt.UTC(), edits, string(c.ID))
+	res, err := r.db.ExecContext(ctx, r.dialect.rebind(
+		`UPDATE comments SET body = ?, updated_at = ?, edits = ? WHERE `+cond), args...)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, domain.ErrCommentNotFound
+	}
+	return r.GetByID(ctx, c.ID)
+}
+
+func (r *SQLCommentRepository) Delete(ctx context.Context, id domain.ID) error {
+	cond, args := tenantWhere(ctx, "id = ?", string(id))
+	res, err := r.db.ExecContext(ctx, r.dialect.rebind(`DELETE FROM comments WHERE `+cond), args...)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrCommentNotFound
+	}
+	return nil
+}
+
+func (r *SQLCommentRepository) Find(ctx context.Context, q domain.CommentQuery) (*domain.CommentPage, error) {
+	cond, args := tenantWhere(ctx, "task_id = ?", string(q.TaskID))
+	query := `SELECT ` + commentColumns + ` FROM comments WHERE ` + cond
+	if q.Cursor != "" {
+		at, id, err := domain.DecodeCommentCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query += " AND (created_at > ? OR (created_at = ? AND id > ?))"
+		args = append(args, at.UTC(), at.UTC(), string(id))
+	}
+	query += " ORDER BY created_at, id"
+	if q.Limit > 0 {
+		// fetch one extra row to learn whether another page exists
+		query += " LIMIT ?"
+		args = append(args, q.Limit+1)
+	}
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var comments []domain.Comment
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, *c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	page := &domain.CommentPage{Comments: comments}
+	if q.Limit > 0 && len(comments) > q.Limit {
+		page.Comments = comments[:q.Limit]
+		page.NextCursor = domain.EncodeCommentCursor(page.Comments[q.Limit-1])
+	}
+	return page, nil
+}
+
+func scanComment(row rowScanner) (*domain.Comment, error) {
+	var c domain.Comment
+	var id, task, author, edits, org string
+	if err := row.Scan(&id, &task, &author, &c.Body, &c.CreatedAt, &c.UpdatedAt, &edits, &org); err != nil {
+		return nil, err
+	}
+	c.ID, c.TaskID, c.AuthorID, c.OrgID = domain.ID(id), domain.ID(task), domain.ID(author), domain.ID(org)
+	if err := json.Unmarshal([]byte(edits), &c.Edits); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func encodeEdits(edits []domain.CommentEdit) (string, error) {
+	if edits == nil {
+		edits = []domain.CommentEdit{}
+	}
+	raw, err := json.Marshal(edits)
+	return string(raw), err
+}
+
+type SQLNotificationRepository struct {
+	db      *sql.DB
+	dialect SQLDialect
+}
+
+func NewSQLNotificationRepository(db *sql.DB, d SQLDialect) *SQLNotificationRepository {
+	return &SQLNotificationRepository{db: db, dialect: d}
+}
+
+const notificationColumns = "id, user_id, kind, task_id, actor_id, comment_id, created_at, read_at, org_id"
+
+func (r *SQLNotificationRepository) Create(ctx context.Context, n domain.Notification) (*domain.Notification, error) {
+	n.ID = domain.NewID()
+	org, err := tenantOf(ctx, n.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	n.OrgID = org
+	_, err = r.db.ExecContext(ctx, r.dialect.rebind(
+		`INSERT INTO notifications (`+notificationColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		string(n.ID), string(n.UserID), string(n.Kind), string(n.TaskID), string(n.ActorID), nullID(n.CommentID),
+		n.CreatedAt.UTC(), nullTime(n.ReadAt), string(n.OrgID),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+func (r *SQLNotificationRepository) Find(ctx context.Context, q domain.NotificationQuery) (*domain.NotificationPage, error) {
+	cond, args := tenantWhere(ctx, "user_id = ?", string(q.UserID))
+	query := `SELECT ` + notificationColumns + ` FROM notifications WHERE ` + cond
+	if q.Unread {
+		query += " AND read_at IS NULL"
+	}
+	if q.Cursor != "" {
+		at, id, err := domain.DecodeNotificationCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query += " AND (created_at < ? OR (created_at = ? AND id < ?))"
+		args = append(args, at.UTC(), at.UTC(), string(id))
+	}
+	query += " ORDER BY created_at DESC, id DESC"
+	if q.Limit > 0 {
+		// fetch one extra row to learn whether another page exists
+		query += " LIMIT ?"
+		args = append(args, q.Limit+1)
+	}
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var notifications []domain.Notification
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, *n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	page := &domain.NotificationPage{Notifications: notifications}
+	if q.Limit > 0 && len(notifications) > q.Limit {
+		page.Notifications = notifications[:q.Limit]
+		page.NextCursor = domain.EncodeNotificationCursor(page.Notifications[q.Limit-1])
+	}
+	return page, nil
+}
+
+func (r *SQLNotificationRepository) MarkRead(ctx context.Context, user, id domain.ID, at time.Time) (*domain.Notification, error) {
+	cond, args := tenantWhere(ctx, "id = ? AND user_id = ? AND read_at IS NULL", at.UTC(), string(id), string(user))
+	if _, err := r.db.ExecContext(ctx, r.dialect.rebind(
+		`UPDATE notifications SET read_at = ? WHERE `+cond), args...); err != nil {
+		return nil, err
+	}
+	cond, args = tenantWhere(ctx, "id = ? AND user_id = ?", string(id), string(user))
+	n, err := scanNotification(r.db.QueryRowContext(ctx, r.dialect.rebind(
+		`SELECT `+notificationColumns+` FROM notifications WHERE `+cond), args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrNotificationNotFound
+	}
+	return n, err
+}
+
+func scanNotification(row rowScanner) (*domain.Notification, error) {
+	var n domain.Notification
+	var id, user, kind, task, actor, org string
+	var comment sql.NullString
+	var read sql.NullTime
+	if err := row.Scan(&id, &user, &kind, &task, &actor, &comment, &n.CreatedAt, &read, &org); err != nil {
+		return nil, err
+	}
+	n.OrgID = domain.ID(org)
+	n.ID, n.UserID, n.Kind, n.TaskID, n.ActorID = domain.ID(id), domain.ID(user), domain.NotificationKind(kind), domain.ID(task), domain.ID(actor)
+	if comment.Valid {
+		commentID := domain.ID(comment.String)
+		n.CommentID = &commentID
+	}
+	if read.Valid {
+		n.ReadAt = &read.Time
+	}
+	return &n, nil
+}
@@ -0,0 +1,120 @@
+package Repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+// compile‑time check that SQLHistoryRepository implements domain.HistoryRepository
+var _ domain.HistoryRepository = (*SQLHistoryRepository)(nil)
+
+// SQLHistoryRepository stores history entries with their changes encoded
+// as a JSON array.
+type SQLHistoryRepository struct {
+	db      *sql.DB
+	dialect SQLDialect
+}
+
+func NewSQLHistoryRepository(db *sql.DB, d SQLDialect) *SQLHistoryRepository {
+	return &SQLHistoryRepository{db: db, dialect: d}
+}
+
+func (r *SQLHistoryRepository) Append(ctx context.Context, entry domain.HistoryEntry) (*domain.HistoryEntry, error) {
+	entry.ID = domain.NewID()
+	org, err := tenantOf(ctx, entry.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	entry.OrgID = org
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.db.ExecContext(ctx, r.dialect.rebind(
+		`INSERT INTO task_history (id, task_id, actor_id, action, at, changes, org_id) VALUES (?, ?, ?, ?, ?, ?, ?)`),
+		string(entry.ID), string(entry.TaskID), string(entry.ActorID), string(entry.Action), entry.At.UTC(), string(changes),
+		string(entry.OrgID),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *SQLHistoryRepository) Find(ctx context.Context, q domain.HistoryQuery) (*domain.HistoryPage, error) {
+	var where []string
+	var args []interface{}
+	add := func(cond string, a ...interface{}) {
+		where = append(where, cond)
+		args = append(args, a...)
+	}
+	if tenant, ok := domain.TenantFrom(ctx); ok {
+		add("org_id = ?", string(tenant))
+	} else if !domain.AllTenants(ctx) {
+		add("1 = 0")
+	}
+	if q.TaskID != nil {
+		add("task_id = ?", string(*q.TaskID))
+	}
+	if q.ActorID != nil {
+		add("actor_id = ?", string(*q.ActorID))
+	}
+	if q.From != nil {
+		add("at >= ?", q.From.UTC())
+	}
+	if q.To != nil {
+		add("at <= ?", q.To.UTC())
+	}
+	if q.Cursor != "" {
+		at, id, err := domain.DecodeHistoryCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		add("(at > ? OR (at = ? AND id > ?))", at.UTC(), at.UTC(), string(id))
+	}
+
+	query := "SELECT id, task_id, actor_id, action, at, changes, org_id FROM task_history"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY at, id"
+	if q.Limit > 0 {
+		// fetch one extra row to learn whether another page exists
+		query += " LIMIT ?"
+		args = append(args, q.Limit+1)
+	}
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var entries []domain.HistoryEntry
+	for rows.Next() {
+		var e domain.HistoryEntry
+		var id, task, actor, action, changes, org string
+		if err := rows.Scan(&id, &task, &actor, &action, &e.At, &changes, &org); err != nil {
+			return nil, err
+		}
+		e.OrgID = domain.ID(org)
+		e.ID, e.TaskID, e.ActorID, e.Action = domain.ID(id), domain.ID(task), domain.ID(actor), domain.HistoryAction(action)
+		if err := json.Unmarshal([]byte(changes), &e.Changes); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	page := &domain.HistoryPage{Entries: entries}
+	if q.Limit > 0 && len(entries) > q.Limit {
+		page.Entries = entries[:q.Limit]
+		page.NextCursor = domain.EncodeHistoryCursor(page.Entries[q.Limit-1])
+	}
+	return page, nil
+}
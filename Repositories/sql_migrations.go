@@ -0,0 +1,341 @@
+package Repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SQLDialect selects the small syntax differences between the supported
+// SQL databases. Queries are written with "?" placeholders and rebound.
+type SQLDialect int
+
+const (
+	DialectSQLite SQLDialect = iota
+	DialectPostgres
+)
+
+// ParseSQLDialect maps a storage name onto a dialect.
+func ParseSQLDialect(name string) (SQLDialect, error) {
+	switch name {
+	case "sqlite":
+		return DialectSQLite, nil
+	case "postgres":
+		return DialectPostgres, nil
+	}
+	return 0, fmt.Errorf("unknown SQL dialect %q", name)
+}
+
+// rebind rewrites "?" placeholders into the dialect's native form.
+func (d SQLDialect) rebind(query string) string {
+	if d != DialectPostgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// ddl adapts a schema statement written for Postgres. SQLite's drivers
+// only recognise TIMESTAMP columns as times.
+func (d SQLDialect) ddl(stmt string) string {
+	if d == DialectSQLite {
+		return strings.ReplaceAll(stmt, "TIMESTAMPTZ", "TIMESTAMP")
+	}
+	return stmt
+}
+
+type migration struct {
+	version int
+	name    string
+	stmts   []string
+}
+
+// migrations are applied in order and never edited once released;
+// schema changes are made by appending a new version.
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "create users and tasks",
+		stmts: []string{
+			`CREATE TABLE users (
+				id         VARCHAR(64) PRIMARY KEY,
+				name       TEXT NOT NULL,
+				email      TEXT NOT NULL,
+				password   TEXT NOT NULL,
+				role       TEXT NOT NULL,
+				created_at TIMESTAMPTZ NOT NULL
+			)`,
+			`CREATE UNIQUE INDEX users_email_key ON users (email)`,
+			`CREATE TABLE tasks (
+				id           VARCHAR(64) PRIMARY KEY,
+				owner_id     VARCHAR(64) NOT NULL,
+				title        TEXT NOT NULL,
+				description  TEXT NOT NULL,
+				due_date     TIMESTAMPTZ NOT NULL,
+				status       TEXT NOT NULL,
+				created_at   TIMESTAMPTZ NOT NULL,
+				updated_at   TIMESTAMPTZ NOT NULL,
+				completed_at TIMESTAMPTZ
+			)`,
+			`CREATE INDEX tasks_owner_created_idx ON tasks (owner_id, created_at, id)`,
+			`CREATE INDEX tasks_owner_due_idx ON tasks (owner_id, due_date, id)`,
+			`CREATE INDEX tasks_status_idx ON tasks (status)`,
+		},
+	},
+	{
+		version: 2,
+		name:    "create refresh tokens and access token denylist",
+		stmts: []string{
+			`CREATE TABLE refresh_tokens (
+				id          VARCHAR(64) PRIMARY KEY,
+				family_id   VARCHAR(64) NOT NULL,
+				user_id     VARCHAR(64) NOT NULL,
+				token_hash  VARCHAR(128) NOT NULL,
+				expires_at  TIMESTAMPTZ NOT NULL,
+				created_at  TIMESTAMPTZ NOT NULL,
+				revoked_at  TIMESTAMPTZ,
+				replaced_by VARCHAR(64) NOT NULL DEFAULT ''
+			)`,
+			`CREATE UNIQUE INDEX refresh_tokens_hash_key ON refresh_tokens (token_hash)`,
+			`CREATE INDEX refresh_tokens_family_idx ON refresh_tokens (family_id)`,
+			`CREATE TABLE revoked_access_tokens (
+				token_id   VARCHAR(64) PRIMARY KEY,
+				expires_at TIMESTAMPTZ NOT NULL
+			)`,
+		},
+	},
+	{
+		version: 3,
+		name:    "add task versions",
+		stmts: []string{
+			`ALTER TABLE tasks ADD COLUMN version BIGINT NOT NULL DEFAULT 1`,
+		},
+	},
+	{
+		version: 4,
+		name:    "create task history",
+		stmts: []string{
+			`CREATE TABLE task_history (
+				id       VARCHAR(64) PRIMARY KEY,
+				task_id  VARCHAR(64) NOT NULL,
+				actor_id VARCHAR(64) NOT NULL,
+				action   TEXT NOT NULL,
+				at       TIMESTAMPTZ NOT NULL,
+				changes  TEXT NOT NULL
+			)`,
+			`CREATE INDEX task_history_task_idx ON task_history (task_id, at, id)`,
+			`CREATE INDEX task_history_actor_idx ON task_history (actor_id, at, id)`,
+			`CREATE INDEX task_history_at_idx ON task_history (at, id)`,
+		},
+	},
+	{
+		version: 5,
+		name:    "add task trash",
+		stmts: []string{
+			`ALTER TABLE tasks ADD COLUMN deleted_at TIMESTAMPTZ`,
+			`CREATE INDEX tasks_deleted_idx ON tasks (deleted_at)`,
+		},
+	},
+	{
+		version: 6,
+		name:    "add subtasks",
+		stmts: []string{
+			`ALTER TABLE tasks ADD COLUMN parent_id VARCHAR(64)`,
+			`CREATE INDEX tasks_parent_idx ON tasks (parent_id, created_at, id)`,
+		},
+	},
+	{
+		version: 7,
+		name:    "add task dependencies",
+		stmts: []string{
+			`ALTER TABLE tasks ADD COLUMN depends_on TEXT NOT NULL DEFAULT '[]'`,
+		},
+	},
+	{
+		version: 8,
+		name:    "add task recurrence",
+		stmts: []string{
+			`ALTER TABLE tasks ADD COLUMN recurrence TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE tasks ADD COLUMN occurrence INTEGER NOT NULL DEFAULT 0`,
+		},
+	},
+	{
+		version: 9,
+		name:    "add task tags",
+		stmts: []string{
+			`ALTER TABLE tasks ADD COLUMN tags TEXT NOT NULL DEFAULT '[]'`,
+		},
+	},
+	{
+		version: 10,
+		name:    "add task priority",
+		stmts: []string{
+			`ALTER TABLE tasks ADD COLUMN priority VARCHAR(16) NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		version: 11,
+		name:    "create comments and notifications",
+		stmts: []string{
+			`CREATE TABLE comments (
+				id         VARCHAR(64) PRIMARY KEY,
+				task_id    VARCHAR(64) NOT NULL,
+				author_id  VARCHAR(64) NOT NULL,
+				body       TEXT NOT NULL,
+				created_at TIMESTAMPTZ NOT NULL,
+				updated_at TIMESTAMPTZ NOT NULL,
+				edits      TEXT NOT NULL DEFAULT '[]'
+			)`,
+			`CREATE INDEX comments_task_idx ON comments (task_id, created_at, id)`,
+			`CREATE TABLE notifications (
+				id         VARCHAR(64) PRIMARY KEY,
+				user_id    VARCHAR(64) NOT NULL,
+				kind       TEXT NOT NULL,
+				task_id    VARCHAR(64) NOT NULL,
+				actor_id   VARCHAR(64) NOT NULL,
+				comment_id VARCHAR(64),
+				created_at TIMESTAMPTZ NOT NULL,
+				read_at    TIMESTAMPTZ
+			)`,
+			`CREATE INDEX notifications_user_idx ON notifications (user_id, created_at, id)`,
+		},
+	},
+	{
+		version: 12,
+		name:    "add task assignees and watchers",
+		stmts: []string{
+			`ALTER TABLE tasks ADD COLUMN assignees TEXT NOT NULL DEFAULT '[]'`,
+			`ALTER TABLE tasks ADD COLUMN watchers TEXT NOT NULL DEFAULT '[]'`,
+		},
+	},
+	{
+		version: 13,
+		name:    "create projects",
+		stmts: []string{
+			`CREATE TABLE projects (
+				id          VARCHAR(64) PRIMARY KEY,
+				owner_id    VARCHAR(64) NOT NULL,
+				name        TEXT NOT NULL,
+				description TEXT NOT NULL,
+				members     TEXT NOT NULL DEFAULT '[]',
+				archived_at TIMESTAMPTZ,
+				created_at  TIMESTAMPTZ NOT NULL,
+				updated_at  TIMESTAMPTZ NOT NULL
+			)`,
+			`CREATE INDEX projects_created_idx ON projects (created_at, id)`,
+			`ALTER TABLE tasks ADD COLUMN project_id VARCHAR(64)`,
+			`CREATE INDEX tasks_project_idx ON tasks (project_id, created_at, id)`,
+		},
+	},
+	{
+		// every existing user gets a personal organization sharing their
+		// ID, which takes over the data they owned or received
+		version: 14,
+		name:    "create organizations",
+		stmts: []string{
+			`CREATE TABLE organizations (
+				id         VARCHAR(64) PRIMARY KEY,
+				owner_id   VARCHAR(64) NOT NULL,
+				name       TEXT NOT NULL,
+				created_at TIMESTAMPTZ NOT NULL
+			)`,
+			`INSERT INTO organizations (id, owner_id, name, created_at) SELECT id, id, name, created_at FROM users`,
+			`ALTER TABLE users ADD COLUMN org_ids TEXT NOT NULL DEFAULT '[]'`,
+			`UPDATE users SET org_ids = '["' || id || '"]'`,
+			`ALTER TABLE tasks ADD COLUMN org_id VARCHAR(64) NOT NULL DEFAULT ''`,
+			`UPDATE tasks SET org_id = owner_id`,
+			`CREATE INDEX tasks_org_idx ON tasks (org_id, created_at, id)`,
+			`ALTER TABLE projects ADD COLUMN org_id VARCHAR(64) NOT NULL DEFAULT ''`,
+			`UPDATE projects SET org_id = owner_id`,
+			`CREATE INDEX projects_org_idx ON projects (org_id, created_at, id)`,
+			`ALTER TABLE comments ADD COLUMN org_id VARCHAR(64) NOT NULL DEFAULT ''`,
+			`UPDATE comments SET org_id = COALESCE((SELECT owner_id FROM tasks WHERE tasks.id = comments.task_id), author_id)`,
+			`ALTER TABLE task_history ADD COLUMN org_id VARCHAR(64) NOT NULL DEFAULT ''`,
+			`UPDATE task_history SET org_id = COALESCE((SELECT owner_id FROM tasks WHERE tasks.id = task_history.task_id), actor_id)`,
+			`CREATE INDEX task_history_org_idx ON task_history (org_id, at, id)`,
+			`ALTER TABLE notifications ADD COLUMN org_id VARCHAR(64) NOT NULL DEFAULT ''`,
+			`UPDATE notifications SET org_id = COALESCE((SELECT owner_id FROM tasks WHERE tasks.id = notifications.task_id), user_id)`,
+			`ALTER TABLE refresh_tokens ADD COLUMN org_id VARCHAR(64) NOT NULL DEFAULT ''`,
+			`UPDATE refresh_tokens SET org_id = user_id`,
+		},
+	},
+	{
+		// the admin role becomes one per organization; existing admins
+		// keep it in the organizations they belong to
+		version: 15,
+		name:    "make roles per organization",
+		stmts: []string{
+			`ALTER TABLE users ADD COLUMN admin_org_ids TEXT NOT NULL DEFAULT '[]'`,
+			`UPDATE users SET admin_org_ids = org_ids WHERE role = 'admin'`,
+			`ALTER TABLE users DROP COLUMN role`,
+		},
+	},
+}
+
+// Migrate brings the schema up to the latest version. Each migration runs
+// in its own transaction together with its schema_migrations record.
+func Migrate(ctx context.Context, db *sql.DB, d SQLDialect) error {
+	if _, err := db.ExecContext(ctx, d.ddl(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL
+	)`)); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	var current int
+	if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := applyMigration(ctx, db, d, m); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+	}
+	return nil
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, d SQLDialect, m migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range m.stmts {
+		if _, err := tx.ExecContext(ctx, d.ddl(stmt)); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx,
+		d.rebind(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`),
+		m.version, m.name, time.Now().UTC(),
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// isUniqueViolation recognises unique constraint errors from both the
+// SQLite and Postgres drivers without importing either.
+func isUniqueViolation(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "UNIQUE constraint failed") || // SQLite
+		strings.Contains(msg, "SQLSTATE 23505") // Postgres
+}
@@ -0,0 +1,89 @@
+package Repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+// compile‑time check that SQLOrganizationRepository implements domain.OrganizationRepository
+var _ domain.OrganizationRepository = (*SQLOrganizationRepository)(nil)
+
+const organizationColumns = "id, owner_id, name, created_at"
+
+type SQLOrganizationRepository struct {
+	db      *sql.DB
+	dialect SQLDialect
+}
+
+func NewSQLOrganizationRepository(db *sql.DB, d SQLDialect) *SQLOrganizationRepository {
+	return &SQLOrganizationRepository{db: db, dialect: d}
+}
+
+func (r *SQLOrganizationRepository) Create(ctx context.Context, o domain.Organization) (*domain.Organization, error) {
+	if o.ID.IsZero() {
+		o.ID = domain.NewID()
+	}
+	o.CreatedAt = time.Now().UTC()
+	_, err := r.db.ExecContext(ctx, r.dialect.rebind(
+		`INSERT INTO organizations (`+organizationColumns+`) VALUES (?, ?, ?, ?)`),
+		string(o.ID), string(o.OwnerID), o.Name, o.CreatedAt,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, domain.ErrOrganizationExists
+		}
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (r *SQLOrganizationRepository) GetByID(ctx context.Context, id domain.ID) (*domain.Organization, error) {
+	o, err := scanOrganization(r.db.QueryRowContext(ctx, r.dialect.rebind(
+		`SELECT `+organizationColumns+` FROM organizations WHERE id = ?`), string(id)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrOrganizationNotFound
+	}
+	return o, err
+}
+
+func (r *SQLOrganizationRepository) GetByIDs(ctx context.Context, ids []domain.ID) ([]domain.Organization, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	marks := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		marks[i] = "?"
+		args[i] = string(id)
+	}
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(
+		`SELECT `+organizationColumns+` FROM organizations WHERE id IN (`+strings.Join(marks, ", ")+`) ORDER BY id`), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var orgs []domain.Organization
+	for rows.Next() {
+		o, err := scanOrganization(rows)
+		if err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, *o)
+	}
+	return orgs, rows.Err()
+}
+
+func scanOrganization(row rowScanner) (*domain.Organization, error) {
+	var o domain.Organization
+	var id, owner string
+	if err := row.Scan(&id, &owner, &o.Name, &o.CreatedAt); err != nil {
+		return nil, err
+	}
+	o.ID, o.OwnerID = domain.ID(id), domain.ID(owner)
+	return &o, nil
+}
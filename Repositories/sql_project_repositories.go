@@ -0,0 +1,179 @@
+package Repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+// compile‑time check for the SQL project store
+var _ domain.ProjectRepository = (*SQLProjectRepository)(nil)
+
+// SQLProjectRepository stores projects with their members encoded as a
+// JSON array.
+type SQLProjectRepository struct {
+	db      *sql.DB
+	dialect SQLDialect
+}
+
+func NewSQLProjectRepository(db *sql.DB, d SQLDialect) *SQLProjectRepository {
+	return &SQLProjectRepository{db: db, dialect: d}
+}
+
+const projectColumns = "id, owner_id, name, description, members, archived_at, created_at, updated_at, org_id"
+
+func (r *SQLProjectRepository) Create(ctx context.Context, p domain.Project) (*domain.Project, error) {
+	p.ID = domain.NewID()
+	org, err := tenantOf(ctx, p.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	p.OrgID = org
+	members, err := encodeMembers(p.Members)
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.db.ExecContext(ctx, r.dialect.rebind(
+		`INSERT INTO projects (`+projectColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		string(p.ID), string(p.OwnerID), p.Name, p.Description, members, nullTime(p.ArchivedAt),
+		p.CreatedAt.UTC(), p.UpdatedAt.UTC(), string(p.OrgID),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *SQLProjectRepository) GetByID(ctx context.Context, id domain.ID) (*domain.Project, error) {
+	cond, args := tenantWhere(ctx, "id = ?", string(id))
+	p, err := scanProject(r.db.QueryRowContext(ctx, r.dialect.rebind(
+		`SELECT `+projectColumns+` FROM projects WHERE `+cond), args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrProjectNotFound
+	}
+	return p, err
+}
+
+func (r *SQLProjectRepository) Update(ctx context.Context, p domain.Project) (*domain.Project, error) {
+	members, err := encodeMembers(p.Members)
+	if err != nil {
+		return nil, err
+	}
+	cond, args := tenantWhere(ctx, "id = ?",
+		p.Name, p.Description, members, nullTime(p.ArchivedAt), p.UpdatedAt.UTC(), string(p.ID))
+	res, err := r.db.ExecContext(ctx, r.dialect.rebind(
+		`UPDATE projects SET name = ?, description = ?, members = ?, archived_at = ?, updated_at = ? WHERE `+cond), args...)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, domain.ErrProjectNotFound
+	}
+	return r.GetByID(ctx, p.ID)
+}
+
+func (r *SQLProjectRepository) Delete(ctx context.Context, id domain.ID) error {
+	cond, args := tenantWhere(ctx, "id = ?", string(id))
+	res, err := r.db.ExecContext(ctx, r.dialect.rebind(`DELETE FROM projects WHERE `+cond), args...)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrProjectNotFound
+	}
+	return nil
+}
+
+func (r *SQLProjectRepository) Find(ctx context.Context, q domain.ProjectQuery) (*domain.ProjectPage, error) {
+	cond, args := tenantWhere(ctx, "1 = 1")
+	where := []string{cond}
+	if q.MemberID != nil {
+		// members is a JSON array of objects, so the quoted member ID can
+		// only match a whole user_id
+		where = append(where, "(owner_id = ? OR members LIKE ?)")
+		args = append(args, string(*q.MemberID), `%"user_id":"`+string(*q.MemberID)+`"%`)
+	}
+	if q.Archived != nil {
+		if *q.Archived {
+			where = append(where, "archived_at IS NOT NULL")
+		} else {
+			where = append(where, "archived_at IS NULL")
+		}
+	}
+	if q.Cursor != "" {
+		at, id, err := domain.DecodeProjectCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		where = append(where, "(created_at > ? OR (created_at = ? AND id > ?))")
+		args = append(args, at.UTC(), at.UTC(), string(id))
+	}
+	query := `SELECT ` + projectColumns + ` FROM projects WHERE ` + strings.Join(where, " AND ") + ` ORDER BY created_at, id`
+	if q.Limit > 0 {
+		// fetch one extra row to learn whether another page exists
+		query += " LIMIT ?"
+		args = append(args, q.Limit+1)
+	}
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var projects []domain.Project
+	for rows.Next() {
+		p, err := scanProject(rows)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, *p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	page := &domain.ProjectPage{Projects: projects}
+	if q.Limit > 0 && len(projects) > q.Limit {
+		page.Projects = projects[:q.Limit]
+		page.NextCursor = domain.EncodeProjectCursor(page.Projects[q.Limit-1])
+	}
+	return page, nil
+}
+
+func scanProject(row rowScanner) (*domain.Project, error) {
+	var p domain.Project
+	var id, owner, org, members string
+	var archived sql.NullTime
+	if err := row.Scan(&id, &owner, &p.Name, &p.Description, &members, &archived, &p.CreatedAt, &p.UpdatedAt, &org); err != nil {
+		return nil, err
+	}
+	p.ID, p.OwnerID, p.OrgID = domain.ID(id), domain.ID(owner), domain.ID(org)
+	if err := json.Unmarshal([]byte(members), &p.Members); err != nil {
+		return nil, err
+	}
+	if len(p.Members) == 0 {
+		p.Members = nil
+	}
+	if archived.Valid {
+		p.ArchivedAt = &archived.Time
+	}
+	return &p, nil
+}
+
+func encodeMembers(members []domain.ProjectMember) (string, error) {
+	if members == nil {
+		members = []domain.ProjectMember{}
+	}
+	raw, err := json.Marshal(members)
+	return string(raw), err
+}
@@ -0,0 +1,453 @@
+package Repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+// compile‑time check that SQLTaskRepository implements domain.TaskRepository
+var _ domain.TaskRepository = (*SQLTaskRepository)(nil)
+
+const taskColumns = `id, owner_id, title, description, due_date, status, created_at, updated_at, completed_at, version, deleted_at, parent_id, depends_on, recurrence, occurrence, tags, priority, assignees, watchers, project_id, org_id`
+
+// SQLTaskRepository stores tasks in a SQL database created by Migrate.
+type SQLTaskRepository struct {
+	db      *sql.DB
+	dialect SQLDialect
+}
+
+func NewSQLTaskRepository(db *sql.DB, d SQLDialect) *SQLTaskRepository {
+	return &SQLTaskRepository{db: db, dialect: d}
+}
+
+func (r *SQLTaskRepository) Find(ctx context.Context, q domain.TaskQuery) (*domain.TaskPage, error) {
+	sortBy := q.SortBy
+	if sortBy == "" {
+		sortBy = domain.SortByCreatedAt
+	}
+	deleted := "deleted_at IS NULL"
+	if q.Trashed {
+		deleted = "deleted_at IS NOT NULL"
+	}
+	cond, args := tenantWhere(ctx, deleted)
+	where := []string{cond}
+	add := func(cond string, a ...interface{}) {
+		where = append(where, cond)
+		args = append(args, a...)
+	}
+
+	if q.OwnerID != nil {
+		add("owner_id = ?", string(*q.OwnerID))
+	}
+	if q.ParentID != nil {
+		add("parent_id = ?", string(*q.ParentID))
+	}
+	if q.ProjectID != nil {
+		add("project_id = ?", string(*q.ProjectID))
+	}
+	if q.DependsOn != nil {
+		// depends_on holds a JSON array of hex IDs, so the quoted ID can
+		// only match a whole element
+		add("depends_on LIKE ?", `%"`+string(*q.DependsOn)+`"%`)
+	}
+	if q.AssigneeID != nil {
+		add("assignees LIKE ?", `%"`+string(*q.AssigneeID)+`"%`)
+	}
+	if len(q.Tags) > 0 {
+		// tags is a JSON array too; valid tags need no JSON escaping
+		conds := make([]string, len(q.Tags))
+		for i, tag := range q.Tags {
+			conds[i] = `tags LIKE ? ESCAPE '\'`
+			args = append(args, `%"`+escapeLike(tag)+`"%`)
+		}
+		joiner := " OR "
+		if q.TagMatch == domain.TagMatchAll {
+			joiner = " AND "
+		}
+		where = append(where, "("+strings.Join(conds, joiner)+")")
+	}
+	if len(q.Statuses) > 0 {
+		marks := make([]string, len(q.Statuses))
+		for i, s := range q.Statuses {
+			marks[i] = "?"
+			args = append(args, string(s))
+		}
+		where = append(where, "status IN ("+strings.Join(marks, ", ")+")")
+	}
+	if q.TitleContains != "" {
+		add(`LOWER(title) LIKE ? ESCAPE '\'`, "%"+escapeLike(strings.ToLower(q.TitleContains))+"%")
+	}
+	for _, rng := range []struct {
+		column   string
+		from, to *time.Time
+	}{
+		{"due_date", q.DueFrom, q.DueTo},
+		{"created_at", q.CreatedFrom, q.CreatedTo},
+		{"updated_at", q.UpdatedFrom, q.UpdatedTo},
+	} {
+		if rng.from != nil {
+			add(rng.column+" >= ?", rng.from.UTC())
+		}
+		if rng.to != nil {
+			add(rng.column+" <= ?", rng.to.UTC())
+		}
+	}
+
+	column := string(sortBy)
+	op, dir := ">", "ASC"
+	if q.SortDesc {
+		op, dir = "<", "DESC"
+	}
+	if q.Cursor != "" {
+		c, err := domain.DecodeTaskCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		var value interface{} = c.Value
+		if sortBy != domain.SortByTitle {
+			t, err := c.TimeValue()
+			if err != nil {
+				return nil, err
+			}
+			value = t.UTC()
+		}
+		add("("+column+" "+op+" ? OR ("+column+" = ? AND id "+op+" ?))", value, value, string(c.ID))
+	}
+
+	query := "SELECT " + taskColumns + " FROM tasks WHERE " + strings.Join(where, " AND ")
+	query += " ORDER BY " + column + " " + dir + ", id " + dir
+	if q.Limit > 0 {
+		// fetch one extra row to learn whether another page exists
+		query += " LIMIT ?"
+		args = append(args, q.Limit+1)
+	}
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tasks []domain.Task
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, *t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	page := &domain.TaskPage{Tasks: tasks}
+	if q.Limit > 0 && len(tasks) > q.Limit {
+		page.Tasks = tasks[:q.Limit]
+		page.NextCursor = domain.EncodeTaskCursor(page.Tasks[q.Limit-1], sortBy, q.SortDesc)
+	}
+	return page, nil
+}
+
+func (r *SQLTaskRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+func (r *SQLTaskRepository) GetByID(ctx context.Context, id domain.ID) (*domain.Task, error) {
+	cond, args := tenantWhere(ctx, "id = ?", string(id))
+	row := r.db.QueryRowContext(ctx, r.dialect.rebind("SELECT "+taskColumns+" FROM tasks WHERE "+cond), args...)
+	t, err := scanTask(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrTaskNotFound
+	}
+	return t, err
+}
+
+func (r *SQLTaskRepository) Create(ctx context.Context, task domain.Task) (*domain.Task, error) {
+	task.TaskID = domain.NewID()
+	org, err := tenantOf(ctx, task.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	task.OrgID = org
+	task.CreatedAt = time.Now().UTC()
+	task.UpdatedAt = task.CreatedAt
+	task.Version = 1
+	_, err = r.db.ExecContext(ctx, r.dialect.rebind(
+		"INSERT INTO tasks ("+taskColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"),
+		string(task.TaskID), string(task.OwnerID), task.Title, task.Description, task.DueDate.UTC(),
+		string(task.Status), task.CreatedAt, task.UpdatedAt, nullTime(task.CompletedAt), task.Version, nullTime(nil),
+		nullID(task.ParentID), encodeIDs(task.DependsOn), task.Recurrence, task.Occurrence,
+		encodeTags(task.Tags), string(task.Priority), encodeIDs(task.Assignees), encodeIDs(task.Watchers),
+		nullID(task.ProjectID), string(task.OrgID),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *SQLTaskRepository) Update(ctx context.Context, id domain.ID, task domain.Task) (*domain.Task, error) {
+	n, err := r.exec(ctx,
+		`UPDATE tasks SET title = ?, description = ?, due_date = ?, status = ?, updated_at = ?, completed_at = ?,
+		parent_id = ?, depends_on = ?, recurrence = ?, tags = ?, priority = ?,
+		assignees = ?, watchers = ?, project_id = ?, version = version + 1
+		WHERE id = ? AND version = ?`,
+		task.Title, task.Description, task.DueDate.UTC(), string(task.Status), time.Now().UTC(),
+		nullTime(task.CompletedAt), nullID(task.ParentID), encodeIDs(task.DependsOn), task.Recurrence,
+		encodeTags(task.Tags), string(task.Priority), encodeIDs(task.Assignees), encodeIDs(task.Watchers),
+		nullID(task.ProjectID), string(id), task.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrVersionConflict
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *SQLTaskRepository) Delete(ctx context.Context, id domain.ID, version int64) error {
+	n, err := r.exec(ctx,
+		"UPDATE tasks SET deleted_at = ?, version = version + 1 WHERE id = ? AND deleted_at IS NULL AND version = ?",
+		time.Now().UTC(), string(id), version)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return r.deleteConflict(ctx, id)
+	}
+	return nil
+}
+
+// deleteConflict tells why Delete matched nothing: the task is gone or
+// already trashed, or it is at another version.
+func (r *SQLTaskRepository) deleteConflict(ctx context.Context, id domain.ID) error {
+	t, err := r.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if t.DeletedAt != nil {
+		return domain.ErrTaskNotFound
+	}
+	return domain.ErrVersionConflict
+}
+
+func (r *SQLTaskRepository) Restore(ctx context.Context, id domain.ID) (*domain.Task, error) {
+	n, err := r.exec(ctx,
+		"UPDATE tasks SET deleted_at = NULL, updated_at = ?, version = version + 1 WHERE id = ? AND deleted_at IS NOT NULL",
+		time.Now().UTC(), string(id))
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrTaskNotInTrash
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *SQLTaskRepository) Purge(ctx context.Context, id domain.ID) error {
+	n, err := r.exec(ctx, "DELETE FROM tasks WHERE id = ? AND deleted_at IS NOT NULL", string(id))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return err
+		}
+		return domain.ErrTaskNotInTrash
+	}
+	return nil
+}
+
+func (r *SQLTaskRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) ([]domain.Task, error) {
+	cond, args := tenantWhere(ctx, "deleted_at < ?", cutoff.UTC())
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind("SELECT "+taskColumns+" FROM tasks WHERE "+cond), args...)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []domain.Task
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		candidates = append(candidates, *t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// delete one by one, re-checking the cutoff, so a task restored in the
+	// meantime is neither purged nor reported
+	var purged []domain.Task
+	for _, t := range candidates {
+		n, err := r.exec(ctx, "DELETE FROM tasks WHERE id = ? AND deleted_at < ?", string(t.TaskID), cutoff.UTC())
+		if err != nil {
+			return purged, err
+		}
+		if n == 1 {
+			purged = append(purged, t)
+		}
+	}
+	return purged, nil
+}
+
+// CountTags decodes the tags of the matching tasks and counts them here,
+// since the JSON functions needed to do it in SQL differ between dialects.
+func (r *SQLTaskRepository) CountTags(ctx context.Context, owner *domain.ID) ([]domain.TagCount, error) {
+	query, args := tenantWhere(ctx, `SELECT tags FROM tasks WHERE deleted_at IS NULL AND tags <> '[]'`)
+	if owner != nil {
+		query += " AND owner_id = ?"
+		args = append(args, string(*owner))
+	}
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tasks []domain.Task
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, err
+		}
+		tags, err := decodeTags(raw)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, domain.Task{Tags: tags})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return domain.CountTags(tasks), nil
+}
+
+// exec runs a statement on the tasks of the tenant of ctx, so its WHERE
+// clause must end it, and returns the number of affected rows.
+func (r *SQLTaskRepository) exec(ctx context.Context, query string, args ...interface{}) (int64, error) {
+	query, args = tenantWhere(ctx, query, args...)
+	res, err := r.db.ExecContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTask(row rowScanner) (*domain.Task, error) {
+	var t domain.Task
+	var id, owner, org, status, priority string
+	var completed, deleted sql.NullTime
+	var parent, project sql.NullString
+	var dependsOn, tags, assignees, watchers string
+	if err := row.Scan(&id, &owner, &t.Title, &t.Description, &t.DueDate, &status,
+		&t.CreatedAt, &t.UpdatedAt, &completed, &t.Version, &deleted, &parent, &dependsOn,
+		&t.Recurrence, &t.Occurrence, &tags, &priority, &assignees, &watchers, &project, &org); err != nil {
+		return nil, err
+	}
+	for _, ids := range []struct {
+		raw string
+		dst *[]domain.ID
+	}{{dependsOn, &t.DependsOn}, {assignees, &t.Assignees}, {watchers, &t.Watchers}} {
+		list, err := decodeIDs(ids.raw)
+		if err != nil {
+			return nil, err
+		}
+		*ids.dst = list
+	}
+	tagList, err := decodeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	t.Tags = tagList
+	t.TaskID, t.OwnerID, t.OrgID, t.Status = domain.ID(id), domain.ID(owner), domain.ID(org), domain.TaskStatus(status)
+	t.Priority = domain.Priority(priority)
+	if completed.Valid {
+		t.CompletedAt = &completed.Time
+	}
+	if deleted.Valid {
+		t.DeletedAt = &deleted.Time
+	}
+	if parent.Valid {
+		parentID := domain.ID(parent.String)
+		t.ParentID = &parentID
+	}
+	if project.Valid {
+		projectID := domain.ID(project.String)
+		t.ProjectID = &projectID
+	}
+	return &t, nil
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+// encodeIDs stores a list of IDs as a JSON array.
+func encodeIDs(ids []domain.ID) string {
+	if ids == nil {
+		ids = []domain.ID{}
+	}
+	raw, _ := json.Marshal(ids)
+	return string(raw)
+}
+
+// decodeIDs reads a column written by encodeIDs; an empty list is nil.
+func decodeIDs(raw string) ([]domain.ID, error) {
+	var ids []domain.ID
+	if err := json.Unmarshal([]byte(raw), &ids); err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// encodeTags stores a list of tags as a JSON array.
+func encodeTags(tags []string) string {
+	if tags == nil {
+		tags = []string{}
+	}
+	raw, _ := json.Marshal(tags)
+	return string(raw)
+}
+
+// decodeTags reads a column written by encodeTags; an empty list is nil.
+func decodeTags(raw string) ([]string, error) {
+	var tags []string
+	if err := json.Unmarshal([]byte(raw), &tags); err != nil || len(tags) == 0 {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func nullID(id *domain.ID) sql.NullString {
+	if id == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: string(*id), Valid: true}
+}
+
+// escapeLike escapes LIKE wildcards so user input matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
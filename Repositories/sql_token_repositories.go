@@ -0,0 +1,115 @@
+package Repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+// compile‑time checks for the SQL token stores
+var (
+	_ domain.RefreshTokenRepository = (*SQLRefreshTokenRepository)(nil)
+	_ domain.TokenDenylist          = (*SQLTokenDenylist)(nil)
+)
+
+type SQLRefreshTokenRepository struct {
+	db      *sql.DB
+	dialect SQLDialect
+}
+
+func NewSQLRefreshTokenRepository(db *sql.DB, d SQLDialect) *SQLRefreshTokenRepository {
+	return &SQLRefreshTokenRepository{db: db, dialect: d}
+}
+
+func (r *SQLRefreshTokenRepository) Create(ctx context.Context, token domain.RefreshToken) (*domain.RefreshToken, error) {
+	token.ID = domain.NewID()
+	token.CreatedAt = time.Now().UTC()
+	_, err := r.db.ExecContext(ctx, r.dialect.rebind(
+		`INSERT INTO refresh_tokens (id, family_id, user_id, token_hash, expires_at, created_at, org_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`),
+		string(token.ID), string(token.FamilyID), string(token.UserID), token.TokenHash,
+		token.ExpiresAt.UTC(), token.CreatedAt, string(token.OrgID),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *SQLRefreshTokenRepository) GetByHash(ctx context.Context, hash string) (*domain.RefreshToken, error) {
+	var t domain.RefreshToken
+	var id, family, user, org, replacedBy string
+	var revoked sql.NullTime
+	err := r.db.QueryRowContext(ctx, r.dialect.rebind(
+		`SELECT id, family_id, user_id, org_id, token_hash, expires_at, created_at, revoked_at, replaced_by
+		FROM refresh_tokens WHERE token_hash = ?`), hash,
+	).Scan(&id, &family, &user, &org, &t.TokenHash, &t.ExpiresAt, &t.CreatedAt, &revoked, &replacedBy)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrRefreshTokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	t.ID, t.FamilyID, t.UserID, t.ReplacedBy = domain.ID(id), domain.ID(family), domain.ID(user), domain.ID(replacedBy)
+	t.OrgID = domain.ID(org)
+	if revoked.Valid {
+		t.RevokedAt = &revoked.Time
+	}
+	return &t, nil
+}
+
+func (r *SQLRefreshTokenRepository) MarkRotated(ctx context.Context, id domain.ID, replacedBy domain.ID) (bool, error) {
+	res, err := r.db.ExecContext(ctx, r.dialect.rebind(
+		`UPDATE refresh_tokens SET revoked_at = ?, replaced_by = ? WHERE id = ? AND revoked_at IS NULL`),
+		time.Now().UTC(), string(replacedBy), string(id),
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (r *SQLRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID domain.ID) error {
+	_, err := r.db.ExecContext(ctx, r.dialect.rebind(
+		`UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL`),
+		time.Now().UTC(), string(familyID),
+	)
+	return err
+}
+
+type SQLTokenDenylist struct {
+	db      *sql.DB
+	dialect SQLDialect
+}
+
+func NewSQLTokenDenylist(db *sql.DB, d SQLDialect) *SQLTokenDenylist {
+	return &SQLTokenDenylist{db: db, dialect: d}
+}
+
+// Revoke also deletes expired entries so the table stays small.
+func (d *SQLTokenDenylist) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	now := time.Now().UTC()
+	if _, err := d.db.ExecContext(ctx, d.dialect.rebind(
+		`DELETE FROM revoked_access_tokens WHERE expires_at < ?`), now); err != nil {
+		return err
+	}
+	_, err := d.db.ExecContext(ctx, d.dialect.rebind(
+		`INSERT INTO revoked_access_tokens (token_id, expires_at) VALUES (?, ?)
+		ON CONFLICT (token_id) DO UPDATE SET expires_at = excluded.expires_at`),
+		tokenID, expiresAt.UTC(),
+	)
+	return err
+}
+
+func (d *SQLTokenDenylist) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	var n int
+	err := d.db.QueryRowContext(ctx, d.dialect.rebind(
+		`SELECT COUNT(*) FROM revoked_access_tokens WHERE token_id = ? AND expires_at > ?`),
+		tokenID, time.Now().UTC(),
+	).Scan(&n)
+	return n > 0, err
+}
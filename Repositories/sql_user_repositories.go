@@ -0,0 +1,159 @@
+package Repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+// compile‑time check that SQLUserRepository implements domain.UserRepository
+var _ domain.UserRepository = (*SQLUserRepository)(nil)
+
+const userColumns = `id, name, email, password, created_at, org_ids, admin_org_ids`
+
+// SQLUserRepository stores users in a SQL database created by Migrate.
+type SQLUserRepository struct {
+	db      *sql.DB
+	dialect SQLDialect
+}
+
+func NewSQLUserRepository(db *sql.DB, d SQLDialect) *SQLUserRepository {
+	return &SQLUserRepository{db: db, dialect: d}
+}
+
+func (r *SQLUserRepository) Create(ctx context.Context, user domain.User) (*domain.User, error) {
+	user.UserID = domain.NewID()
+	user.CreatedAt = time.Now().UTC()
+	_, err := r.db.ExecContext(ctx, r.dialect.rebind(
+		"INSERT INTO users ("+userColumns+") VALUES (?, ?, ?, ?, ?, ?, ?)"),
+		string(user.UserID), user.Name, user.Email, user.Password, user.CreatedAt, encodeIDs(user.OrgIDs), encodeIDs(user.AdminOrgIDs),
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, domain.ErrDuplicateEmail
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *SQLUserRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+func (r *SQLUserRepository) GetByID(ctx context.Context, id domain.ID) (*domain.User, error) {
+	return r.getOne(ctx, "id = ?", string(id))
+}
+
+func (r *SQLUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return r.getOne(ctx, "email = ?", email)
+}
+
+func (r *SQLUserRepository) getOne(ctx context.Context, cond string, arg interface{}) (*domain.User, error) {
+	cond, args := userTenantWhere(ctx, cond, arg)
+	row := r.db.QueryRowContext(ctx, r.dialect.rebind("SELECT "+userColumns+" FROM users WHERE "+cond), args...)
+	u, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrUserNotFound
+	}
+	return u, err
+}
+
+func (r *SQLUserRepository) GetAll(ctx context.Context) ([]*domain.User, error) {
+	cond, args := userTenantWhere(ctx, "1 = 1")
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind("SELECT "+userColumns+" FROM users WHERE "+cond+" ORDER BY id"), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*domain.User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+func (r *SQLUserRepository) PromoteUser(ctx context.Context, id, org domain.ID) (*domain.User, error) {
+	return r.changeOrganizations(ctx, id, func(u *domain.User) error {
+		if !u.InOrganization(org) {
+			return domain.ErrUserNotFound
+		}
+		if u.RoleIn(org) != "admin" {
+			u.AdminOrgIDs = append(u.AdminOrgIDs, org)
+		}
+		return nil
+	})
+}
+
+// PromoteUser, AddOrganization and RemoveOrganization rewrite the whole
+// lists; a concurrent change to the same user's organizations may be
+// lost.
+func (r *SQLUserRepository) AddOrganization(ctx context.Context, id, org domain.ID) (*domain.User, error) {
+	return r.changeOrganizations(ctx, id, func(u *domain.User) error {
+		if !u.InOrganization(org) {
+			u.OrgIDs = append(u.OrgIDs, org)
+		}
+		return nil
+	})
+}
+
+func (r *SQLUserRepository) RemoveOrganization(ctx context.Context, id, org domain.ID) (*domain.User, error) {
+	return r.changeOrganizations(ctx, id, func(u *domain.User) error {
+		u.OrgIDs = withoutID(u.OrgIDs, org)
+		u.AdminOrgIDs = withoutID(u.AdminOrgIDs, org)
+		return nil
+	})
+}
+
+func (r *SQLUserRepository) changeOrganizations(ctx context.Context, id domain.ID, change func(*domain.User) error) (*domain.User, error) {
+	u, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := change(u); err != nil {
+		return nil, err
+	}
+	if _, err := r.db.ExecContext(ctx, r.dialect.rebind("UPDATE users SET org_ids = ?, admin_org_ids = ? WHERE id = ?"),
+		encodeIDs(u.OrgIDs), encodeIDs(u.AdminOrgIDs), string(id)); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// userTenantWhere restricts a condition on users to the members of the
+// tenant of ctx. org_ids holds a JSON array of hex IDs, so the quoted ID
+// can only match a whole element.
+func userTenantWhere(ctx context.Context, cond string, args ...interface{}) (string, []interface{}) {
+	if tenant, ok := domain.TenantFrom(ctx); ok {
+		return cond + " AND org_ids LIKE ?", append(args, `%"`+string(tenant)+`"%`)
+	}
+	if !domain.AllTenants(ctx) {
+		return cond + " AND 1 = 0", args
+	}
+	return cond, args
+}
+
+func scanUser(row rowScanner) (*domain.User, error) {
+	var u domain.User
+	var id, orgs, adminOrgs string
+	if err := row.Scan(&id, &u.Name, &u.Email, &u.Password, &u.CreatedAt, &orgs, &adminOrgs); err != nil {
+		return nil, err
+	}
+	u.UserID = domain.ID(id)
+	var err error
+	if u.OrgIDs, err = decodeIDs(orgs); err != nil {
+		return nil, err
+	}
+	if u.AdminOrgIDs, err = decodeIDs(adminOrgs); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
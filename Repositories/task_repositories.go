@@ -3,94 +3,392 @@ package Repositories
 import (
 	"context"
 	"errors"
+	"regexp"
 	"time"
 
 	domain "github.com/surafelbkassa/go-task-manager/Domain"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 type TaskRepository struct {
 	Coll *mongo.Collection
-	ctx  context.Context
 }
 
 func NewTaskRepository(ctx *mongo.Collection) *TaskRepository {
 	return &TaskRepository{
 		Coll: ctx,
-		ctx:  context.Background(),
 	}
 }
-func (r *TaskRepository) GetAll() ([]domain.Task, error) {
-	cur, err := r.Coll.Find(r.ctx, bson.M{})
+
+// EnsureIndexes creates the indexes used to list an organization's
+// tasks, the subtasks and the dependents of a task, to filter by tag and
+// to list a user's assigned tasks and a project's tasks.
+func (r *TaskRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.Coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "org_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "parent_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "depends_on", Value: 1}}},
+		{Keys: bson.D{{Key: "tags", Value: 1}}},
+		{Keys: bson.D{{Key: "assignees", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "project_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+	})
+	return err
+}
+// Find returns one page of tasks matching q, ordered by q.SortBy with the
+// task ID as tiebreaker so cursors stay stable across equal sort values.
+func (r *TaskRepository) Find(ctx context.Context, q domain.TaskQuery) (*domain.TaskPage, error) {
+	sortBy := q.SortBy
+	if sortBy == "" {
+		sortBy = domain.SortByCreatedAt
+	}
+	filter, err := taskFilter(q, sortBy)
 	if err != nil {
 		return nil, err
 	}
-	defer cur.Close(r.ctx)
+	dir := 1
+	if q.SortDesc {
+		dir = -1
+	}
+	opts := options.Find().SetSort(bson.D{{Key: string(sortBy), Value: dir}, {Key: "_id", Value: dir}})
+	if q.Limit > 0 {
+		// fetch one extra document to learn whether another page exists
+		opts.SetLimit(int64(q.Limit) + 1)
+	}
+
+	cur, err := r.Coll.Find(ctx, tenantFilter(ctx, filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
 	var tasks []domain.Task
-	for cur.Next(r.ctx) {
+	for cur.Next(ctx) {
 		var t domain.Task
 		if err := cur.Decode(&t); err != nil {
 			return nil, err
 		}
 		tasks = append(tasks, t)
 	}
-	return tasks, nil
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	page := &domain.TaskPage{Tasks: tasks}
+	if q.Limit > 0 && len(tasks) > q.Limit {
+		page.Tasks = tasks[:q.Limit]
+		page.NextCursor = domain.EncodeTaskCursor(page.Tasks[q.Limit-1], sortBy, q.SortDesc)
+	}
+	return page, nil
 }
 
-func (r *TaskRepository) GetByID(id primitive.ObjectID) (*domain.Task, error) {
+func taskFilter(q domain.TaskQuery, sortBy domain.TaskSortField) (bson.M, error) {
+	// live documents have no deleted_at field; null matches it as missing
+	and := []bson.M{{"deleted_at": nil}}
+	if q.Trashed {
+		and[0] = bson.M{"deleted_at": bson.M{"$ne": nil}}
+	}
+	if q.OwnerID != nil {
+		and = append(and, bson.M{"owner_id": *q.OwnerID})
+	}
+	if q.ParentID != nil {
+		and = append(and, bson.M{"parent_id": *q.ParentID})
+	}
+	if q.ProjectID != nil {
+		and = append(and, bson.M{"project_id": *q.ProjectID})
+	}
+	if q.DependsOn != nil {
+		// matches any element of the array
+		and = append(and, bson.M{"depends_on": *q.DependsOn})
+	}
+	if q.AssigneeID != nil {
+		and = append(and, bson.M{"assignees": *q.AssigneeID})
+	}
+	if len(q.Tags) > 0 {
+		op := "$in"
+		if q.TagMatch == domain.TagMatchAll {
+			op = "$all"
+		}
+		and = append(and, bson.M{"tags": bson.M{op: q.Tags}})
+	}
+	if len(q.Statuses) > 0 {
+		and = append(and, bson.M{"status": bson.M{"$in": q.Statuses}})
+	}
+	if q.TitleContains != "" {
+		and = append(and, bson.M{"title": primitive.Regex{Pattern: regexp.QuoteMeta(q.TitleContains), Options: "i"}})
+	}
+	for _, r := range []struct {
+		field    string
+		from, to *time.Time
+	}{
+		{"due_date", q.DueFrom, q.DueTo},
+		{"created_at", q.CreatedFrom, q.CreatedTo},
+		{"updated_at", q.UpdatedFrom, q.UpdatedTo},
+	} {
+		rng := bson.M{}
+		if r.from != nil {
+			rng["$gte"] = *r.from
+		}
+		if r.to != nil {
+			rng["$lte"] = *r.to
+		}
+		if len(rng) > 0 {
+			and = append(and, bson.M{r.field: rng})
+		}
+	}
+	if q.Cursor != "" {
+		after, err := cursorFilter(q.Cursor, sortBy, q.SortDesc)
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, after)
+	}
+	return bson.M{"$and": and}, nil
+}
+
+// cursorFilter matches documents positioned strictly after the cursor.
+func cursorFilter(cursor string, sortBy domain.TaskSortField, desc bool) (bson.M, error) {
+	c, err := domain.DecodeTaskCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{} = c.Value
+	if sortBy != domain.SortByTitle {
+		if value, err = c.TimeValue(); err != nil {
+			return nil, err
+		}
+	}
+	op := "$gt"
+	if desc {
+		op = "$lt"
+	}
+	field := string(sortBy)
+	return bson.M{"$or": []bson.M{
+		{field: bson.M{op: value}},
+		{field: value, "_id": bson.M{op: c.ID}},
+	}}, nil
+}
+
+func (r *TaskRepository) Ping(ctx context.Context) error {
+	return r.Coll.Database().Client().Ping(ctx, readpref.Primary())
+}
+
+func (r *TaskRepository) GetByID(ctx context.Context, id domain.ID) (*domain.Task, error) {
 	var task domain.Task
-	if err := r.Coll.FindOne(r.ctx, bson.M{"_id": id}).Decode(&task); err != nil {
+	if err := r.Coll.FindOne(ctx, tenantFilter(ctx, bson.M{"_id": id})).Decode(&task); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrTaskNotFound
+		}
 		return nil, err
 	}
 	return &task, nil
 }
 
-func (r *TaskRepository) Create(task domain.Task) (*domain.Task, error) {
-	task.TaskID = primitive.NewObjectID()
+func (r *TaskRepository) Create(ctx context.Context, task domain.Task) (*domain.Task, error) {
+	task.TaskID = domain.NewID()
+	org, err := tenantOf(ctx, task.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	task.OrgID = org
 	task.CreatedAt = time.Now()
 	task.UpdatedAt = time.Now()
-	_, err := r.Coll.InsertOne(r.ctx, task)
+	task.Version = 1
+	_, err = r.Coll.InsertOne(ctx, task)
 	if err != nil {
 		return nil, err
 	}
 	return &task, nil
 }
 
-func (r *TaskRepository) Update(id primitive.ObjectID, task domain.Task) (*domain.Task, error) {
+func (r *TaskRepository) Update(ctx context.Context, id domain.ID, task domain.Task) (*domain.Task, error) {
 	task.UpdatedAt = time.Now()
-	update := bson.M{
-		"$set": bson.M{
-			"title":       task.Title,
-			"description": task.Description,
-			"due_date":    task.DueDate,
-			"status":      task.Status,
-			"updated_at":  task.UpdatedAt,
-		},
+	set := bson.M{
+		"title":        task.Title,
+		"description":  task.Description,
+		"due_date":     task.DueDate,
+		"status":       task.Status,
+		"updated_at":   task.UpdatedAt,
+		"completed_at": task.CompletedAt,
+		"priority":     task.Priority,
+	}
+	unset := bson.M{}
+	update := bson.M{"$set": set, "$inc": bson.M{"version": 1}}
+	if task.ParentID != nil {
+		set["parent_id"] = *task.ParentID
+	} else {
+		unset["parent_id"] = ""
+	}
+	if task.ProjectID != nil {
+		set["project_id"] = *task.ProjectID
+	} else {
+		unset["project_id"] = ""
+	}
+	if len(task.DependsOn) > 0 {
+		set["depends_on"] = task.DependsOn
+	} else {
+		unset["depends_on"] = ""
 	}
-	res, err := r.Coll.UpdateByID(r.ctx, id, update)
+	if task.Recurrence != "" {
+		set["recurrence"] = task.Recurrence
+	} else {
+		unset["recurrence"] = ""
+	}
+	if len(task.Tags) > 0 {
+		set["tags"] = task.Tags
+	} else {
+		unset["tags"] = ""
+	}
+	if len(task.Assignees) > 0 {
+		set["assignees"] = task.Assignees
+	} else {
+		unset["assignees"] = ""
+	}
+	if len(task.Watchers) > 0 {
+		set["watchers"] = task.Watchers
+	} else {
+		unset["watchers"] = ""
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	res, err := r.Coll.UpdateOne(ctx, tenantFilter(ctx, versionFilter(id, task.Version)), update)
 	if err != nil {
 		return nil, err
 	}
 	if res.MatchedCount == 0 {
-		return nil, errors.New("task not found")
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrVersionConflict
 	}
 
-	updatedTask, err := r.GetByID(id)
+	updatedTask, err := r.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	return updatedTask, nil
 }
 
-func (r *TaskRepository) Delete(id primitive.ObjectID) error {
-	res, err := r.Coll.DeleteOne(r.ctx, bson.M{"_id": id})
+// versionFilter matches the task id while it is at version.
+func versionFilter(id domain.ID, version int64) bson.M {
+	if version == 0 {
+		// documents written before versioning have no version field
+		return bson.M{"_id": id, "version": bson.M{"$in": bson.A{0, nil}}}
+	}
+	return bson.M{"_id": id, "version": version}
+}
+
+func (r *TaskRepository) Delete(ctx context.Context, id domain.ID, version int64) error {
+	filter := versionFilter(id, version)
+	filter["deleted_at"] = nil
+	res, err := r.Coll.UpdateOne(ctx,
+		tenantFilter(ctx, filter),
+		bson.M{"$set": bson.M{"deleted_at": time.Now()}, "$inc": bson.M{"version": 1}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		t, err := r.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if t.DeletedAt != nil {
+			return domain.ErrTaskNotFound
+		}
+		return domain.ErrVersionConflict
+	}
+	return nil
+}
+
+func (r *TaskRepository) Restore(ctx context.Context, id domain.ID) (*domain.Task, error) {
+	res, err := r.Coll.UpdateOne(ctx,
+		tenantFilter(ctx, bson.M{"_id": id, "deleted_at": bson.M{"$ne": nil}}),
+		bson.M{
+			"$unset": bson.M{"deleted_at": ""},
+			"$set":   bson.M{"updated_at": time.Now()},
+			"$inc":   bson.M{"version": 1},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if res.MatchedCount == 0 {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrTaskNotInTrash
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *TaskRepository) Purge(ctx context.Context, id domain.ID) error {
+	res, err := r.Coll.DeleteOne(ctx, tenantFilter(ctx, bson.M{"_id": id, "deleted_at": bson.M{"$ne": nil}}))
 	if err != nil {
 		return err
 	}
 	if res.DeletedCount == 0 {
-		return errors.New("task not found")
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return err
+		}
+		return domain.ErrTaskNotInTrash
 	}
 	return nil
 }
+
+func (r *TaskRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) ([]domain.Task, error) {
+	filter := tenantFilter(ctx, bson.M{"deleted_at": bson.M{"$lt": cutoff}})
+	cur, err := r.Coll.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []domain.Task
+	if err := cur.All(ctx, &candidates); err != nil {
+		return nil, err
+	}
+	// delete one by one, re-checking the filter, so a task restored in the
+	// meantime is neither purged nor reported
+	var purged []domain.Task
+	for _, t := range candidates {
+		res, err := r.Coll.DeleteOne(ctx, tenantFilter(ctx, bson.M{"_id": t.TaskID, "deleted_at": bson.M{"$lt": cutoff}}))
+		if err != nil {
+			return purged, err
+		}
+		if res.DeletedCount == 1 {
+			purged = append(purged, t)
+		}
+	}
+	return purged, nil
+}
+
+// CountTags groups the tags of the live tasks on the server.
+func (r *TaskRepository) CountTags(ctx context.Context, owner *domain.ID) ([]domain.TagCount, error) {
+	match := tenantFilter(ctx, bson.M{"deleted_at": nil, "tags": bson.M{"$exists": true}})
+	if owner != nil {
+		match["owner_id"] = *owner
+	}
+	cur, err := r.Coll.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$group", Value: bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var docs []struct {
+		Name  string `bson:"_id"`
+		Count int    `bson:"count"`
+	}
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	counts := make([]domain.TagCount, len(docs))
+	for i, d := range docs {
+		counts[i] = domain.TagCount{Name: d.Name, Count: d.Count}
+	}
+	return counts, nil
+}
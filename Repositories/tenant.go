@@ -0,0 +1,59 @@
+package Repositories
+
+import (
+	"context"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Every repository of tenant data scopes itself with these helpers: a
+// record is only visible from a context scoped to its organization, and
+// records created in a scoped context belong to its organization.
+// Contexts unscoped with domain.WithoutTenant see and create records of
+// any organization. Contexts with no tenant at all fail closed: they see
+// nothing and cannot create records.
+
+// tenantOf returns the organization a record created in ctx belongs to.
+func tenantOf(ctx context.Context, org domain.ID) (domain.ID, error) {
+	if tenant, ok := domain.TenantFrom(ctx); ok {
+		return tenant, nil
+	}
+	if !domain.AllTenants(ctx) {
+		return "", domain.ErrNoTenant
+	}
+	return org, nil
+}
+
+// inTenant reports whether a record of org is visible from ctx.
+func inTenant(ctx context.Context, org domain.ID) bool {
+	if tenant, ok := domain.TenantFrom(ctx); ok {
+		return tenant == org
+	}
+	return domain.AllTenants(ctx)
+}
+
+// matchNothing is a Mongo condition no document satisfies.
+var matchNothing = bson.M{"$in": bson.A{}}
+
+// tenantFilter restricts a Mongo filter to the organization of ctx.
+func tenantFilter(ctx context.Context, filter bson.M) bson.M {
+	if tenant, ok := domain.TenantFrom(ctx); ok {
+		filter["org_id"] = tenant
+	} else if !domain.AllTenants(ctx) {
+		filter["org_id"] = matchNothing
+	}
+	return filter
+}
+
+// tenantWhere appends the organization of ctx to a SQL condition, which
+// must end the statement's WHERE clause, and to its arguments.
+func tenantWhere(ctx context.Context, cond string, args ...interface{}) (string, []interface{}) {
+	if tenant, ok := domain.TenantFrom(ctx); ok {
+		return cond + " AND org_id = ?", append(args, string(tenant))
+	}
+	if !domain.AllTenants(ctx) {
+		return cond + " AND 1 = 0", args
+	}
+	return cond, args
+}
@@ -0,0 +1,114 @@
+package Repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// compile‑time checks for the Mongo token stores
+var (
+	_ domain.RefreshTokenRepository = (*RefreshTokenRepository)(nil)
+	_ domain.TokenDenylist          = (*TokenDenylist)(nil)
+)
+
+type RefreshTokenRepository struct {
+	Coll *mongo.Collection
+}
+
+func NewRefreshTokenRepository(c *mongo.Collection) *RefreshTokenRepository {
+	return &RefreshTokenRepository{Coll: c}
+}
+
+// EnsureIndexes creates the token hash lookup index and a TTL index that
+// lets Mongo drop tokens once they expire.
+func (r *RefreshTokenRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.Coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "family_id", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return err
+}
+
+func (r *RefreshTokenRepository) Create(ctx context.Context, token domain.RefreshToken) (*domain.RefreshToken, error) {
+	token.ID = domain.NewID()
+	token.CreatedAt = time.Now()
+	if _, err := r.Coll.InsertOne(ctx, token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *RefreshTokenRepository) GetByHash(ctx context.Context, hash string) (*domain.RefreshToken, error) {
+	var token domain.RefreshToken
+	if err := r.Coll.FindOne(ctx, bson.M{"token_hash": hash}).Decode(&token); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrRefreshTokenNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *RefreshTokenRepository) MarkRotated(ctx context.Context, id domain.ID, replacedBy domain.ID) (bool, error) {
+	res, err := r.Coll.UpdateOne(ctx,
+		bson.M{"_id": id, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": time.Now(), "replaced_by": replacedBy}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount == 1, nil
+}
+
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID domain.ID) error {
+	_, err := r.Coll.UpdateMany(ctx,
+		bson.M{"family_id": familyID, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	)
+	return err
+}
+
+// TokenDenylist stores revoked access token IDs in Mongo.
+type TokenDenylist struct {
+	Coll *mongo.Collection
+}
+
+func NewTokenDenylist(c *mongo.Collection) *TokenDenylist {
+	return &TokenDenylist{Coll: c}
+}
+
+// EnsureIndexes creates a TTL index so entries vanish once the token
+// they block would have expired anyway.
+func (d *TokenDenylist) EnsureIndexes(ctx context.Context) error {
+	_, err := d.Coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+func (d *TokenDenylist) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	_, err := d.Coll.UpdateOne(ctx,
+		bson.M{"_id": tokenID},
+		bson.M{"$set": bson.M{"expires_at": expiresAt}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// IsRevoked also checks the expiry itself because Mongo's TTL monitor
+// only runs about once a minute.
+func (d *TokenDenylist) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	err := d.Coll.FindOne(ctx, bson.M{"_id": tokenID, "expires_at": bson.M{"$gt": time.Now()}}).Err()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -7,8 +7,9 @@ import (
 
 	domain "github.com/surafelbkassa/go-task-manager/Domain"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 // compile‑time check that UserRepository implements domain.UserRepository
@@ -16,53 +17,75 @@ var _ domain.UserRepository = (*UserRepository)(nil)
 
 type UserRepository struct {
 	Coll *mongo.Collection
-	ctx  context.Context
 }
 
-func NewUserRepository(c *mongo.Collection, ctx context.Context) *UserRepository {
+func NewUserRepository(c *mongo.Collection) *UserRepository {
 	return &UserRepository{
 		Coll: c,
-		ctx:  ctx,
 	}
 }
 
-func (r *UserRepository) Create(user domain.User) (*domain.User, error) {
-	user.UserID = primitive.NewObjectID()
+// EnsureIndexes creates the unique email index that backs duplicate
+// detection in Create and the index used to list an organization's
+// members. It is safe to call on every startup.
+func (r *UserRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.Coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "org_ids", Value: 1}}},
+	})
+	return err
+}
+
+func (r *UserRepository) Create(ctx context.Context, user domain.User) (*domain.User, error) {
+	user.UserID = domain.NewID()
 	user.CreatedAt = time.Now()
-	_, err := r.Coll.InsertOne(r.ctx, user)
+	_, err := r.Coll.InsertOne(ctx, user)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, domain.ErrDuplicateEmail
+		}
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *UserRepository) GetByID(id primitive.ObjectID) (*domain.User, error) {
+func (r *UserRepository) Ping(ctx context.Context) error {
+	return r.Coll.Database().Client().Ping(ctx, readpref.Primary())
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id domain.ID) (*domain.User, error) {
 	var user domain.User
-	err := r.Coll.FindOne(r.ctx, bson.M{"_id": id}).Decode(&user)
+	err := r.Coll.FindOne(ctx, userTenantFilter(ctx, bson.M{"_id": id})).Decode(&user)
 	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrUserNotFound
+		}
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *UserRepository) GetByEmail(email string) (*domain.User, error) {
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	var user domain.User
-	err := r.Coll.FindOne(r.ctx, bson.M{"email": email}).Decode(&user)
+	err := r.Coll.FindOne(ctx, userTenantFilter(ctx, bson.M{"email": email})).Decode(&user)
 	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrUserNotFound
+		}
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *UserRepository) GetAll() ([]*domain.User, error) {
-	cur, err := r.Coll.Find(r.ctx, bson.M{})
+func (r *UserRepository) GetAll(ctx context.Context) ([]*domain.User, error) {
+	cur, err := r.Coll.Find(ctx, userTenantFilter(ctx, bson.M{}))
 	if err != nil {
 		return nil, err
 	}
-	defer cur.Close(r.ctx)
+	defer cur.Close(ctx)
 
 	var users []*domain.User
-	for cur.Next(r.ctx) {
+	for cur.Next(ctx) {
 		var u domain.User
 		if err := cur.Decode(&u); err != nil {
 			return nil, err
@@ -72,17 +95,40 @@ func (r *UserRepository) GetAll() ([]*domain.User, error) {
 	return users, nil
 }
 
-func (r *UserRepository) PromoteUser(id primitive.ObjectID) (*domain.User, error) {
-	res, err := r.Coll.UpdateOne(
-		r.ctx,
-		bson.M{"_id": id},
-		bson.M{"$set": bson.M{"role": "admin"}},
-	)
+func (r *UserRepository) PromoteUser(ctx context.Context, id, org domain.ID) (*domain.User, error) {
+	// $and keeps the membership condition apart from the tenant's
+	return r.changeOrganizations(ctx, bson.M{"_id": id, "$and": bson.A{bson.M{"org_ids": org}}},
+		bson.M{"$addToSet": bson.M{"admin_org_ids": org}})
+}
+
+func (r *UserRepository) AddOrganization(ctx context.Context, id, org domain.ID) (*domain.User, error) {
+	return r.changeOrganizations(ctx, bson.M{"_id": id}, bson.M{"$addToSet": bson.M{"org_ids": org}})
+}
+
+func (r *UserRepository) RemoveOrganization(ctx context.Context, id, org domain.ID) (*domain.User, error) {
+	return r.changeOrganizations(ctx, bson.M{"_id": id}, bson.M{"$pull": bson.M{"org_ids": org, "admin_org_ids": org}})
+}
+
+func (r *UserRepository) changeOrganizations(ctx context.Context, filter, update bson.M) (*domain.User, error) {
+	var user domain.User
+	err := r.Coll.FindOneAndUpdate(ctx, userTenantFilter(ctx, filter), update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&user)
 	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrUserNotFound
+		}
 		return nil, err
 	}
-	if res.MatchedCount == 0 {
-		return nil, errors.New("user not found")
+	return &user, nil
+}
+
+// userTenantFilter restricts a filter on users to the members of the
+// tenant of ctx; matching an array field matches any of its elements.
+func userTenantFilter(ctx context.Context, filter bson.M) bson.M {
+	if tenant, ok := domain.TenantFrom(ctx); ok {
+		filter["org_ids"] = tenant
+	} else if !domain.AllTenants(ctx) {
+		filter["org_ids"] = matchNothing
 	}
-	return r.GetByID(id)
+	return filter
 }
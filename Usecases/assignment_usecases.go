@@ -0,0 +1,106 @@
+package Usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+var (
+	ErrInvalidAssignee = domain.NewError(domain.ErrValidation, "invalid_assignee", "assignee is not a user")
+	ErrInvalidWatcher  = domain.NewError(domain.ErrValidation, "invalid_watcher", "watcher is not a user")
+	ErrReadOnlyTask    = domain.NewError(domain.ErrForbidden, "task_read_only", "watchers can only read the task")
+	ErrOwnerRequired   = domain.NewError(domain.ErrForbidden, "owner_required", "only the task's owner or an admin can do that")
+)
+
+// GetAssignedTasks returns one page of the live tasks assigned to the
+// actor, whoever owns them.
+func (u *TaskUseCase) GetAssignedTasks(ctx context.Context, actor domain.Actor, q domain.TaskQuery) (*domain.TaskPage, error) {
+	q.Trashed = false
+	q.OwnerID = nil
+	q.AssigneeID = &actor.UserID
+	return u.findTasks(ctx, q)
+}
+
+// normalizePeople sorts the task's assignees and watchers and drops
+// duplicates.
+func normalizePeople(task *domain.Task) {
+	task.Assignees = domain.NormalizeIDs(task.Assignees)
+	task.Watchers = domain.NormalizeIDs(task.Watchers)
+}
+
+// checkPeople verifies that every assignee and watcher added by a change
+// from before to after is a user. Only the owner, an admin or a
+// maintainer of the task's project may change them; a new task is always
+// the actor's own.
+func (u *TaskUseCase) checkPeople(ctx context.Context, actor domain.Actor, before, after domain.Task) error {
+	added := domain.AddedIDs(before.Assignees, after.Assignees)
+	addedWatchers := domain.AddedIDs(before.Watchers, after.Watchers)
+	changed := !domain.SameIDs(before.Assignees, after.Assignees) || !domain.SameIDs(before.Watchers, after.Watchers)
+	if changed && before.TaskID != "" {
+		if err := u.authorize(ctx, actor, &before, domain.OwnerAccess); err != nil {
+			return err
+		}
+	}
+	for _, check := range []struct {
+		ids []domain.ID
+		err error
+	}{{added, ErrInvalidAssignee}, {addedWatchers, ErrInvalidWatcher}} {
+		for _, id := range check.ids {
+			if u.users == nil {
+				return fmt.Errorf("%w: %s", check.err, id)
+			}
+			_, err := u.users.GetByID(ctx, id)
+			if errors.Is(err, domain.ErrNotFound) {
+				return fmt.Errorf("%w: %s", check.err, id)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// notify tells users about a change that has already been stored: added
+// and removed assignees, then every watcher unless the task was just
+// created. Nobody is notified of their own change, nor twice of the same
+// one.
+func (u *TaskUseCase) notify(ctx context.Context, actor domain.Actor, id domain.ID, action domain.HistoryAction, before, after domain.Task) error {
+	if u.notifications == nil || action == domain.HistoryPurged {
+		return nil
+	}
+	notified := map[domain.ID]bool{actor.UserID: true}
+	send := func(users []domain.ID, kind domain.NotificationKind) error {
+		for _, user := range users {
+			if notified[user] {
+				continue
+			}
+			notified[user] = true
+			_, err := u.notifications.Create(ctx, domain.Notification{
+				OrgID:     taskOrg(before, after),
+				UserID:    user,
+				Kind:      kind,
+				TaskID:    id,
+				ActorID:   actor.UserID,
+				CreatedAt: u.now(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := send(domain.AddedIDs(before.Assignees, after.Assignees), domain.NotificationAssigned); err != nil {
+		return err
+	}
+	if err := send(domain.AddedIDs(after.Assignees, before.Assignees), domain.NotificationUnassigned); err != nil {
+		return err
+	}
+	if action == domain.HistoryCreated {
+		return nil
+	}
+	return send(domain.NormalizeIDs(append(append([]domain.ID(nil), before.Watchers...), after.Watchers...)), domain.NotificationTaskChanged)
+}
@@ -0,0 +1,135 @@
+package Usecases
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	Domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+var (
+	assignee = Domain.Actor{UserID: Domain.NewID(), Role: "user"}
+	watcher  = Domain.Actor{UserID: Domain.NewID(), Role: "user"}
+)
+
+func TestTransitionTask_AssigneeMayEdit(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	id := Domain.NewID()
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{
+		TaskID: id, OwnerID: owner.UserID, Status: Domain.StatusPending, Assignees: []Domain.ID{assignee.UserID},
+	}, nil)
+	mockRepo.On("Update", mock.Anything, id, mock.Anything).Return(&Domain.Task{TaskID: id, Status: Domain.StatusInProgress}, nil)
+
+	_, err := uc.TransitionTask(ctx, assignee, id.String(), "in_progress")
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTransitionTask_WatcherIsReadOnly(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	id := Domain.NewID()
+	task := &Domain.Task{TaskID: id, OwnerID: owner.UserID, Status: Domain.StatusPending, Watchers: []Domain.ID{watcher.UserID}}
+	mockRepo.On("GetByID", mock.Anything, id).Return(task, nil)
+
+	got, err := uc.GetTaskByID(ctx, watcher, id.String())
+	require.NoError(t, err)
+	assert.Equal(t, task, got)
+
+	_, err = uc.TransitionTask(ctx, watcher, id.String(), "in_progress")
+	assert.ErrorIs(t, err, ErrReadOnlyTask)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDeleteTask_AssigneeIsNotOwner(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	id := Domain.NewID()
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{
+		TaskID: id, OwnerID: owner.UserID, Assignees: []Domain.ID{assignee.UserID},
+	}, nil)
+
+	err := uc.DeleteTask(ctx, assignee, id.String(), 0)
+	assert.ErrorIs(t, err, ErrOwnerRequired)
+	mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPatchTask_AssigneeCannotChangePeople(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	id := Domain.NewID()
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{
+		TaskID: id, OwnerID: owner.UserID, Title: "t", Status: Domain.StatusPending, Assignees: []Domain.ID{assignee.UserID},
+	}, nil)
+
+	_, err := uc.PatchTask(ctx, assignee, id.String(), 0, Domain.TaskPatch{Assignees: &[]Domain.ID{}})
+	assert.ErrorIs(t, err, ErrOwnerRequired)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateTask_UnknownAssignee(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	users := new(MockUserRepo)
+	uc := NewTaskUseCase(mockRepo, WithUsers(users))
+
+	ghost := Domain.NewID()
+	users.On("GetByID", mock.Anything, ghost).Return(nil, Domain.ErrUserNotFound)
+
+	_, err := uc.CreateTask(ctx, owner, Domain.Task{Title: "t", Assignees: []Domain.ID{ghost}})
+	assert.ErrorIs(t, err, ErrInvalidAssignee)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestPatchTask_NotifiesAssigneesAndWatchers(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	users := new(MockUserRepo)
+	notifications := new(MockNotificationRepo)
+	now := time.Date(2025, 7, 20, 12, 0, 0, 0, time.UTC)
+	uc := NewTaskUseCase(mockRepo, WithUsers(users), WithNotifications(notifications))
+	uc.now = func() time.Time { return now }
+
+	id := Domain.NewID()
+	before := &Domain.Task{
+		TaskID: id, OwnerID: owner.UserID, Title: "t", Status: Domain.StatusPending,
+		Assignees: []Domain.ID{watcher.UserID}, Watchers: []Domain.ID{watcher.UserID},
+	}
+	after := *before
+	after.Assignees = []Domain.ID{assignee.UserID}
+	mockRepo.On("GetByID", mock.Anything, id).Return(before, nil)
+	mockRepo.On("Update", mock.Anything, id, mock.Anything).Return(&after, nil)
+	users.On("GetByID", mock.Anything, assignee.UserID).Return(&Domain.User{UserID: assignee.UserID}, nil)
+	for user, kind := range map[Domain.ID]Domain.NotificationKind{
+		assignee.UserID: Domain.NotificationAssigned,
+		watcher.UserID:  Domain.NotificationUnassigned,
+	} {
+		notifications.On("Create", mock.Anything, Domain.Notification{
+			UserID: user, Kind: kind, TaskID: id, ActorID: owner.UserID, CreatedAt: now,
+		}).Return(&Domain.Notification{}, nil).Once()
+	}
+
+	_, err := uc.PatchTask(ctx, owner, id.String(), 0, Domain.TaskPatch{Assignees: &[]Domain.ID{assignee.UserID}})
+	require.NoError(t, err)
+	notifications.AssertExpectations(t)
+	notifications.AssertNumberOfCalls(t, "Create", 2)
+}
+
+func TestGetAssignedTasks_QueriesByAssignee(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	mockRepo.On("Find", mock.Anything, mock.MatchedBy(func(q Domain.TaskQuery) bool {
+		return q.OwnerID == nil && q.AssigneeID != nil && *q.AssigneeID == assignee.UserID && !q.Trashed
+	})).Return(&Domain.TaskPage{}, nil)
+
+	_, err := uc.GetAssignedTasks(ctx, assignee, Domain.TaskQuery{OwnerID: &owner.UserID, Trashed: true})
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
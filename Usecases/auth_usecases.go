@@ -0,0 +1,179 @@
+package Usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+var (
+	ErrInvalidRefreshToken = domain.NewError(domain.ErrUnauthorized, "invalid_refresh_token", "invalid refresh token")
+	// ErrRefreshTokenReused means an already rotated token was presented,
+	// which suggests it was stolen; its whole family has been revoked.
+	ErrRefreshTokenReused = domain.NewError(domain.ErrUnauthorized, "refresh_token_reused", "refresh token reuse detected")
+)
+
+type AuthUseCaseInterface interface {
+	IssueTokens(ctx context.Context, user *domain.User, org domain.ID) (*domain.TokenPair, error)
+	SwitchOrganization(ctx context.Context, claims *domain.AccessClaims, org domain.ID) (*domain.TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (*domain.TokenPair, error)
+	Logout(ctx context.Context, claims *domain.AccessClaims, refreshToken string) error
+}
+
+// AuthUseCase pairs short-lived access tokens with rotating refresh tokens.
+type AuthUseCase struct {
+	users      domain.UserRepository
+	tokens     domain.RefreshTokenRepository
+	denylist   domain.TokenDenylist
+	jwt        domain.JWTService
+	refreshTTL time.Duration
+	now        func() time.Time
+}
+
+func NewAuthUseCase(
+	users domain.UserRepository,
+	tokens domain.RefreshTokenRepository,
+	denylist domain.TokenDenylist,
+	jwt domain.JWTService,
+	refreshTTL time.Duration,
+) *AuthUseCase {
+	return &AuthUseCase{
+		users:      users,
+		tokens:     tokens,
+		denylist:   denylist,
+		jwt:        jwt,
+		refreshTTL: refreshTTL,
+		now:        time.Now,
+	}
+}
+
+// IssueTokens starts a new refresh token family for a freshly logged in
+// user, scoped to org or, when org is empty, to their default
+// organization. Organizations the user does not belong to are reported
+// as not found.
+func (uc *AuthUseCase) IssueTokens(ctx context.Context, user *domain.User, org domain.ID) (*domain.TokenPair, error) {
+	if org.IsZero() {
+		org, _ = user.DefaultOrganization()
+	}
+	if org.IsZero() || !user.InOrganization(org) {
+		return nil, domain.ErrOrganizationNotFound
+	}
+	pair, _, err := uc.newPair(ctx, user, org, domain.NewID())
+	return pair, err
+}
+
+// SwitchOrganization issues the caller a new token pair scoped to org.
+// The tokens of the current session stay valid.
+func (uc *AuthUseCase) SwitchOrganization(ctx context.Context, claims *domain.AccessClaims, org domain.ID) (*domain.TokenPair, error) {
+	user, err := uc.users.GetByID(domain.WithoutTenant(ctx), claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.IssueTokens(ctx, user, org)
+}
+
+// Refresh rotates a refresh token: the presented token is revoked and a new
+// one in the same family is returned with a new access token. Presenting a
+// token that was already rotated revokes the whole family.
+func (uc *AuthUseCase) Refresh(ctx context.Context, refreshToken string) (*domain.TokenPair, error) {
+	stored, err := uc.tokens.GetByHash(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if stored.RevokedAt != nil {
+		return nil, uc.reused(ctx, stored)
+	}
+	if !uc.now().Before(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+	user, err := uc.users.GetByID(domain.WithoutTenant(ctx), stored.UserID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	// a user taken out of the organization keeps their session, moved
+	// back to their default organization
+	org := stored.OrgID
+	if !user.InOrganization(org) {
+		if org, _ = user.DefaultOrganization(); org.IsZero() {
+			return nil, ErrInvalidRefreshToken
+		}
+	}
+
+	pair, next, err := uc.newPair(ctx, user, org, stored.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	rotated, err := uc.tokens.MarkRotated(ctx, stored.ID, next.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// a concurrent request won the rotation with the same token
+		return nil, uc.reused(ctx, stored)
+	}
+	return pair, nil
+}
+
+// Logout revokes the caller's access token and, when given, the family of
+// the refresh token so no session derived from this login survives.
+func (uc *AuthUseCase) Logout(ctx context.Context, claims *domain.AccessClaims, refreshToken string) error {
+	if refreshToken != "" {
+		stored, err := uc.tokens.GetByHash(ctx, hashRefreshToken(refreshToken))
+		if err != nil || stored.UserID != claims.UserID {
+			return ErrInvalidRefreshToken
+		}
+		if err := uc.tokens.RevokeFamily(ctx, stored.FamilyID); err != nil {
+			return err
+		}
+	}
+	return uc.denylist.Revoke(ctx, claims.TokenID, claims.ExpiresAt)
+}
+
+func (uc *AuthUseCase) reused(ctx context.Context, stored *domain.RefreshToken) error {
+	if err := uc.tokens.RevokeFamily(ctx, stored.FamilyID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// newPair signs an access token scoped to org and stores a new refresh
+// token in family.
+func (uc *AuthUseCase) newPair(ctx context.Context, user *domain.User, org, family domain.ID) (*domain.TokenPair, *domain.RefreshToken, error) {
+	access, accessExp, err := uc.jwt.GenerateToken(user.UserID, user.RoleIn(org), org)
+	if err != nil {
+		return nil, nil, err
+	}
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, nil, err
+	}
+	refresh := base64.RawURLEncoding.EncodeToString(raw)
+	stored, err := uc.tokens.Create(ctx, domain.RefreshToken{
+		FamilyID:  family,
+		UserID:    user.UserID,
+		OrgID:     org,
+		TokenHash: hashRefreshToken(refresh),
+		ExpiresAt: uc.now().Add(uc.refreshTTL),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &domain.TokenPair{
+		AccessToken:      access,
+		AccessExpiresAt:  accessExp,
+		RefreshToken:     refresh,
+		RefreshExpiresAt: stored.ExpiresAt,
+	}, stored, nil
+}
+
+// hashRefreshToken is what gets stored, so a database leak does not
+// expose usable refresh tokens.
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
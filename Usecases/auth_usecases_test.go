@@ -0,0 +1,204 @@
+package Usecases
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	Domain "github.com/surafelbkassa/go-task-manager/Domain"
+	"github.com/surafelbkassa/go-task-manager/Infrastructure"
+	"github.com/surafelbkassa/go-task-manager/Repositories"
+)
+
+type authFixture struct {
+	uc       *AuthUseCase
+	jwt      Infrastructure.JWTServiceInterface
+	tokens   *Repositories.InMemoryRefreshTokenRepository
+	denylist *Repositories.InMemoryTokenDenylist
+	users    *Repositories.InMemoryUserRepository
+	user     *Domain.User
+}
+
+func newAuthFixture(t *testing.T) *authFixture {
+	users := Repositories.NewInMemoryUserRepository()
+	user, err := users.Create(ctx, Domain.User{Email: "a@b.com"})
+	require.NoError(t, err)
+	user, err = users.AddOrganization(ctx, user.UserID, user.UserID)
+	require.NoError(t, err)
+
+	tokens := Repositories.NewInMemoryRefreshTokenRepository()
+	denylist := Repositories.NewInMemoryTokenDenylist()
+	jwt := Infrastructure.NewJWTService("test-secret", time.Minute, denylist)
+	return &authFixture{
+		uc:       NewAuthUseCase(users, tokens, denylist, jwt, time.Hour),
+		jwt:      jwt,
+		tokens:   tokens,
+		denylist: denylist,
+		users:    users,
+		user:     user,
+	}
+}
+
+func TestIssueTokens(t *testing.T) {
+	f := newAuthFixture(t)
+
+	pair, err := f.uc.IssueTokens(ctx, f.user, "")
+	require.NoError(t, err)
+	assert.NotEmpty(t, pair.RefreshToken)
+
+	claims, err := f.jwt.ValidateToken(ctx, pair.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, f.user.UserID, claims.UserID)
+	assert.Equal(t, "user", claims.Role)
+	assert.Equal(t, f.user.UserID, claims.OrgID, "defaults to the personal organization")
+	assert.NotEmpty(t, claims.TokenID)
+
+	stored, err := f.tokens.GetByHash(ctx, hashRefreshToken(pair.RefreshToken))
+	require.NoError(t, err)
+	assert.Equal(t, f.user.UserID, stored.UserID)
+	assert.Equal(t, f.user.UserID, stored.OrgID)
+}
+
+func TestIssueTokens_Organization(t *testing.T) {
+	f := newAuthFixture(t)
+	org := Domain.NewID()
+
+	_, err := f.uc.IssueTokens(ctx, f.user, org)
+	assert.ErrorIs(t, err, Domain.ErrOrganizationNotFound, "not a member yet")
+
+	user, err := f.users.AddOrganization(ctx, f.user.UserID, org)
+	require.NoError(t, err)
+	pair, err := f.uc.IssueTokens(ctx, user, org)
+	require.NoError(t, err)
+	claims, err := f.jwt.ValidateToken(ctx, pair.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, org, claims.OrgID)
+}
+
+func TestSwitchOrganization(t *testing.T) {
+	f := newAuthFixture(t)
+	org := Domain.NewID()
+	_, err := f.users.AddOrganization(ctx, f.user.UserID, org)
+	require.NoError(t, err)
+	first, err := f.uc.IssueTokens(ctx, f.user, "")
+	require.NoError(t, err)
+	claims, err := f.jwt.ValidateToken(ctx, first.AccessToken)
+	require.NoError(t, err)
+
+	// the caller's tenant must not hide their own user record
+	switched, err := f.uc.SwitchOrganization(Domain.WithTenant(ctx, claims.OrgID), claims, org)
+	require.NoError(t, err)
+	next, err := f.jwt.ValidateToken(ctx, switched.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, org, next.OrgID)
+	_, err = f.jwt.ValidateToken(ctx, first.AccessToken)
+	assert.NoError(t, err, "the old session stays valid")
+
+	_, err = f.uc.SwitchOrganization(ctx, claims, Domain.NewID())
+	assert.ErrorIs(t, err, Domain.ErrOrganizationNotFound)
+}
+
+func TestRefresh_KeepsOrganization(t *testing.T) {
+	f := newAuthFixture(t)
+	org := Domain.NewID()
+	user, err := f.users.AddOrganization(ctx, f.user.UserID, org)
+	require.NoError(t, err)
+	first, err := f.uc.IssueTokens(ctx, user, org)
+	require.NoError(t, err)
+
+	second, err := f.uc.Refresh(ctx, first.RefreshToken)
+	require.NoError(t, err)
+	claims, err := f.jwt.ValidateToken(ctx, second.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, org, claims.OrgID)
+
+	// once removed from the organization the session falls back to the
+	// personal one
+	_, err = f.users.RemoveOrganization(ctx, f.user.UserID, org)
+	require.NoError(t, err)
+	third, err := f.uc.Refresh(ctx, second.RefreshToken)
+	require.NoError(t, err)
+	claims, err = f.jwt.ValidateToken(ctx, third.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, f.user.UserID, claims.OrgID)
+}
+
+func TestRefresh_RotatesToken(t *testing.T) {
+	f := newAuthFixture(t)
+	first, err := f.uc.IssueTokens(ctx, f.user, "")
+	require.NoError(t, err)
+
+	second, err := f.uc.Refresh(ctx, first.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+	_, err = f.jwt.ValidateToken(ctx, second.AccessToken)
+	assert.NoError(t, err)
+
+	old, err := f.tokens.GetByHash(ctx, hashRefreshToken(first.RefreshToken))
+	require.NoError(t, err)
+	assert.NotNil(t, old.RevokedAt)
+	next, err := f.tokens.GetByHash(ctx, hashRefreshToken(second.RefreshToken))
+	require.NoError(t, err)
+	assert.Equal(t, old.FamilyID, next.FamilyID)
+	assert.Equal(t, next.ID, old.ReplacedBy)
+}
+
+func TestRefresh_ReuseRevokesFamily(t *testing.T) {
+	f := newAuthFixture(t)
+	first, err := f.uc.IssueTokens(ctx, f.user, "")
+	require.NoError(t, err)
+	second, err := f.uc.Refresh(ctx, first.RefreshToken)
+	require.NoError(t, err)
+
+	// an attacker replays the rotated token
+	_, err = f.uc.Refresh(ctx, first.RefreshToken)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+
+	// the legitimate client's newer token is now dead too
+	_, err = f.uc.Refresh(ctx, second.RefreshToken)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+}
+
+func TestRefresh_UnknownToken(t *testing.T) {
+	f := newAuthFixture(t)
+	_, err := f.uc.Refresh(ctx, "nope")
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestRefresh_Expired(t *testing.T) {
+	f := newAuthFixture(t)
+	pair, err := f.uc.IssueTokens(ctx, f.user, "")
+	require.NoError(t, err)
+
+	f.uc.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	_, err = f.uc.Refresh(ctx, pair.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestLogout_RevokesAccessTokenAndFamily(t *testing.T) {
+	f := newAuthFixture(t)
+	pair, err := f.uc.IssueTokens(ctx, f.user, "")
+	require.NoError(t, err)
+	claims, err := f.jwt.ValidateToken(ctx, pair.AccessToken)
+	require.NoError(t, err)
+
+	require.NoError(t, f.uc.Logout(ctx, claims, pair.RefreshToken))
+
+	_, err = f.jwt.ValidateToken(ctx, pair.AccessToken)
+	assert.Error(t, err, "revoked access token must be rejected")
+	_, err = f.uc.Refresh(ctx, pair.RefreshToken)
+	assert.Error(t, err)
+}
+
+func TestLogout_ForeignRefreshToken(t *testing.T) {
+	f := newAuthFixture(t)
+	pair, err := f.uc.IssueTokens(ctx, f.user, "")
+	require.NoError(t, err)
+
+	other := &Domain.AccessClaims{UserID: Domain.NewID(), TokenID: "x", ExpiresAt: time.Now().Add(time.Minute)}
+	assert.ErrorIs(t, f.uc.Logout(ctx, other, pair.RefreshToken), ErrInvalidRefreshToken)
+
+	_, err = f.uc.Refresh(ctx, pair.RefreshToken)
+	assert.NoError(t, err, "another user's logout must not revoke the family")
+}
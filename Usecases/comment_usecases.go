@@ -0,0 +1,210 @@
+package Usecases
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+var ErrNotCommentAuthor = domain.NewError(domain.ErrForbidden, "not_comment_author", "only the author or an admin can change a comment")
+
+type CommentUseCaseInterface interface {
+	GetComments(ctx context.Context, actor domain.Actor, taskID string, q domain.CommentQuery) (*domain.CommentPage, error)
+	AddComment(ctx context.Context, actor domain.Actor, taskID, body string) (*domain.Comment, error)
+	UpdateComment(ctx context.Context, actor domain.Actor, taskID, commentID, body string) (*domain.Comment, error)
+	DeleteComment(ctx context.Context, actor domain.Actor, taskID, commentID string) error
+}
+
+// CommentUseCase implements the discussion on tasks. Anyone who can access
+// a task, including the members of its project, can read and add its
+// comments; only the author or an admin can change one.
+type CommentUseCase struct {
+	comments      domain.CommentRepository
+	tasks         domain.TaskRepository
+	projects      domain.ProjectRepository
+	users         domain.UserRepository
+	notifications domain.NotificationRepository
+	now           func() time.Time
+}
+
+func NewCommentUseCase(c domain.CommentRepository, t domain.TaskRepository, p domain.ProjectRepository, u domain.UserRepository, n domain.NotificationRepository) *CommentUseCase {
+	return &CommentUseCase{comments: c, tasks: t, projects: p, users: u, notifications: n, now: time.Now}
+}
+
+// GetComments returns one page of a task's comments, oldest first.
+func (uc *CommentUseCase) GetComments(ctx context.Context, actor domain.Actor, taskID string, q domain.CommentQuery) (*domain.CommentPage, error) {
+	task, err := uc.loadTask(ctx, actor, taskID, false)
+	if err != nil {
+		return nil, err
+	}
+	if q.Cursor != "" {
+		if _, _, err := domain.DecodeCommentCursor(q.Cursor); err != nil {
+			return nil, err
+		}
+	}
+	q.TaskID = task.TaskID
+	q.Limit = pageLimit(q.Limit)
+	return uc.comments.Find(ctx, q)
+}
+
+// AddComment comments on a task and notifies the users it mentions.
+func (uc *CommentUseCase) AddComment(ctx context.Context, actor domain.Actor, taskID, body string) (*domain.Comment, error) {
+	task, err := uc.loadTask(ctx, actor, taskID, true)
+	if err != nil {
+		return nil, err
+	}
+	now := uc.now()
+	comment := domain.Comment{
+		OrgID:     task.OrgID,
+		TaskID:    task.TaskID,
+		AuthorID:  actor.UserID,
+		Body:      strings.TrimSpace(body),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := comment.Validate(); err != nil {
+		return nil, err
+	}
+	created, err := uc.comments.Create(ctx, comment)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.notifyMentions(ctx, actor, task, *created, ""); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// UpdateComment replaces a comment's body, keeping the old one in its
+// edit history. Only users newly mentioned by the edit are notified.
+func (uc *CommentUseCase) UpdateComment(ctx context.Context, actor domain.Actor, taskID, commentID, body string) (*domain.Comment, error) {
+	task, comment, err := uc.loadComment(ctx, actor, taskID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	body = strings.TrimSpace(body)
+	if body == comment.Body {
+		return comment, nil
+	}
+	previous := comment.Body
+	comment.Edit(body, uc.now())
+	if err := comment.Validate(); err != nil {
+		return nil, err
+	}
+	updated, err := uc.comments.Update(ctx, *comment)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.notifyMentions(ctx, actor, task, *updated, previous); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// DeleteComment removes a comment for good.
+func (uc *CommentUseCase) DeleteComment(ctx context.Context, actor domain.Actor, taskID, commentID string) error {
+	_, comment, err := uc.loadComment(ctx, actor, taskID, commentID)
+	if err != nil {
+		return err
+	}
+	return uc.comments.Delete(ctx, comment.ID)
+}
+
+// loadTask returns a live task the actor can access. Comments on the
+// tasks of an archived project can be read but not written.
+func (uc *CommentUseCase) loadTask(ctx context.Context, actor domain.Actor, id string, write bool) (*domain.Task, error) {
+	objID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+	task, err := uc.tasks.GetByID(ctx, objID)
+	if err != nil {
+		return nil, err
+	}
+	if task.DeletedAt != nil {
+		return nil, domain.ErrTaskNotFound
+	}
+	access, project, err := taskAccess(ctx, uc.projects, actor, task)
+	if err != nil {
+		return nil, err
+	}
+	if access < domain.ReadAccess {
+		return nil, domain.ErrTaskNotFound
+	}
+	if write && project != nil && project.Archived() {
+		return nil, ErrProjectArchived
+	}
+	return task, nil
+}
+
+// loadComment returns a comment that the actor may change, with its task.
+func (uc *CommentUseCase) loadComment(ctx context.Context, actor domain.Actor, taskID, commentID string) (*domain.Task, *domain.Comment, error) {
+	task, err := uc.loadTask(ctx, actor, taskID, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	objID, err := domain.ParseID(commentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	comment, err := uc.comments.GetByID(ctx, objID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if comment.TaskID != task.TaskID {
+		return nil, nil, domain.ErrCommentNotFound
+	}
+	if !actor.IsAdmin() && comment.AuthorID != actor.UserID {
+		return nil, nil, ErrNotCommentAuthor
+	}
+	return task, comment, nil
+}
+
+// notifyMentions notifies the users mentioned in the comment but not in
+// its previous body. Unknown emails, self-mentions and users who cannot
+// read the task are ignored, so a mention never reveals a task.
+func (uc *CommentUseCase) notifyMentions(ctx context.Context, actor domain.Actor, task *domain.Task, comment domain.Comment, previous string) error {
+	already := map[string]bool{}
+	for _, email := range domain.ParseMentions(previous) {
+		already[email] = true
+	}
+	for _, email := range domain.ParseMentions(comment.Body) {
+		if already[email] {
+			continue
+		}
+		user, err := uc.users.GetByEmail(ctx, email)
+		if errors.Is(err, domain.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if user.UserID == actor.UserID {
+			continue
+		}
+		mentioned := domain.Actor{UserID: user.UserID, Role: user.RoleIn(task.OrgID), OrgID: task.OrgID}
+		access, _, err := taskAccess(ctx, uc.projects, mentioned, task)
+		if err != nil {
+			return err
+		}
+		if access < domain.ReadAccess {
+			continue
+		}
+		_, err = uc.notifications.Create(ctx, domain.Notification{
+			OrgID:     comment.OrgID,
+			UserID:    user.UserID,
+			Kind:      domain.NotificationMentioned,
+			TaskID:    comment.TaskID,
+			ActorID:   actor.UserID,
+			CommentID: &comment.ID,
+			CreatedAt: comment.UpdatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package Usecases
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	Domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+// --- Mock CommentRepository ---
+type MockCommentRepo struct {
+	mock.Mock
+}
+
+func (m *MockCommentRepo) Create(ctx context.Context, c Domain.Comment) (*Domain.Comment, error) {
+	args := m.Called(ctx, c)
+	return args.Get(0).(*Domain.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepo) GetByID(ctx context.Context, id Domain.ID) (*Domain.Comment, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*Domain.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepo) Update(ctx context.Context, c Domain.Comment) (*Domain.Comment, error) {
+	args := m.Called(ctx, c)
+	return args.Get(0).(*Domain.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepo) Delete(ctx context.Context, id Domain.ID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockCommentRepo) Find(ctx context.Context, q Domain.CommentQuery) (*Domain.CommentPage, error) {
+	args := m.Called(ctx, q)
+	return args.Get(0).(*Domain.CommentPage), args.Error(1)
+}
+
+// --- Mock NotificationRepository ---
+type MockNotificationRepo struct {
+	mock.Mock
+}
+
+func (m *MockNotificationRepo) Create(ctx context.Context, n Domain.Notification) (*Domain.Notification, error) {
+	args := m.Called(ctx, n)
+	return args.Get(0).(*Domain.Notification), args.Error(1)
+}
+
+func (m *MockNotificationRepo) Find(ctx context.Context, q Domain.NotificationQuery) (*Domain.NotificationPage, error) {
+	args := m.Called(ctx, q)
+	return args.Get(0).(*Domain.NotificationPage), args.Error(1)
+}
+
+func (m *MockNotificationRepo) MarkRead(ctx context.Context, user, id Domain.ID, at time.Time) (*Domain.Notification, error) {
+	args := m.Called(ctx, user, id, at)
+	return args.Get(0).(*Domain.Notification), args.Error(1)
+}
+
+type commentFixture struct {
+	uc            *CommentUseCase
+	tasks         *MockTaskRepo
+	comments      *MockCommentRepo
+	users         *MockUserRepo
+	notifications *MockNotificationRepo
+	task          *Domain.Task
+	now           time.Time
+}
+
+// newCommentFixture wires a CommentUseCase over mocks, with a live task of
+// owner that is returned by the task repository.
+func newCommentFixture() *commentFixture {
+	f := &commentFixture{
+		tasks:         new(MockTaskRepo),
+		comments:      new(MockCommentRepo),
+		users:         new(MockUserRepo),
+		notifications: new(MockNotificationRepo),
+		task:          &Domain.Task{TaskID: Domain.NewID(), OwnerID: owner.UserID},
+		now:           time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC),
+	}
+	f.uc = NewCommentUseCase(f.comments, f.tasks, nil, f.users, f.notifications)
+	f.uc.now = func() time.Time { return f.now }
+	f.tasks.On("GetByID", mock.Anything, f.task.TaskID).Return(f.task, nil)
+	return f
+}
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		body string
+		want []string
+	}{
+		{"no mentions here", nil},
+		{"@ada@example.com please look", []string{"ada@example.com"}},
+		{"cc @ada@example.com, @bob@example.org.", []string{"ada@example.com", "bob@example.org"}},
+		{"(@ada@example.com) and again @ada@example.com", []string{"ada@example.com"}},
+		{"mail ada@example.com or x@ada@example.com", nil},
+		{"@not-an-email and @@ada@example.com", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			assert.Equal(t, tt.want, Domain.ParseMentions(tt.body))
+		})
+	}
+}
+
+func TestAddComment_NotifiesMentionedUsers(t *testing.T) {
+	f := newCommentFixture()
+	bob := &Domain.User{UserID: Domain.NewID(), Email: "bob@example.com"}
+	self := &Domain.User{UserID: owner.UserID, Email: "me@example.com"}
+	body := "@bob@example.com @me@example.com @ghost@example.com ready for review"
+	created := &Domain.Comment{ID: Domain.NewID(), TaskID: f.task.TaskID, AuthorID: owner.UserID, Body: body, CreatedAt: f.now, UpdatedAt: f.now}
+	f.task.Watchers = []Domain.ID{bob.UserID}
+
+	f.comments.On("Create", mock.Anything, Domain.Comment{
+		TaskID: f.task.TaskID, AuthorID: owner.UserID, Body: body, CreatedAt: f.now, UpdatedAt: f.now,
+	}).Return(created, nil)
+	f.users.On("GetByEmail", mock.Anything, "bob@example.com").Return(bob, nil)
+	f.users.On("GetByEmail", mock.Anything, "me@example.com").Return(self, nil)
+	f.users.On("GetByEmail", mock.Anything, "ghost@example.com").Return(nil, Domain.ErrUserNotFound)
+	f.notifications.On("Create", mock.Anything, Domain.Notification{
+		UserID: bob.UserID, Kind: Domain.NotificationMentioned, TaskID: f.task.TaskID,
+		ActorID: owner.UserID, CommentID: &created.ID, CreatedAt: f.now,
+	}).Return(&Domain.Notification{}, nil).Once()
+
+	res, err := f.uc.AddComment(ctx, owner, f.task.TaskID.String(), "  "+body+"\n")
+	require.NoError(t, err)
+	assert.Equal(t, created, res)
+	f.notifications.AssertExpectations(t)
+}
+
+func TestAddComment_Validation(t *testing.T) {
+	f := newCommentFixture()
+
+	_, err := f.uc.AddComment(ctx, owner, f.task.TaskID.String(), "   ")
+	assert.ErrorIs(t, err, Domain.ErrValidation)
+
+	stranger := Domain.Actor{UserID: Domain.NewID(), Role: "user"}
+	_, err = f.uc.AddComment(ctx, stranger, f.task.TaskID.String(), "hi")
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	f.comments.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestUpdateComment_KeepsEditsAndNotifiesNewMentions(t *testing.T) {
+	f := newCommentFixture()
+	created := f.now.Add(-time.Hour)
+	bob := &Domain.User{UserID: Domain.NewID(), Email: "bob@example.com"}
+	carol := &Domain.User{UserID: Domain.NewID(), Email: "carol@example.com"}
+	f.task.Assignees = []Domain.ID{carol.UserID}
+	comment := &Domain.Comment{
+		ID: Domain.NewID(), TaskID: f.task.TaskID, AuthorID: owner.UserID,
+		Body: "ask @bob@example.com", CreatedAt: created, UpdatedAt: created,
+	}
+	want := Domain.Comment{
+		ID: comment.ID, TaskID: f.task.TaskID, AuthorID: owner.UserID,
+		Body: "ask @bob@example.com and @carol@example.com", CreatedAt: created, UpdatedAt: f.now,
+		Edits: []Domain.CommentEdit{{Body: "ask @bob@example.com", EditedAt: f.now}},
+	}
+	f.comments.On("GetByID", mock.Anything, comment.ID).Return(comment, nil)
+	f.comments.On("Update", mock.Anything, want).Return(&want, nil)
+	f.users.On("GetByEmail", mock.Anything, "carol@example.com").Return(carol, nil)
+	f.notifications.On("Create", mock.Anything, mock.MatchedBy(func(n Domain.Notification) bool {
+		return n.UserID == carol.UserID
+	})).Return(&Domain.Notification{}, nil).Once()
+
+	res, err := f.uc.UpdateComment(ctx, owner, f.task.TaskID.String(), comment.ID.String(), want.Body)
+	require.NoError(t, err)
+	assert.Equal(t, &want, res)
+	f.users.AssertNotCalled(t, "GetByEmail", mock.Anything, bob.Email)
+	f.notifications.AssertExpectations(t)
+}
+
+func TestAddComment_MentionsNeedTaskAccess(t *testing.T) {
+	f := newCommentFixture()
+	project, projects := newProject()
+	f.uc.projects = projects
+	f.task.ProjectID = &project.ID
+	member := &Domain.User{UserID: viewer.UserID, Email: "viewer@example.com"}
+	outsider := &Domain.User{UserID: Domain.NewID(), Email: "outsider@example.com"}
+	body := "@viewer@example.com @outsider@example.com please check"
+	created := &Domain.Comment{ID: Domain.NewID(), TaskID: f.task.TaskID, AuthorID: owner.UserID, Body: body, CreatedAt: f.now, UpdatedAt: f.now}
+
+	f.comments.On("Create", mock.Anything, mock.Anything).Return(created, nil)
+	f.users.On("GetByEmail", mock.Anything, member.Email).Return(member, nil)
+	f.users.On("GetByEmail", mock.Anything, outsider.Email).Return(outsider, nil)
+	f.notifications.On("Create", mock.Anything, mock.MatchedBy(func(n Domain.Notification) bool {
+		return n.UserID == member.UserID
+	})).Return(&Domain.Notification{}, nil).Once()
+
+	_, err := f.uc.AddComment(ctx, owner, f.task.TaskID.String(), body)
+	require.NoError(t, err)
+	f.notifications.AssertExpectations(t)
+	f.notifications.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestUpdateComment_OnlyAuthorOrAdmin(t *testing.T) {
+	f := newCommentFixture()
+	other := Domain.NewID()
+	f.task.OwnerID = other
+	comment := &Domain.Comment{ID: Domain.NewID(), TaskID: f.task.TaskID, AuthorID: other, Body: "mine"}
+	f.comments.On("GetByID", mock.Anything, comment.ID).Return(comment, nil)
+
+	_, err := f.uc.UpdateComment(ctx, owner, f.task.TaskID.String(), comment.ID.String(), "yours")
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound, "the task is not the actor's")
+
+	f.task.OwnerID = owner.UserID
+	_, err = f.uc.UpdateComment(ctx, owner, f.task.TaskID.String(), comment.ID.String(), "yours")
+	assert.ErrorIs(t, err, ErrNotCommentAuthor)
+	err = f.uc.DeleteComment(ctx, owner, f.task.TaskID.String(), comment.ID.String())
+	assert.ErrorIs(t, err, ErrNotCommentAuthor)
+
+	f.comments.On("Delete", mock.Anything, comment.ID).Return(nil)
+	require.NoError(t, f.uc.DeleteComment(ctx, admin, f.task.TaskID.String(), comment.ID.String()))
+	f.comments.AssertNumberOfCalls(t, "Delete", 1)
+}
+
+func TestDeleteComment_OfAnotherTask(t *testing.T) {
+	f := newCommentFixture()
+	comment := &Domain.Comment{ID: Domain.NewID(), TaskID: Domain.NewID(), AuthorID: owner.UserID}
+	f.comments.On("GetByID", mock.Anything, comment.ID).Return(comment, nil)
+
+	err := f.uc.DeleteComment(ctx, owner, f.task.TaskID.String(), comment.ID.String())
+	assert.ErrorIs(t, err, Domain.ErrCommentNotFound)
+	f.comments.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestGetNotifications_ScopedToActor(t *testing.T) {
+	repo := new(MockNotificationRepo)
+	uc := NewNotificationUseCase(repo)
+	page := &Domain.NotificationPage{}
+	repo.On("Find", mock.Anything, Domain.NotificationQuery{UserID: owner.UserID, Unread: true, Limit: DefaultTaskLimit}).Return(page, nil)
+
+	res, err := uc.GetNotifications(ctx, owner, Domain.NotificationQuery{UserID: admin.UserID, Unread: true})
+	require.NoError(t, err)
+	assert.Same(t, page, res)
+
+	_, err = uc.GetNotifications(ctx, owner, Domain.NotificationQuery{Cursor: "garbage"})
+	assert.ErrorIs(t, err, Domain.ErrInvalidCursor)
+}
@@ -0,0 +1,274 @@
+package Usecases
+
+import (
+	"context"
+	"errors"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+var (
+	ErrInvalidDependency     = domain.NewError(domain.ErrValidation, "invalid_dependency", "dependency task not found")
+	ErrDependencyNotFound    = domain.NewError(domain.ErrNotFound, "dependency_not_found", "task does not depend on that task")
+	ErrBlockedByDependencies = domain.NewError(domain.ErrConflict, "blocked_by_dependencies", "task is blocked by unfinished dependencies")
+)
+
+// TaskDependencies are the tasks a task waits for and the tasks waiting
+// for it.
+type TaskDependencies struct {
+	BlockedBy []domain.Task
+	Blocking  []domain.Task
+}
+
+// GetDependencies lists both sides of a task's dependencies, leaving out
+// tasks the actor cannot read and tasks in the trash. Only the oldest
+// MaxTaskLimit dependents are looked at for the blocking side.
+func (u *TaskUseCase) GetDependencies(ctx context.Context, actor domain.Actor, id string) (*TaskDependencies, error) {
+	objID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+	task, err := u.load(ctx, actor, objID)
+	if err != nil {
+		return nil, err
+	}
+	deps := &TaskDependencies{BlockedBy: []domain.Task{}}
+	for _, depID := range task.DependsOn {
+		dep, err := u.load(ctx, actor, depID)
+		if errors.Is(err, domain.ErrTaskNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		deps.BlockedBy = append(deps.BlockedBy, *dep)
+	}
+	page, err := u.repo.Find(ctx, domain.TaskQuery{DependsOn: &objID, SortBy: domain.SortByCreatedAt, Limit: MaxTaskLimit})
+	if err != nil {
+		return nil, err
+	}
+	deps.Blocking = []domain.Task{}
+	for _, t := range page.Tasks {
+		ok, err := u.canRead(ctx, actor, &t)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			deps.Blocking = append(deps.Blocking, t)
+		}
+	}
+	return deps, nil
+}
+
+// AddDependency makes task id wait for task dependsOn, which the actor
+// must also be able to access. An edge that would close a cycle is
+// rejected. While dependsOn is unfinished an open task is moved to Blocked.
+// Adding an existing dependency changes nothing.
+func (u *TaskUseCase) AddDependency(ctx context.Context, actor domain.Actor, id, dependsOn string) (*domain.Task, error) {
+	objID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+	depID, err := domain.ParseID(dependsOn)
+	if err != nil {
+		return nil, err
+	}
+	task, err := u.loadForEdit(ctx, actor, objID, 0)
+	if err != nil {
+		return nil, err
+	}
+	dep, err := u.load(ctx, actor, depID)
+	if errors.Is(err, domain.ErrTaskNotFound) {
+		return nil, ErrInvalidDependency
+	}
+	if err != nil {
+		return nil, err
+	}
+	if task.HasDependency(depID) {
+		return task, nil
+	}
+	if depID == objID {
+		return nil, domain.ErrDependencyCycle
+	}
+	cycle, err := u.reaches(ctx, *dep, objID)
+	if err != nil {
+		return nil, err
+	}
+	if cycle {
+		return nil, domain.ErrDependencyCycle
+	}
+
+	before := *task
+	task.DependsOn = append(append([]domain.ID(nil), task.DependsOn...), depID)
+	if !dep.Finished() && task.Status.IsOpen() {
+		u.applyStatus(task, domain.StatusBlocked)
+	}
+	return u.save(ctx, actor, objID, domain.HistoryUpdated, 0, before, *task)
+}
+
+// RemoveDependency drops the edge from task id to dependsOn. A Blocked
+// task left without unfinished dependencies goes back to Pending.
+func (u *TaskUseCase) RemoveDependency(ctx context.Context, actor domain.Actor, id, dependsOn string) (*domain.Task, error) {
+	objID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+	depID, err := domain.ParseID(dependsOn)
+	if err != nil {
+		return nil, err
+	}
+	task, err := u.loadForEdit(ctx, actor, objID, 0)
+	if err != nil {
+		return nil, err
+	}
+	if !task.HasDependency(depID) {
+		return nil, ErrDependencyNotFound
+	}
+
+	before := *task
+	task.DependsOn = nil
+	for _, d := range before.DependsOn {
+		if d != depID {
+			task.DependsOn = append(task.DependsOn, d)
+		}
+	}
+	if task.Status == domain.StatusBlocked {
+		waiting, err := u.waitingOnDependencies(ctx, *task)
+		if err != nil {
+			return nil, err
+		}
+		if !waiting {
+			u.applyStatus(task, domain.StatusPending)
+		}
+	}
+	return u.save(ctx, actor, objID, domain.HistoryUpdated, 0, before, *task)
+}
+
+// PlanTasks orders the given tasks so that each comes after the tasks it
+// depends on. Unfinished dependencies the actor can access are pulled into
+// the plan even when they were not asked for, since the plan cannot be
+// carried out without them.
+func (u *TaskUseCase) PlanTasks(ctx context.Context, actor domain.Actor, ids []string) ([]domain.Task, error) {
+	seen := map[domain.ID]bool{}
+	var tasks []domain.Task
+	for _, raw := range ids {
+		id, err := domain.ParseID(raw)
+		if err != nil {
+			return nil, err
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		task, err := u.load(ctx, actor, id)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, *task)
+	}
+	for i := 0; i < len(tasks); i++ {
+		for _, depID := range tasks[i].DependsOn {
+			if seen[depID] {
+				continue
+			}
+			seen[depID] = true
+			dep, err := u.load(ctx, actor, depID)
+			if errors.Is(err, domain.ErrTaskNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if !dep.Finished() {
+				tasks = append(tasks, *dep)
+			}
+		}
+	}
+	return domain.PlanTasks(tasks)
+}
+
+// reaches reports whether target is among the direct or indirect
+// dependencies of from.
+func (u *TaskUseCase) reaches(ctx context.Context, from domain.Task, target domain.ID) (bool, error) {
+	seen := map[domain.ID]bool{from.TaskID: true}
+	stack := []domain.Task{from}
+	for len(stack) > 0 {
+		t := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, depID := range t.DependsOn {
+			if depID == target {
+				return true, nil
+			}
+			if seen[depID] {
+				continue
+			}
+			seen[depID] = true
+			dep, err := u.repo.GetByID(ctx, depID)
+			if errors.Is(err, domain.ErrTaskNotFound) {
+				continue
+			}
+			if err != nil {
+				return false, err
+			}
+			stack = append(stack, *dep)
+		}
+	}
+	return false, nil
+}
+
+// waitingOnDependencies reports whether any task t depends on is still
+// unfinished. Purged dependencies no longer count.
+func (u *TaskUseCase) waitingOnDependencies(ctx context.Context, t domain.Task) (bool, error) {
+	for _, depID := range t.DependsOn {
+		dep, err := u.repo.GetByID(ctx, depID)
+		if errors.Is(err, domain.ErrTaskNotFound) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		if !dep.Finished() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// updateDependents is called after task id became finished or unfinished.
+// It moves each open task depending on it to Blocked while some dependency
+// is unfinished, and back to Pending once none is. These moves are made on
+// the actor's behalf and bypass the transition table.
+func (u *TaskUseCase) updateDependents(ctx context.Context, actor domain.Actor, id domain.ID) error {
+	page, err := u.repo.Find(ctx, domain.TaskQuery{DependsOn: &id})
+	if err != nil {
+		return err
+	}
+	for _, task := range page.Tasks {
+		if task.Finished() {
+			continue
+		}
+		waiting, err := u.waitingOnDependencies(ctx, task)
+		if err != nil {
+			return err
+		}
+		var status domain.TaskStatus
+		switch {
+		case waiting && task.Status != domain.StatusBlocked:
+			status = domain.StatusBlocked
+		case !waiting && task.Status == domain.StatusBlocked:
+			status = domain.StatusPending
+		default:
+			continue
+		}
+		before := task
+		u.applyStatus(&task, status)
+		saved, err := u.repo.Update(ctx, task.TaskID, task)
+		if err != nil {
+			return err
+		}
+		if err := u.record(ctx, actor, task.TaskID, domain.HistoryTransitioned, before, *saved); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package Usecases
+
+import (
+	"context"
+	"time"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+type NotificationUseCaseInterface interface {
+	GetNotifications(ctx context.Context, actor domain.Actor, q domain.NotificationQuery) (*domain.NotificationPage, error)
+	MarkNotificationRead(ctx context.Context, actor domain.Actor, id string) (*domain.Notification, error)
+}
+
+// NotificationUseCase lets users read their own notifications.
+type NotificationUseCase struct {
+	repo domain.NotificationRepository
+	now  func() time.Time
+}
+
+func NewNotificationUseCase(r domain.NotificationRepository) *NotificationUseCase {
+	return &NotificationUseCase{repo: r, now: time.Now}
+}
+
+// GetNotifications returns one page of the actor's notifications, newest
+// first. Admins only see their own as well.
+func (uc *NotificationUseCase) GetNotifications(ctx context.Context, actor domain.Actor, q domain.NotificationQuery) (*domain.NotificationPage, error) {
+	if q.Cursor != "" {
+		if _, _, err := domain.DecodeNotificationCursor(q.Cursor); err != nil {
+			return nil, err
+		}
+	}
+	q.UserID = actor.UserID
+	q.Limit = pageLimit(q.Limit)
+	return uc.repo.Find(ctx, q)
+}
+
+// MarkNotificationRead marks one of the actor's notifications as read.
+func (uc *NotificationUseCase) MarkNotificationRead(ctx context.Context, actor domain.Actor, id string) (*domain.Notification, error) {
+	objID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+	return uc.repo.MarkRead(ctx, actor.UserID, objID, uc.now())
+}
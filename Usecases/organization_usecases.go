@@ -0,0 +1,131 @@
+package Usecases
+
+import (
+	"context"
+	"strings"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+var (
+	ErrOrganizationOwnerRequired = domain.NewError(domain.ErrForbidden, "organization_owner_required", "only the organization's owner can do that")
+	ErrOrganizationOwnerLeaving  = domain.NewError(domain.ErrConflict, "organization_owner_leaving", "the owner cannot leave the organization")
+)
+
+type OrganizationUseCaseInterface interface {
+	GetOrganizations(ctx context.Context, actor domain.Actor) ([]domain.Organization, error)
+	GetOrganization(ctx context.Context, actor domain.Actor, id string) (*domain.Organization, error)
+	CreateOrganization(ctx context.Context, actor domain.Actor, o domain.Organization) (*domain.Organization, error)
+	GetMembers(ctx context.Context, actor domain.Actor, id string) ([]*domain.User, error)
+	AddMember(ctx context.Context, actor domain.Actor, id, email string) (*domain.User, error)
+	RemoveMember(ctx context.Context, actor domain.Actor, id, userID string) error
+}
+
+// OrganizationUseCase manages organizations and who belongs to them.
+// Members may see an organization and its members; only its owner may
+// change them. Everyone else gets domain.ErrOrganizationNotFound, admins
+// included, as for a missing organization.
+type OrganizationUseCase struct {
+	orgs  domain.OrganizationRepository
+	users domain.UserRepository
+}
+
+func NewOrganizationUseCase(o domain.OrganizationRepository, u domain.UserRepository) *OrganizationUseCase {
+	return &OrganizationUseCase{orgs: o, users: u}
+}
+
+// GetOrganizations lists the organizations the actor belongs to.
+func (uc *OrganizationUseCase) GetOrganizations(ctx context.Context, actor domain.Actor) ([]domain.Organization, error) {
+	user, err := uc.users.GetByID(domain.WithoutTenant(ctx), actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.orgs.GetByIDs(ctx, user.OrgIDs)
+}
+
+func (uc *OrganizationUseCase) GetOrganization(ctx context.Context, actor domain.Actor, id string) (*domain.Organization, error) {
+	return uc.load(ctx, actor, id)
+}
+
+// CreateOrganization creates an organization owned by the actor, who
+// becomes its first member.
+func (uc *OrganizationUseCase) CreateOrganization(ctx context.Context, actor domain.Actor, o domain.Organization) (*domain.Organization, error) {
+	org := domain.Organization{OwnerID: actor.UserID, Name: strings.TrimSpace(o.Name)}
+	if err := org.Validate(); err != nil {
+		return nil, err
+	}
+	created, err := uc.orgs.Create(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := uc.users.AddOrganization(domain.WithoutTenant(ctx), actor.UserID, created.ID); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// GetMembers lists the users of an organization the actor belongs to.
+func (uc *OrganizationUseCase) GetMembers(ctx context.Context, actor domain.Actor, id string) ([]*domain.User, error) {
+	org, err := uc.load(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	return uc.users.GetAll(domain.WithTenant(ctx, org.ID))
+}
+
+// AddMember adds the user registered with email to an organization. Only
+// its owner may add members; adding a member again changes nothing.
+func (uc *OrganizationUseCase) AddMember(ctx context.Context, actor domain.Actor, id, email string) (*domain.User, error) {
+	org, err := uc.load(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if org.OwnerID != actor.UserID {
+		return nil, ErrOrganizationOwnerRequired
+	}
+	// the user is not in the organization yet, so look them up in all
+	user, err := uc.users.GetByEmail(domain.WithoutTenant(ctx), strings.TrimSpace(email))
+	if err != nil {
+		return nil, err
+	}
+	return uc.users.AddOrganization(domain.WithoutTenant(ctx), user.UserID, org.ID)
+}
+
+// RemoveMember takes a user out of an organization. Its owner may remove
+// anyone but themselves; other members may only leave. The removed user's
+// access tokens for the organization stop working at once, as
+// AuthMiddleware checks membership on every request.
+func (uc *OrganizationUseCase) RemoveMember(ctx context.Context, actor domain.Actor, id, userID string) error {
+	user, err := domain.ParseID(userID)
+	if err != nil {
+		return err
+	}
+	org, err := uc.load(ctx, actor, id)
+	if err != nil {
+		return err
+	}
+	if user == org.OwnerID {
+		return ErrOrganizationOwnerLeaving
+	}
+	if user != actor.UserID && org.OwnerID != actor.UserID {
+		return ErrOrganizationOwnerRequired
+	}
+	_, err = uc.users.RemoveOrganization(domain.WithTenant(ctx, org.ID), user, org.ID)
+	return err
+}
+
+// load returns an organization the actor belongs to.
+func (uc *OrganizationUseCase) load(ctx context.Context, actor domain.Actor, id string) (*domain.Organization, error) {
+	orgID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+	user, err := uc.users.GetByID(domain.WithoutTenant(ctx), actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.InOrganization(orgID) {
+		return nil, domain.ErrOrganizationNotFound
+	}
+	return uc.orgs.GetByID(ctx, orgID)
+}
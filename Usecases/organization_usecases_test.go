@@ -0,0 +1,135 @@
+package Usecases
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	Domain "github.com/surafelbkassa/go-task-manager/Domain"
+	"github.com/surafelbkassa/go-task-manager/Repositories"
+)
+
+type orgFixture struct {
+	uc           *OrganizationUseCase
+	users        *Repositories.InMemoryUserRepository
+	owner, other Domain.Actor
+}
+
+// newOrgFixture registers two users, each in their personal organization.
+func newOrgFixture(t *testing.T) *orgFixture {
+	users := Repositories.NewInMemoryUserRepository()
+	orgs := Repositories.NewInMemoryOrganizationRepository()
+	f := &orgFixture{uc: NewOrganizationUseCase(orgs, users), users: users}
+	for _, a := range []*Domain.Actor{&f.owner, &f.other} {
+		u, err := users.Create(ctx, Domain.User{Email: Domain.NewID().String() + "@x.io"})
+		require.NoError(t, err)
+		_, err = orgs.Create(ctx, Domain.Organization{ID: u.UserID, OwnerID: u.UserID, Name: "personal"})
+		require.NoError(t, err)
+		_, err = users.AddOrganization(ctx, u.UserID, u.UserID)
+		require.NoError(t, err)
+		*a = Domain.Actor{UserID: u.UserID, Role: "user", OrgID: u.UserID}
+	}
+	return f
+}
+
+func TestCreateOrganization(t *testing.T) {
+	f := newOrgFixture(t)
+
+	_, err := f.uc.CreateOrganization(ctx, f.owner, Domain.Organization{Name: "  "})
+	var ve *Domain.ValidationError
+	require.ErrorAs(t, err, &ve)
+
+	org, err := f.uc.CreateOrganization(Domain.WithTenant(ctx, f.owner.OrgID), f.owner, Domain.Organization{Name: " Acme "})
+	require.NoError(t, err)
+	assert.Equal(t, "Acme", org.Name)
+	assert.Equal(t, f.owner.UserID, org.OwnerID)
+	assert.False(t, org.Personal())
+
+	orgs, err := f.uc.GetOrganizations(ctx, f.owner)
+	require.NoError(t, err)
+	assert.Len(t, orgs, 2)
+
+	_, err = f.uc.GetOrganization(ctx, f.other, org.ID.String())
+	assert.ErrorIs(t, err, Domain.ErrOrganizationNotFound, "non-members cannot see it")
+}
+
+func TestOrganizationMembers(t *testing.T) {
+	f := newOrgFixture(t)
+	org, err := f.uc.CreateOrganization(ctx, f.owner, Domain.Organization{Name: "Acme"})
+	require.NoError(t, err)
+	id := org.ID.String()
+	other, err := f.users.GetByID(ctx, f.other.UserID)
+	require.NoError(t, err)
+
+	_, err = f.uc.AddMember(ctx, f.other, id, other.Email)
+	assert.ErrorIs(t, err, Domain.ErrOrganizationNotFound)
+	_, err = f.uc.AddMember(ctx, f.owner, id, "nobody@x.io")
+	assert.ErrorIs(t, err, Domain.ErrUserNotFound)
+
+	// the owner's current tenant must not hide users of other organizations
+	added, err := f.uc.AddMember(Domain.WithTenant(ctx, f.owner.OrgID), f.owner, id, other.Email)
+	require.NoError(t, err)
+	assert.True(t, added.InOrganization(org.ID))
+
+	members, err := f.uc.GetMembers(ctx, f.other, id)
+	require.NoError(t, err)
+	assert.Len(t, members, 2)
+
+	assert.ErrorIs(t, f.uc.RemoveMember(ctx, f.other, id, f.owner.UserID.String()), ErrOrganizationOwnerLeaving)
+	assert.ErrorIs(t, f.uc.RemoveMember(ctx, f.owner, id, f.owner.UserID.String()), ErrOrganizationOwnerLeaving)
+	require.NoError(t, f.uc.RemoveMember(ctx, f.other, id, f.other.UserID.String()), "members may leave")
+
+	members, err = f.uc.GetMembers(ctx, f.owner, id)
+	require.NoError(t, err)
+	assert.Len(t, members, 1)
+	_, err = f.uc.GetMembers(ctx, f.other, id)
+	assert.ErrorIs(t, err, Domain.ErrOrganizationNotFound)
+}
+
+func TestRemoveMember_OwnerOnly(t *testing.T) {
+	f := newOrgFixture(t)
+	org, err := f.uc.CreateOrganization(ctx, f.owner, Domain.Organization{Name: "Acme"})
+	require.NoError(t, err)
+	third, err := f.users.Create(ctx, Domain.User{Email: "c@x.io"})
+	require.NoError(t, err)
+	for _, u := range []Domain.ID{f.other.UserID, third.UserID} {
+		_, err = f.users.AddOrganization(ctx, u, org.ID)
+		require.NoError(t, err)
+	}
+
+	err = f.uc.RemoveMember(ctx, f.other, org.ID.String(), third.UserID.String())
+	assert.ErrorIs(t, err, ErrOrganizationOwnerRequired)
+	require.NoError(t, f.uc.RemoveMember(ctx, f.owner, org.ID.String(), third.UserID.String()))
+
+	// users outside the organization are not found in it
+	err = f.uc.RemoveMember(ctx, f.owner, org.ID.String(), third.UserID.String())
+	assert.ErrorIs(t, err, Domain.ErrUserNotFound)
+}
+
+func TestPurgeExpiredTrash_AuditedInOwningOrganization(t *testing.T) {
+	history := Repositories.NewInMemoryHistoryRepository()
+	uc := NewTaskUseCase(Repositories.NewInMemoryTaskRepository(), WithHistory(history))
+	inAcme := Domain.WithTenant(context.Background(), Domain.NewID())
+	inGlobex := Domain.WithTenant(context.Background(), Domain.NewID())
+
+	task, err := uc.CreateTask(inAcme, owner, Domain.Task{Title: "old", DueDate: time.Now().Add(24 * time.Hour)})
+	require.NoError(t, err)
+	require.NoError(t, uc.DeleteTask(inAcme, owner, task.TaskID.String(), 0))
+
+	uc.now = func() time.Time { return time.Now().Add(time.Hour) }
+	n, err := uc.PurgeExpiredTrash(Domain.WithoutTenant(context.Background()), time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	log, err := uc.AuditLog(inAcme, admin, Domain.HistoryQuery{})
+	require.NoError(t, err)
+	require.NotEmpty(t, log.Entries)
+	last := log.Entries[len(log.Entries)-1]
+	assert.Equal(t, Domain.HistoryPurged, last.Action)
+	assert.Equal(t, task.TaskID, last.TaskID)
+	log, err = uc.AuditLog(inGlobex, admin, Domain.HistoryQuery{})
+	require.NoError(t, err)
+	assert.Empty(t, log.Entries)
+}
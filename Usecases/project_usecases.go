@@ -0,0 +1,292 @@
+package Usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+var (
+	ErrInvalidProject       = domain.NewError(domain.ErrValidation, "invalid_project", "project not found")
+	ErrInvalidMember        = domain.NewError(domain.ErrValidation, "invalid_member", "member is not a user")
+	ErrInvalidProjectRole   = domain.NewError(domain.ErrValidation, "invalid_project_role", "role must be viewer, editor or maintainer")
+	ErrProjectRoleRequired  = domain.NewError(domain.ErrForbidden, "project_role_required", "your role in the project does not allow that")
+	ErrProjectOwnerRequired = domain.NewError(domain.ErrForbidden, "project_owner_required", "only the project's owner or an admin can do that")
+	ErrProjectArchived      = domain.NewError(domain.ErrConflict, "project_archived", "the project is archived and its tasks are read-only")
+	ErrProjectNotEmpty      = domain.NewError(domain.ErrConflict, "project_not_empty", "the project still has tasks")
+)
+
+type ProjectUseCaseInterface interface {
+	GetProjects(ctx context.Context, actor domain.Actor, q domain.ProjectQuery) (*domain.ProjectPage, error)
+	GetProject(ctx context.Context, actor domain.Actor, id string) (*domain.Project, error)
+	CreateProject(ctx context.Context, actor domain.Actor, p domain.Project) (*domain.Project, error)
+	UpdateProject(ctx context.Context, actor domain.Actor, id string, p domain.Project) (*domain.Project, error)
+	DeleteProject(ctx context.Context, actor domain.Actor, id string) error
+	ArchiveProject(ctx context.Context, actor domain.Actor, id string, archived bool) (*domain.Project, error)
+	SetMember(ctx context.Context, actor domain.Actor, id, userID, role string) (*domain.Project, error)
+	RemoveMember(ctx context.Context, actor domain.Actor, id, userID string) (*domain.Project, error)
+}
+
+// ProjectUseCase manages projects and their members. Members see a
+// project according to their role; everyone else gets
+// domain.ErrProjectNotFound, as for a missing project.
+type ProjectUseCase struct {
+	projects domain.ProjectRepository
+	tasks    domain.TaskRepository
+	users    domain.UserRepository
+	now      func() time.Time
+}
+
+func NewProjectUseCase(p domain.ProjectRepository, t domain.TaskRepository, u domain.UserRepository) *ProjectUseCase {
+	return &ProjectUseCase{projects: p, tasks: t, users: u, now: time.Now}
+}
+
+// GetProjects returns one page of the projects the actor owns or is a
+// member of, oldest first. Admins see every project.
+func (uc *ProjectUseCase) GetProjects(ctx context.Context, actor domain.Actor, q domain.ProjectQuery) (*domain.ProjectPage, error) {
+	q.MemberID = nil
+	if !actor.IsAdmin() {
+		q.MemberID = &actor.UserID
+	}
+	if q.Cursor != "" {
+		if _, _, err := domain.DecodeProjectCursor(q.Cursor); err != nil {
+			return nil, err
+		}
+	}
+	q.Limit = pageLimit(q.Limit)
+	return uc.projects.Find(ctx, q)
+}
+
+func (uc *ProjectUseCase) GetProject(ctx context.Context, actor domain.Actor, id string) (*domain.Project, error) {
+	return uc.load(ctx, actor, id, domain.ProjectViewer)
+}
+
+// CreateProject creates an empty project owned by the actor.
+func (uc *ProjectUseCase) CreateProject(ctx context.Context, actor domain.Actor, p domain.Project) (*domain.Project, error) {
+	p.Name = strings.TrimSpace(p.Name)
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	now := uc.now()
+	project := domain.Project{
+		OwnerID:     actor.UserID,
+		Name:        p.Name,
+		Description: p.Description,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	return uc.projects.Create(ctx, project)
+}
+
+// UpdateProject replaces the name and description of a project. Only
+// maintainers may change a project.
+func (uc *ProjectUseCase) UpdateProject(ctx context.Context, actor domain.Actor, id string, p domain.Project) (*domain.Project, error) {
+	project, err := uc.load(ctx, actor, id, domain.ProjectMaintainer)
+	if err != nil {
+		return nil, err
+	}
+	project.Name, project.Description = strings.TrimSpace(p.Name), p.Description
+	if err := project.Validate(); err != nil {
+		return nil, err
+	}
+	project.UpdatedAt = uc.now()
+	return uc.projects.Update(ctx, *project)
+}
+
+// DeleteProject deletes a project that has no live tasks left. Only its
+// owner or an admin may delete it.
+func (uc *ProjectUseCase) DeleteProject(ctx context.Context, actor domain.Actor, id string) error {
+	project, err := uc.load(ctx, actor, id, domain.ProjectViewer)
+	if err != nil {
+		return err
+	}
+	if !actor.IsAdmin() && project.OwnerID != actor.UserID {
+		return ErrProjectOwnerRequired
+	}
+	page, err := uc.tasks.Find(ctx, domain.TaskQuery{ProjectID: &project.ID, Limit: 1})
+	if err != nil {
+		return err
+	}
+	if len(page.Tasks) > 0 {
+		return ErrProjectNotEmpty
+	}
+	return uc.projects.Delete(ctx, project.ID)
+}
+
+// ArchiveProject archives or unarchives a project. The tasks of an
+// archived project are read-only. Archiving an archived project keeps its
+// original archive time.
+func (uc *ProjectUseCase) ArchiveProject(ctx context.Context, actor domain.Actor, id string, archived bool) (*domain.Project, error) {
+	project, err := uc.load(ctx, actor, id, domain.ProjectMaintainer)
+	if err != nil {
+		return nil, err
+	}
+	if project.Archived() == archived {
+		return project, nil
+	}
+	now := uc.now()
+	project.ArchivedAt = nil
+	if archived {
+		project.ArchivedAt = &now
+	}
+	project.UpdatedAt = now
+	return uc.projects.Update(ctx, *project)
+}
+
+// SetMember adds a user to a project or changes their role. Only
+// maintainers may manage members, and the owner's role cannot change.
+func (uc *ProjectUseCase) SetMember(ctx context.Context, actor domain.Actor, id, userID, role string) (*domain.Project, error) {
+	project, err := uc.load(ctx, actor, id, domain.ProjectMaintainer)
+	if err != nil {
+		return nil, err
+	}
+	r, ok := domain.ParseProjectRole(role)
+	if !ok {
+		return nil, ErrInvalidProjectRole
+	}
+	user, err := domain.ParseID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == project.OwnerID {
+		return nil, fmt.Errorf("%w: the owner is always a maintainer", ErrInvalidMember)
+	}
+	if _, err := uc.users.GetByID(ctx, user); errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidMember, user)
+	} else if err != nil {
+		return nil, err
+	}
+	project.SetMember(user, r)
+	if err := project.Validate(); err != nil {
+		return nil, err
+	}
+	project.UpdatedAt = uc.now()
+	return uc.projects.Update(ctx, *project)
+}
+
+// RemoveMember takes a user out of a project. Maintainers may remove
+// anyone; other members may only leave.
+func (uc *ProjectUseCase) RemoveMember(ctx context.Context, actor domain.Actor, id, userID string) (*domain.Project, error) {
+	user, err := domain.ParseID(userID)
+	if err != nil {
+		return nil, err
+	}
+	need := domain.ProjectMaintainer
+	if user == actor.UserID {
+		need = domain.ProjectViewer
+	}
+	project, err := uc.load(ctx, actor, id, need)
+	if err != nil {
+		return nil, err
+	}
+	if !project.RemoveMember(user) {
+		return nil, domain.ErrUserNotFound
+	}
+	project.UpdatedAt = uc.now()
+	return uc.projects.Update(ctx, *project)
+}
+
+// load returns a project in which the actor holds at least the role
+// need. Non-members get domain.ErrProjectNotFound and members with a
+// lesser role ErrProjectRoleRequired.
+func (uc *ProjectUseCase) load(ctx context.Context, actor domain.Actor, id string, need domain.ProjectRole) (*domain.Project, error) {
+	objID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+	return loadProject(ctx, uc.projects, actor, objID, need)
+}
+
+func loadProject(ctx context.Context, projects domain.ProjectRepository, actor domain.Actor, id domain.ID, need domain.ProjectRole) (*domain.Project, error) {
+	if projects == nil {
+		return nil, domain.ErrProjectNotFound
+	}
+	project, err := projects.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	role := actor.ProjectRole(project)
+	if role == "" {
+		return nil, domain.ErrProjectNotFound
+	}
+	if !role.AtLeast(need) {
+		return nil, ErrProjectRoleRequired
+	}
+	return project, nil
+}
+
+// taskAccess returns what the actor may do with a task, taking their role
+// in its project into account, together with that project. A task whose
+// project is gone is treated as outside any project.
+func taskAccess(ctx context.Context, projects domain.ProjectRepository, actor domain.Actor, task *domain.Task) (domain.TaskAccess, *domain.Project, error) {
+	if task.ProjectID == nil || projects == nil {
+		return actor.TaskAccess(task, nil), nil, nil
+	}
+	project, err := projects.GetByID(ctx, *task.ProjectID)
+	if errors.Is(err, domain.ErrProjectNotFound) {
+		return actor.TaskAccess(task, nil), nil, nil
+	}
+	if err != nil {
+		return domain.NoAccess, nil, err
+	}
+	return actor.TaskAccess(task, project), project, nil
+}
+
+// GetProjectTasks returns one page of the live tasks of a project the
+// actor is a member of, whoever owns them.
+func (u *TaskUseCase) GetProjectTasks(ctx context.Context, actor domain.Actor, id string, q domain.TaskQuery) (*domain.TaskPage, error) {
+	objID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := loadProject(ctx, u.projects, actor, objID, domain.ProjectViewer); err != nil {
+		return nil, err
+	}
+	q.ProjectID = &objID
+	q.Trashed = false
+	return u.findTasks(ctx, q)
+}
+
+// authorize checks that the actor has at least the access need to a task
+// they can already read. Below it they get ErrOwnerRequired or
+// ErrReadOnlyTask; changes to the tasks of an archived project fail with
+// ErrProjectArchived.
+func (u *TaskUseCase) authorize(ctx context.Context, actor domain.Actor, task *domain.Task, need domain.TaskAccess) error {
+	access, project, err := taskAccess(ctx, u.projects, actor, task)
+	if err != nil {
+		return err
+	}
+	switch {
+	case project != nil && project.Archived():
+		return ErrProjectArchived
+	case access >= need:
+		return nil
+	case need == domain.OwnerAccess:
+		return ErrOwnerRequired
+	}
+	return ErrReadOnlyTask
+}
+
+// checkProject verifies that a task may be put in the project projectID:
+// the actor must be at least an editor there, and the project must not be
+// archived.
+func (u *TaskUseCase) checkProject(ctx context.Context, actor domain.Actor, projectID *domain.ID) error {
+	if projectID == nil {
+		return nil
+	}
+	project, err := loadProject(ctx, u.projects, actor, *projectID, domain.ProjectEditor)
+	if errors.Is(err, domain.ErrProjectNotFound) {
+		return ErrInvalidProject
+	}
+	if err != nil {
+		return err
+	}
+	if project.Archived() {
+		return ErrProjectArchived
+	}
+	return nil
+}
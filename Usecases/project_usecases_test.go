@@ -0,0 +1,315 @@
+package Usecases
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	Domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+type MockProjectRepo struct {
+	mock.Mock
+}
+
+func (m *MockProjectRepo) Create(ctx context.Context, p Domain.Project) (*Domain.Project, error) {
+	args := m.Called(ctx, p)
+	return args.Get(0).(*Domain.Project), args.Error(1)
+}
+
+func (m *MockProjectRepo) GetByID(ctx context.Context, id Domain.ID) (*Domain.Project, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Domain.Project), args.Error(1)
+}
+
+func (m *MockProjectRepo) Update(ctx context.Context, p Domain.Project) (*Domain.Project, error) {
+	args := m.Called(ctx, p)
+	return args.Get(0).(*Domain.Project), args.Error(1)
+}
+
+func (m *MockProjectRepo) Delete(ctx context.Context, id Domain.ID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockProjectRepo) Find(ctx context.Context, q Domain.ProjectQuery) (*Domain.ProjectPage, error) {
+	args := m.Called(ctx, q)
+	return args.Get(0).(*Domain.ProjectPage), args.Error(1)
+}
+
+var (
+	viewer     = Domain.Actor{UserID: Domain.NewID(), Role: "user"}
+	editor     = Domain.Actor{UserID: Domain.NewID(), Role: "user"}
+	maintainer = Domain.Actor{UserID: Domain.NewID(), Role: "user"}
+)
+
+// newProject returns a project of owner with a viewer, an editor and a
+// maintainer, served by a fresh mock repository.
+func newProject() (*Domain.Project, *MockProjectRepo) {
+	p := &Domain.Project{ID: Domain.NewID(), OwnerID: owner.UserID, Name: "launch"}
+	p.SetMember(viewer.UserID, Domain.ProjectViewer)
+	p.SetMember(editor.UserID, Domain.ProjectEditor)
+	p.SetMember(maintainer.UserID, Domain.ProjectMaintainer)
+	projects := new(MockProjectRepo)
+	projects.On("GetByID", mock.Anything, p.ID).Return(p, nil)
+	return p, projects
+}
+
+func TestActorTaskAccess(t *testing.T) {
+	project, _ := newProject()
+	task := &Domain.Task{OwnerID: Domain.NewID(), ProjectID: &project.ID, Watchers: []Domain.ID{editor.UserID}}
+	tests := []struct {
+		name    string
+		actor   Domain.Actor
+		project *Domain.Project
+		want    Domain.TaskAccess
+	}{
+		{"stranger", Domain.Actor{UserID: Domain.NewID()}, project, Domain.NoAccess},
+		{"viewer", viewer, project, Domain.ReadAccess},
+		{"editor who watches", editor, project, Domain.EditAccess},
+		{"watcher outside the project", editor, nil, Domain.ReadAccess},
+		{"maintainer", maintainer, project, Domain.OwnerAccess},
+		{"project owner", owner, project, Domain.OwnerAccess},
+		{"admin", admin, nil, Domain.OwnerAccess},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.actor.TaskAccess(task, tt.project))
+		})
+	}
+}
+
+func TestCreateProject_OwnedByActor(t *testing.T) {
+	projects := new(MockProjectRepo)
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	uc := NewProjectUseCase(projects, new(MockTaskRepo), new(MockUserRepo))
+	uc.now = func() time.Time { return now }
+
+	want := Domain.Project{OwnerID: owner.UserID, Name: "launch", Description: "d", CreatedAt: now, UpdatedAt: now}
+	projects.On("Create", mock.Anything, want).Return(&want, nil)
+
+	_, err := uc.CreateProject(ctx, owner, Domain.Project{Name: " launch ", Description: "d", OwnerID: admin.UserID})
+	require.NoError(t, err)
+	projects.AssertExpectations(t)
+
+	_, err = uc.CreateProject(ctx, owner, Domain.Project{Name: " "})
+	assert.ErrorIs(t, err, Domain.ErrValidation)
+}
+
+func TestSetMember_Roles(t *testing.T) {
+	project, projects := newProject()
+	users := new(MockUserRepo)
+	uc := NewProjectUseCase(projects, new(MockTaskRepo), users)
+	carol, ghost := Domain.NewID(), Domain.NewID()
+	users.On("GetByID", mock.Anything, carol).Return(&Domain.User{UserID: carol}, nil)
+	users.On("GetByID", mock.Anything, ghost).Return(nil, Domain.ErrUserNotFound)
+	projects.On("Update", mock.Anything, mock.MatchedBy(func(p Domain.Project) bool {
+		return p.RoleOf(carol) == Domain.ProjectEditor
+	})).Return(project, nil).Once()
+	id := project.ID.String()
+
+	_, err := uc.SetMember(ctx, editor, id, carol.String(), "viewer")
+	assert.ErrorIs(t, err, ErrProjectRoleRequired)
+	_, err = uc.SetMember(ctx, Domain.Actor{UserID: carol}, id, carol.String(), "viewer")
+	assert.ErrorIs(t, err, Domain.ErrProjectNotFound, "non-members do not see the project")
+	_, err = uc.SetMember(ctx, maintainer, id, carol.String(), "owner")
+	assert.ErrorIs(t, err, ErrInvalidProjectRole)
+	_, err = uc.SetMember(ctx, maintainer, id, ghost.String(), "viewer")
+	assert.ErrorIs(t, err, ErrInvalidMember)
+	_, err = uc.SetMember(ctx, maintainer, id, owner.UserID.String(), "viewer")
+	assert.ErrorIs(t, err, ErrInvalidMember)
+
+	_, err = uc.SetMember(ctx, maintainer, id, carol.String(), "Editor")
+	require.NoError(t, err)
+	projects.AssertExpectations(t)
+}
+
+func TestRemoveMember_MembersMayLeave(t *testing.T) {
+	project, projects := newProject()
+	uc := NewProjectUseCase(projects, new(MockTaskRepo), new(MockUserRepo))
+	projects.On("Update", mock.Anything, mock.MatchedBy(func(p Domain.Project) bool {
+		return p.RoleOf(viewer.UserID) == ""
+	})).Return(project, nil).Once()
+
+	_, err := uc.RemoveMember(ctx, editor, project.ID.String(), viewer.UserID.String())
+	assert.ErrorIs(t, err, ErrProjectRoleRequired)
+	_, err = uc.RemoveMember(ctx, viewer, project.ID.String(), viewer.UserID.String())
+	require.NoError(t, err)
+	projects.AssertExpectations(t)
+}
+
+func TestDeleteProject_OwnerOnlyAndEmpty(t *testing.T) {
+	project, projects := newProject()
+	tasks := new(MockTaskRepo)
+	uc := NewProjectUseCase(projects, tasks, new(MockUserRepo))
+	tasks.On("Find", mock.Anything, Domain.TaskQuery{ProjectID: &project.ID, Limit: 1}).
+		Return(&Domain.TaskPage{Tasks: []Domain.Task{{}}}, nil).Once()
+	tasks.On("Find", mock.Anything, Domain.TaskQuery{ProjectID: &project.ID, Limit: 1}).
+		Return(&Domain.TaskPage{}, nil).Once()
+	projects.On("Delete", mock.Anything, project.ID).Return(nil).Once()
+
+	assert.ErrorIs(t, uc.DeleteProject(ctx, maintainer, project.ID.String()), ErrProjectOwnerRequired)
+	assert.ErrorIs(t, uc.DeleteProject(ctx, owner, project.ID.String()), ErrProjectNotEmpty)
+	require.NoError(t, uc.DeleteProject(ctx, owner, project.ID.String()))
+	projects.AssertExpectations(t)
+}
+
+func TestArchiveProject_KeepsFirstArchiveTime(t *testing.T) {
+	project, projects := newProject()
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	uc := NewProjectUseCase(projects, new(MockTaskRepo), new(MockUserRepo))
+	uc.now = func() time.Time { return now }
+	projects.On("Update", mock.Anything, mock.MatchedBy(func(p Domain.Project) bool {
+		return p.ArchivedAt != nil && p.ArchivedAt.Equal(now)
+	})).Return(project, nil).Once()
+
+	_, err := uc.ArchiveProject(ctx, editor, project.ID.String(), true)
+	assert.ErrorIs(t, err, ErrProjectRoleRequired)
+	_, err = uc.ArchiveProject(ctx, maintainer, project.ID.String(), true)
+	require.NoError(t, err)
+
+	earlier := now.Add(-time.Hour)
+	project.ArchivedAt = &earlier
+	got, err := uc.ArchiveProject(ctx, maintainer, project.ID.String(), true)
+	require.NoError(t, err)
+	assert.Equal(t, &earlier, got.ArchivedAt)
+	projects.AssertExpectations(t)
+}
+
+func TestProjectTasks_AccessFollowsRole(t *testing.T) {
+	project, projects := newProject()
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo, WithProjects(projects))
+
+	id := Domain.NewID()
+	task := &Domain.Task{TaskID: id, OwnerID: owner.UserID, Status: Domain.StatusPending, ProjectID: &project.ID}
+	mockRepo.On("GetByID", mock.Anything, id).Return(task, nil)
+	mockRepo.On("Update", mock.Anything, id, mock.Anything).Return(&Domain.Task{TaskID: id, Status: Domain.StatusInProgress}, nil).Once()
+
+	got, err := uc.GetTaskByID(ctx, viewer, id.String())
+	require.NoError(t, err)
+	assert.Equal(t, task, got)
+	_, err = uc.GetTaskByID(ctx, Domain.Actor{UserID: Domain.NewID()}, id.String())
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+
+	_, err = uc.TransitionTask(ctx, viewer, id.String(), "in_progress")
+	assert.ErrorIs(t, err, ErrReadOnlyTask)
+	_, err = uc.TransitionTask(ctx, editor, id.String(), "in_progress")
+	require.NoError(t, err)
+	assert.ErrorIs(t, uc.DeleteTask(ctx, editor, id.String(), 0), ErrOwnerRequired)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProjectTasks_ArchivedAreReadOnly(t *testing.T) {
+	project, projects := newProject()
+	archived := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	project.ArchivedAt = &archived
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo, WithProjects(projects))
+
+	id := Domain.NewID()
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{
+		TaskID: id, OwnerID: owner.UserID, Title: "t", Status: Domain.StatusPending, ProjectID: &project.ID,
+	}, nil)
+
+	_, err := uc.GetTaskByID(ctx, owner, id.String())
+	require.NoError(t, err)
+	_, err = uc.TransitionTask(ctx, owner, id.String(), "in_progress")
+	assert.ErrorIs(t, err, ErrProjectArchived)
+	_, err = uc.AddTags(ctx, admin, id.String(), []string{"late"})
+	assert.ErrorIs(t, err, ErrProjectArchived)
+	assert.ErrorIs(t, uc.DeleteTask(ctx, owner, id.String(), 0), ErrProjectArchived)
+	_, err = uc.CreateTask(ctx, owner, Domain.Task{Title: "new", ProjectID: &project.ID})
+	assert.ErrorIs(t, err, ErrProjectArchived)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateTask_InProject(t *testing.T) {
+	project, projects := newProject()
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo, WithProjects(projects))
+	mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(task Domain.Task) bool {
+		return task.OwnerID == editor.UserID && *task.ProjectID == project.ID
+	})).Return(&Domain.Task{TaskID: Domain.NewID()}, nil).Once()
+
+	_, err := uc.CreateTask(ctx, Domain.Actor{UserID: Domain.NewID()}, Domain.Task{Title: "t", ProjectID: &project.ID})
+	assert.ErrorIs(t, err, ErrInvalidProject)
+	_, err = uc.CreateTask(ctx, viewer, Domain.Task{Title: "t", ProjectID: &project.ID})
+	assert.ErrorIs(t, err, ErrProjectRoleRequired)
+	_, err = uc.CreateTask(ctx, editor, Domain.Task{Title: "t", ProjectID: &project.ID})
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetProjectTasks_MembersOnly(t *testing.T) {
+	project, projects := newProject()
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo, WithProjects(projects))
+	mockRepo.On("Find", mock.Anything, mock.MatchedBy(func(q Domain.TaskQuery) bool {
+		return q.ProjectID != nil && *q.ProjectID == project.ID && q.OwnerID == nil && !q.Trashed
+	})).Return(&Domain.TaskPage{}, nil).Once()
+
+	_, err := uc.GetProjectTasks(ctx, Domain.Actor{UserID: Domain.NewID()}, project.ID.String(), Domain.TaskQuery{})
+	assert.ErrorIs(t, err, Domain.ErrProjectNotFound)
+	_, err = uc.GetProjectTasks(ctx, viewer, project.ID.String(), Domain.TaskQuery{Trashed: true})
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProjectSubtasks_VisibleToMembers(t *testing.T) {
+	project, projects := newProject()
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo, WithProjects(projects))
+
+	// the parent and one subtask belong to other members of the project,
+	// the other subtask is private to someone outside it
+	parent, shared, private := Domain.NewID(), Domain.NewID(), Domain.NewID()
+	mockRepo.On("GetByID", mock.Anything, parent).Return(&Domain.Task{
+		TaskID: parent, OwnerID: editor.UserID, Status: Domain.StatusInProgress, ProjectID: &project.ID,
+	}, nil)
+	children := &Domain.TaskPage{Tasks: []Domain.Task{
+		{TaskID: shared, OwnerID: maintainer.UserID, Status: Domain.StatusPending, ProjectID: &project.ID},
+		{TaskID: private, OwnerID: Domain.NewID(), Status: Domain.StatusCompleted},
+	}}
+	mockRepo.On("Find", mock.Anything, childrenOf(parent)).Return(children, nil)
+	mockRepo.On("Find", mock.Anything, mock.Anything).Return(&Domain.TaskPage{}, nil)
+
+	tree, err := uc.GetTaskTree(ctx, viewer, parent.String(), 0)
+	require.NoError(t, err)
+	require.Len(t, tree.Children, 1)
+	assert.Equal(t, shared, tree.Children[0].Task.TaskID)
+	assert.Equal(t, 50.0, tree.Progress, "hidden subtasks still count towards progress")
+
+	page, err := uc.GetSubtasks(ctx, viewer, parent.String(), Domain.TaskQuery{})
+	require.NoError(t, err)
+	require.Len(t, page.Tasks, 1)
+	assert.Equal(t, shared, page.Tasks[0].TaskID)
+}
+
+func TestProjectDependencies_BlockingVisibleToMembers(t *testing.T) {
+	project, projects := newProject()
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo, WithProjects(projects))
+
+	id, shared, private := Domain.NewID(), Domain.NewID(), Domain.NewID()
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{
+		TaskID: id, OwnerID: editor.UserID, Status: Domain.StatusInProgress, ProjectID: &project.ID,
+	}, nil)
+	mockRepo.On("Find", mock.Anything, Domain.TaskQuery{DependsOn: &id, SortBy: Domain.SortByCreatedAt, Limit: MaxTaskLimit}).
+		Return(&Domain.TaskPage{Tasks: []Domain.Task{
+			{TaskID: shared, OwnerID: maintainer.UserID, Status: Domain.StatusBlocked, ProjectID: &project.ID, DependsOn: []Domain.ID{id}},
+			{TaskID: private, OwnerID: Domain.NewID(), Status: Domain.StatusBlocked, DependsOn: []Domain.ID{id}},
+		}}, nil)
+
+	deps, err := uc.GetDependencies(ctx, viewer, id.String())
+	require.NoError(t, err)
+	require.Len(t, deps.Blocking, 1)
+	assert.Equal(t, shared, deps.Blocking[0].TaskID)
+	mockRepo.AssertExpectations(t)
+}
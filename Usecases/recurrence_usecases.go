@@ -0,0 +1,84 @@
+package Usecases
+
+import (
+	"context"
+	"time"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+// Bounds on the number of upcoming occurrences a preview returns.
+const (
+	DefaultOccurrencePreview = 5
+	MaxOccurrencePreview     = 50
+)
+
+// PreviewOccurrences returns the due dates of up to n occurrences that
+// would follow task id. It is empty for a task that does not recur or
+// whose series has ended.
+func (u *TaskUseCase) PreviewOccurrences(ctx context.Context, actor domain.Actor, id string, n int) ([]time.Time, error) {
+	objID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+	task, err := u.load(ctx, actor, objID)
+	if err != nil {
+		return nil, err
+	}
+	dates, err := task.NextOccurrences(n)
+	if err != nil {
+		return nil, err
+	}
+	if dates == nil {
+		dates = []time.Time{}
+	}
+	return dates, nil
+}
+
+// normalizeRecurrence rewrites a validated rule in canonical form.
+func normalizeRecurrence(task *domain.Task) {
+	if task.Recurrence == "" {
+		return
+	}
+	if r, err := domain.ParseRecurrenceRule(task.Recurrence); err == nil {
+		task.Recurrence = r.String()
+	}
+}
+
+// nextOccurrence is called when task is being completed. It returns the
+// task that continues the series, or nil when the series has ended. It
+// keeps the task's title, description, parent, tags and priority. The
+// rule moves on to the new task, so the completed one no longer recurs
+// and reopening it cannot fork the series. Occurrences already due by
+// now are skipped, since a new task may not be due before its creation;
+// they still count towards the rule's COUNT.
+func (u *TaskUseCase) nextOccurrence(task *domain.Task) (*domain.Task, error) {
+	next := &domain.Task{
+		OwnerID:     task.OwnerID,
+		Title:       task.Title,
+		Description: task.Description,
+		DueDate:     task.DueDate,
+		ParentID:    task.ParentID,
+		ProjectID:   task.ProjectID,
+		Tags:        task.Tags,
+		Priority:    task.Priority,
+		Assignees:   task.Assignees,
+		Watchers:    task.Watchers,
+		Recurrence:  task.Recurrence,
+		Occurrence:  task.SeriesPosition(),
+	}
+	task.Recurrence = ""
+	now := u.now()
+	for {
+		dates, err := next.NextOccurrences(1)
+		if err != nil || len(dates) == 0 {
+			return nil, err
+		}
+		next.DueDate, next.Occurrence = dates[0], next.Occurrence+1
+		if next.DueDate.After(now) {
+			break
+		}
+	}
+	u.applyStatus(next, domain.StatusPending)
+	return next, nil
+}
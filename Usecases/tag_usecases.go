@@ -0,0 +1,60 @@
+package Usecases
+
+import (
+	"context"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+var ErrTagNotFound = domain.NewError(domain.ErrNotFound, "tag_not_found", "task does not have that tag")
+
+// AddTags adds tags to the task. They are normalized first, and tags the
+// task already has are skipped.
+func (u *TaskUseCase) AddTags(ctx context.Context, actor domain.Actor, id string, tags []string) (*domain.Task, error) {
+	objID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+	task, err := u.loadForEdit(ctx, actor, objID, 0)
+	if err != nil {
+		return nil, err
+	}
+	before := *task
+	task.Tags = domain.NormalizeTags(append(append([]string(nil), task.Tags...), tags...))
+	if err := task.Validate(task.CreatedAt); err != nil {
+		return nil, err
+	}
+	if len(task.Tags) == len(before.Tags) {
+		return task, nil
+	}
+	return u.save(ctx, actor, objID, domain.HistoryUpdated, 0, before, *task)
+}
+
+// RemoveTag takes a tag off the task.
+func (u *TaskUseCase) RemoveTag(ctx context.Context, actor domain.Actor, id, tag string) (*domain.Task, error) {
+	objID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+	task, err := u.loadForEdit(ctx, actor, objID, 0)
+	if err != nil {
+		return nil, err
+	}
+	tag = domain.NormalizeTag(tag)
+	if !task.HasTag(tag) {
+		return nil, ErrTagNotFound
+	}
+	before := *task
+	task.Tags = nil
+	for _, t := range before.Tags {
+		if t != tag {
+			task.Tags = append(task.Tags, t)
+		}
+	}
+	return u.save(ctx, actor, objID, domain.HistoryUpdated, 0, before, *task)
+}
+
+// GetTags counts the tags on the actor's own live tasks, most used first.
+func (u *TaskUseCase) GetTags(ctx context.Context, actor domain.Actor) ([]domain.TagCount, error) {
+	return u.repo.CountTags(ctx, &actor.UserID)
+}
@@ -1,57 +1,826 @@
 package Usecases
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	domain "github.com/surafelbkassa/go-task-manager/Domain"
-	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrInvalidSortField = domain.NewError(domain.ErrValidation, "invalid_sort_field", "invalid sort field")
+
+var (
+	ErrInvalidStatus     = domain.NewError(domain.ErrValidation, "invalid_status", "invalid task status")
+	ErrIllegalTransition = domain.NewError(domain.ErrConflict, "illegal_transition", "illegal status transition")
+	ErrAdminRequired     = domain.NewError(domain.ErrForbidden, "insufficient_role", "admin role required")
+	ErrInvalidParent     = domain.NewError(domain.ErrValidation, "invalid_parent", "parent task not found")
+	ErrTaskCycle         = domain.NewError(domain.ErrConflict, "task_cycle", "a task cannot be nested under itself or its subtasks")
+	ErrOpenSubtasks      = domain.NewError(domain.ErrConflict, "open_subtasks", "task has subtasks that are still open")
+)
+
+// Page size bounds applied to every listing.
+const (
+	DefaultTaskLimit = 20
+	MaxTaskLimit     = 100
+)
+
+// pageLimit applies the page size bounds to a requested limit.
+func pageLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultTaskLimit
+	}
+	if limit > MaxTaskLimit {
+		return MaxTaskLimit
+	}
+	return limit
+}
+
+// Depth bounds of the task tree: how many levels of subtasks are returned
+// by default and at most. Progress is always rolled up over MaxTreeDepth
+// levels, whatever depth is returned.
+const (
+	DefaultTreeDepth = 3
+	MaxTreeDepth     = 10
 )
 
 type TaskUseCaseInterface interface {
-	GetTasks() ([]domain.Task, error)
-	GetTaskByID(string) (*domain.Task, error)
-	CreateTask(domain.Task) (*domain.Task, error)
-	UpdateTask(string, domain.Task) (*domain.Task, error)
-	DeleteTask(string) error
+	GetTasks(ctx context.Context, actor domain.Actor, q domain.TaskQuery) (*domain.TaskPage, error)
+	GetAssignedTasks(ctx context.Context, actor domain.Actor, q domain.TaskQuery) (*domain.TaskPage, error)
+	GetProjectTasks(ctx context.Context, actor domain.Actor, id string, q domain.TaskQuery) (*domain.TaskPage, error)
+	GetTaskByID(ctx context.Context, actor domain.Actor, id string) (*domain.Task, error)
+	CreateTask(ctx context.Context, actor domain.Actor, task domain.Task) (*domain.Task, error)
+	UpdateTask(ctx context.Context, actor domain.Actor, id string, version int64, task domain.Task) (*domain.Task, error)
+	PatchTask(ctx context.Context, actor domain.Actor, id string, version int64, patch domain.TaskPatch) (*domain.Task, error)
+	DeleteTask(ctx context.Context, actor domain.Actor, id string, version int64) error
+	TransitionTask(ctx context.Context, actor domain.Actor, id string, status string) (*domain.Task, error)
+	GetTrash(ctx context.Context, actor domain.Actor, q domain.TaskQuery) (*domain.TaskPage, error)
+	RestoreTask(ctx context.Context, actor domain.Actor, id string) (*domain.Task, error)
+	PurgeTask(ctx context.Context, actor domain.Actor, id string) error
+	GetSubtasks(ctx context.Context, actor domain.Actor, id string, q domain.TaskQuery) (*domain.TaskPage, error)
+	GetTaskTree(ctx context.Context, actor domain.Actor, id string, depth int) (*domain.TaskNode, error)
+	GetDependencies(ctx context.Context, actor domain.Actor, id string) (*TaskDependencies, error)
+	AddDependency(ctx context.Context, actor domain.Actor, id, dependsOn string) (*domain.Task, error)
+	RemoveDependency(ctx context.Context, actor domain.Actor, id, dependsOn string) (*domain.Task, error)
+	PlanTasks(ctx context.Context, actor domain.Actor, ids []string) ([]domain.Task, error)
+	PreviewOccurrences(ctx context.Context, actor domain.Actor, id string, n int) ([]time.Time, error)
+	AddTags(ctx context.Context, actor domain.Actor, id string, tags []string) (*domain.Task, error)
+	RemoveTag(ctx context.Context, actor domain.Actor, id, tag string) (*domain.Task, error)
+	GetTags(ctx context.Context, actor domain.Actor) ([]domain.TagCount, error)
+	TaskHistory(ctx context.Context, actor domain.Actor, id string, q domain.HistoryQuery) (*domain.HistoryPage, error)
+	AuditLog(ctx context.Context, actor domain.Actor, q domain.HistoryQuery) (*domain.HistoryPage, error)
 }
 
 type TaskUseCase struct {
-	repo domain.TaskRepository
+	repo          domain.TaskRepository
+	history       domain.HistoryRepository
+	users         domain.UserRepository
+	notifications domain.NotificationRepository
+	projects      domain.ProjectRepository
+	transitions   domain.TransitionTable
+	// allowOpenSubtasks lets a task be completed while some of its
+	// subtasks are still open.
+	allowOpenSubtasks bool
+	urgency           domain.UrgencyWeights
+	now               func() time.Time
+}
+
+// TaskUseCaseOption customizes a TaskUseCase.
+type TaskUseCaseOption func(*TaskUseCase)
+
+// WithTransitions replaces the default status workflow.
+func WithTransitions(t domain.TransitionTable) TaskUseCaseOption {
+	return func(u *TaskUseCase) { u.transitions = t }
+}
+
+// WithHistory records every change to a task in h. Without it no history
+// is kept.
+func WithHistory(h domain.HistoryRepository) TaskUseCaseOption {
+	return func(u *TaskUseCase) { u.history = h }
+}
+
+// WithUsers looks up the users assigned to or watching a task. Without it
+// tasks cannot have assignees or watchers.
+func WithUsers(r domain.UserRepository) TaskUseCaseOption {
+	return func(u *TaskUseCase) { u.users = r }
+}
+
+// WithNotifications notifies users of assignment changes and watchers of
+// changes to the tasks they watch. Without it nobody is notified.
+func WithNotifications(n domain.NotificationRepository) TaskUseCaseOption {
+	return func(u *TaskUseCase) { u.notifications = n }
+}
+
+// WithProjects lets tasks belong to projects, whose members may access
+// them according to their role. Without it tasks cannot be put in a
+// project.
+func WithProjects(p domain.ProjectRepository) TaskUseCaseOption {
+	return func(u *TaskUseCase) { u.projects = p }
+}
+
+// WithOpenSubtasksAllowed controls whether a task may be completed while
+// some of its subtasks are still open. By default it may not.
+func WithOpenSubtasksAllowed(allowed bool) TaskUseCaseOption {
+	return func(u *TaskUseCase) { u.allowOpenSubtasks = allowed }
+}
+
+// WithUrgencyWeights replaces domain.DefaultUrgencyWeights in the urgency
+// score of tasks.
+func WithUrgencyWeights(w domain.UrgencyWeights) TaskUseCaseOption {
+	return func(u *TaskUseCase) { u.urgency = w }
+}
+
+func NewTaskUseCase(r domain.TaskRepository, opts ...TaskUseCaseOption) *TaskUseCase {
+	u := &TaskUseCase{
+		transitions: domain.DefaultTransitions(),
+		urgency:     domain.DefaultUrgencyWeights(),
+		now:         time.Now,
+	}
+	u.repo = scoredRepository{TaskRepository: r, u: u}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u
+}
+
+// GetTasks returns one page of tasks matching q. Non-admin callers are
+// always restricted to their own tasks, whatever owner q asks for.
+func (u *TaskUseCase) GetTasks(ctx context.Context, actor domain.Actor, q domain.TaskQuery) (*domain.TaskPage, error) {
+	q.Trashed = false
+	scopeToOwner(actor, &q)
+	return u.findTasks(ctx, q)
 }
 
-func NewTaskUseCase(r domain.TaskRepository) *TaskUseCase {
-	return &TaskUseCase{repo: r}
+// GetTrash is GetTasks for the tasks in the trash.
+func (u *TaskUseCase) GetTrash(ctx context.Context, actor domain.Actor, q domain.TaskQuery) (*domain.TaskPage, error) {
+	q.Trashed = true
+	scopeToOwner(actor, &q)
+	return u.findTasks(ctx, q)
+}
+
+// scopeToOwner restricts non-admin callers to their own tasks.
+func scopeToOwner(actor domain.Actor, q *domain.TaskQuery) {
+	if !actor.IsAdmin() {
+		q.OwnerID = &actor.UserID
+	}
+}
+
+func (u *TaskUseCase) findTasks(ctx context.Context, q domain.TaskQuery) (*domain.TaskPage, error) {
+	if q.SortBy == "" {
+		q.SortBy = domain.SortByCreatedAt
+	}
+	q.Tags = domain.NormalizeTags(q.Tags)
+	if !q.SortBy.Valid() {
+		return nil, ErrInvalidSortField
+	}
+	if q.Cursor != "" {
+		c, err := domain.DecodeTaskCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		// a cursor only marks a position in the order it was made for
+		if !c.SortedBy(q.SortBy, q.SortDesc) {
+			return nil, domain.ErrInvalidCursor
+		}
+	}
+	q.Limit = pageLimit(q.Limit)
+	if q.SortBy == domain.SortByUrgency {
+		return u.findByUrgency(ctx, q)
+	}
+	return u.repo.Find(ctx, q)
+}
+
+func (u *TaskUseCase) GetTaskByID(ctx context.Context, actor domain.Actor, id string) (*domain.Task, error) {
+	objID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+	return u.load(ctx, actor, objID)
+}
+
+// CreateTask accepts any defined status and defaults to Pending.
+func (u *TaskUseCase) CreateTask(ctx context.Context, actor domain.Actor, task domain.Task) (*domain.Task, error) {
+	task.Tags = domain.NormalizeTags(task.Tags)
+	task.Priority = domain.NormalizePriority(task.Priority)
+	normalizePeople(&task)
+	if err := task.Validate(u.now()); err != nil {
+		return nil, err
+	}
+	if err := u.checkParent(ctx, actor, "", task.ParentID); err != nil {
+		return nil, err
+	}
+	if err := u.checkPeople(ctx, actor, domain.Task{}, task); err != nil {
+		return nil, err
+	}
+	if err := u.checkProject(ctx, actor, task.ProjectID); err != nil {
+		return nil, err
+	}
+	task.OwnerID = actor.UserID
+	task.DependsOn = nil
+	task.Occurrence = 0
+	if task.Recurrence != "" {
+		normalizeRecurrence(&task)
+		task.Occurrence = 1
+	}
+	status := domain.StatusPending
+	if task.Status != "" {
+		var ok bool
+		if status, ok = domain.ParseTaskStatus(string(task.Status)); !ok {
+			return nil, ErrInvalidStatus
+		}
+	}
+	task.Status = ""
+	task.CompletedAt = nil
+	u.applyStatus(&task, status)
+	created, err := u.repo.Create(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.record(ctx, actor, created.TaskID, domain.HistoryCreated, domain.Task{}, *created); err != nil {
+		return nil, err
+	}
+	return created, nil
 }
 
-// ← ADD THIS
-func (u *TaskUseCase) GetTasks() ([]domain.Task, error) {
-	return u.repo.GetAll()
+// UpdateTask replaces the task's fields. An empty status keeps the current
+// one; any other status must be reachable under the transition table.
+//
+// UpdateTask, PatchTask and DeleteTask take the version the caller last
+// saw and fail with domain.ErrVersionMismatch if the task has changed
+// since; version 0 skips the check.
+func (u *TaskUseCase) UpdateTask(ctx context.Context, actor domain.Actor, id string, version int64, task domain.Task) (*domain.Task, error) {
+	objID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := u.loadForEdit(ctx, actor, objID, version)
+	if err != nil {
+		return nil, err
+	}
+	task.Tags = domain.NormalizeTags(task.Tags)
+	task.Priority = domain.NormalizePriority(task.Priority)
+	normalizePeople(&task)
+	if err := task.Validate(existing.CreatedAt); err != nil {
+		return nil, err
+	}
+	requested := string(task.Status)
+	task.Status, task.CompletedAt = existing.Status, existing.CompletedAt
+	task.Version = existing.Version
+	task.DependsOn = existing.DependsOn
+	task.Occurrence = existing.Occurrence
+	normalizeRecurrence(&task)
+	if requested != "" {
+		if err := u.transition(&task, requested); err != nil {
+			return nil, err
+		}
+	}
+	if err := u.checkRules(ctx, actor, objID, *existing, task); err != nil {
+		return nil, err
+	}
+	return u.save(ctx, actor, objID, domain.HistoryUpdated, version, *existing, task)
 }
 
-func (u *TaskUseCase) GetTaskByID(id string) (*domain.Task, error) {
-	objID, err := primitive.ObjectIDFromHex(id)
+// PatchTask changes only the fields set in patch. The merged task is
+// validated as a whole, and a status change must be allowed by the workflow.
+func (u *TaskUseCase) PatchTask(ctx context.Context, actor domain.Actor, id string, version int64, patch domain.TaskPatch) (*domain.Task, error) {
+	objID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+	task, err := u.loadForEdit(ctx, actor, objID, version)
 	if err != nil {
 		return nil, err
 	}
-	return u.repo.GetByID(objID)
+	before := *task
+	patch.Apply(task)
+	task.Tags = domain.NormalizeTags(task.Tags)
+	task.Priority = domain.NormalizePriority(task.Priority)
+	normalizePeople(task)
+	if err := task.Validate(task.CreatedAt); err != nil {
+		return nil, err
+	}
+	normalizeRecurrence(task)
+	if patch.Status != nil {
+		if err := u.transition(task, *patch.Status); err != nil {
+			return nil, err
+		}
+	}
+	if err := u.checkRules(ctx, actor, objID, before, *task); err != nil {
+		return nil, err
+	}
+	return u.save(ctx, actor, objID, domain.HistoryUpdated, version, before, *task)
 }
 
-func (u *TaskUseCase) CreateTask(task domain.Task) (*domain.Task, error) {
-	return u.repo.Create(task)
+// DeleteTask moves the task to the trash, from where it can be restored
+// until it is purged. Only the owner, an admin or a maintainer of the
+// task's project may delete a task.
+func (u *TaskUseCase) DeleteTask(ctx context.Context, actor domain.Actor, id string, version int64) error {
+	objID, err := domain.ParseID(id)
+	if err != nil {
+		return err
+	}
+	task, err := u.loadVersion(ctx, actor, objID, version)
+	if err != nil {
+		return err
+	}
+	if err := u.authorize(ctx, actor, task, domain.OwnerAccess); err != nil {
+		return err
+	}
+	if err := u.repo.Delete(ctx, objID, task.Version); err != nil {
+		return err
+	}
+	trashed, err := u.repo.GetByID(ctx, objID)
+	if err != nil {
+		return err
+	}
+	if err := u.record(ctx, actor, objID, domain.HistoryDeleted, *task, *trashed); err != nil {
+		return err
+	}
+	if task.Finished() {
+		return nil
+	}
+	return u.updateDependents(ctx, actor, objID)
 }
 
-func (u *TaskUseCase) UpdateTask(id string, task domain.Task) (*domain.Task, error) {
-	objID, err := primitive.ObjectIDFromHex(id)
+// RestoreTask takes a task out of the trash; it needs the same rights as
+// deleting it.
+func (u *TaskUseCase) RestoreTask(ctx context.Context, actor domain.Actor, id string) (*domain.Task, error) {
+	objID, err := domain.ParseID(id)
 	if err != nil {
-		return nil, errors.New("invalid ID format")
+		return nil, err
 	}
-	return u.repo.Update(objID, task)
+	task, err := u.loadWithTrash(ctx, actor, objID)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.authorize(ctx, actor, task, domain.OwnerAccess); err != nil {
+		return nil, err
+	}
+	restored, err := u.repo.Restore(ctx, objID)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.record(ctx, actor, objID, domain.HistoryRestored, *task, *restored); err != nil {
+		return nil, err
+	}
+	if !restored.Finished() {
+		if err := u.updateDependents(ctx, actor, objID); err != nil {
+			return nil, err
+		}
+	}
+	return restored, nil
 }
 
-func (u *TaskUseCase) DeleteTask(id string) error {
-	objID, err := primitive.ObjectIDFromHex(id)
+// PurgeTask deletes a task in the trash for good. Only admins may purge.
+func (u *TaskUseCase) PurgeTask(ctx context.Context, actor domain.Actor, id string) error {
+	if !actor.IsAdmin() {
+		return ErrAdminRequired
+	}
+	objID, err := domain.ParseID(id)
+	if err != nil {
+		return err
+	}
+	task, err := u.repo.GetByID(ctx, objID)
 	if err != nil {
-		return errors.New("invalid ID format")
+		return err
+	}
+	if err := u.repo.Purge(ctx, objID); err != nil {
+		return err
+	}
+	return u.record(ctx, actor, objID, domain.HistoryPurged, *task, domain.Task{})
+}
+
+// PurgeExpiredTrash purges every task that has been in the trash for
+// longer than retention and returns how many were purged. It runs as a
+// background job, so the history entries it writes have no actor, and it
+// covers every organization ctx can see, all of them once unscoped with
+// domain.WithoutTenant.
+func (u *TaskUseCase) PurgeExpiredTrash(ctx context.Context, retention time.Duration) (int, error) {
+	purged, err := u.repo.PurgeDeletedBefore(ctx, u.now().Add(-retention))
+	for _, t := range purged {
+		if err := u.record(ctx, domain.Actor{}, t.TaskID, domain.HistoryPurged, t, domain.Task{}); err != nil {
+			return len(purged), err
+		}
+	}
+	return len(purged), err
+}
+
+// TransitionTask moves a task to a new status if the workflow allows it.
+func (u *TaskUseCase) TransitionTask(ctx context.Context, actor domain.Actor, id string, status string) (*domain.Task, error) {
+	objID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+	task, err := u.loadForEdit(ctx, actor, objID, 0)
+	if err != nil {
+		return nil, err
+	}
+	before := *task
+	if err := u.transition(task, status); err != nil {
+		return nil, err
+	}
+	if err := u.checkRules(ctx, actor, objID, before, *task); err != nil {
+		return nil, err
+	}
+	return u.save(ctx, actor, objID, domain.HistoryTransitioned, 0, before, *task)
+}
+
+// GetSubtasks returns one page of the direct subtasks of a task the actor
+// can access, leaving out the subtasks the actor may not read, so a page
+// can hold fewer tasks than q.Limit.
+func (u *TaskUseCase) GetSubtasks(ctx context.Context, actor domain.Actor, id string, q domain.TaskQuery) (*domain.TaskPage, error) {
+	objID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := u.load(ctx, actor, objID); err != nil {
+		return nil, err
+	}
+	q.ParentID = &objID
+	q.Trashed = false
+	page, err := u.findTasks(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	readable := page.Tasks[:0]
+	for _, t := range page.Tasks {
+		ok, err := u.canRead(ctx, actor, &t)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			readable = append(readable, t)
+		}
+	}
+	page.Tasks = readable
+	return page, nil
+}
+
+// GetTaskTree returns a task with its subtasks nested depth levels deep;
+// depth 0 or less means DefaultTreeDepth and it is capped at MaxTreeDepth.
+// Every node carries the completion percentage rolled up from below it,
+// counting the subtasks the actor may not read, which are left out of the
+// tree.
+func (u *TaskUseCase) GetTaskTree(ctx context.Context, actor domain.Actor, id string, depth int) (*domain.TaskNode, error) {
+	objID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+	task, err := u.load(ctx, actor, objID)
+	if err != nil {
+		return nil, err
+	}
+	if depth <= 0 {
+		depth = DefaultTreeDepth
+	}
+	if depth > MaxTreeDepth {
+		depth = MaxTreeDepth
+	}
+	root := &domain.TaskNode{Task: *task}
+	if err := u.loadSubtree(ctx, root, MaxTreeDepth); err != nil {
+		return nil, err
+	}
+	root.RollUp()
+	root.Prune(depth)
+	if err := u.hideUnreadable(ctx, actor, root); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// loadSubtree attaches all subtasks of n, depth levels deep. Cycles cannot
+// be stored, but the depth bound keeps a corrupted hierarchy from looping.
+func (u *TaskUseCase) loadSubtree(ctx context.Context, n *domain.TaskNode, depth int) error {
+	if depth == 0 {
+		return nil
+	}
+	page, err := u.repo.Find(ctx, domain.TaskQuery{ParentID: &n.Task.TaskID, SortBy: domain.SortByCreatedAt})
+	if err != nil {
+		return err
+	}
+	for _, t := range page.Tasks {
+		child := &domain.TaskNode{Task: t}
+		if err := u.loadSubtree(ctx, child, depth-1); err != nil {
+			return err
+		}
+		n.Children = append(n.Children, child)
+	}
+	return nil
+}
+
+// hideUnreadable drops the subtasks below n that the actor may not read,
+// together with everything under them.
+func (u *TaskUseCase) hideUnreadable(ctx context.Context, actor domain.Actor, n *domain.TaskNode) error {
+	readable := n.Children[:0]
+	for _, c := range n.Children {
+		ok, err := u.canRead(ctx, actor, &c.Task)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if err := u.hideUnreadable(ctx, actor, c); err != nil {
+			return err
+		}
+		readable = append(readable, c)
+	}
+	n.Children = readable
+	return nil
+}
+
+// TaskHistory pages through the changes made to a task the actor can
+// access, oldest first. The history of a task in the trash stays readable.
+func (u *TaskUseCase) TaskHistory(ctx context.Context, actor domain.Actor, id string, q domain.HistoryQuery) (*domain.HistoryPage, error) {
+	objID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := u.loadWithTrash(ctx, actor, objID); err != nil {
+		return nil, err
+	}
+	q.TaskID = &objID
+	return u.findHistory(ctx, q)
+}
+
+// AuditLog pages through the changes to every task. Only admins may read
+// it; q can narrow it down by actor and time range.
+func (u *TaskUseCase) AuditLog(ctx context.Context, actor domain.Actor, q domain.HistoryQuery) (*domain.HistoryPage, error) {
+	if !actor.IsAdmin() {
+		return nil, ErrAdminRequired
+	}
+	return u.findHistory(ctx, q)
+}
+
+func (u *TaskUseCase) findHistory(ctx context.Context, q domain.HistoryQuery) (*domain.HistoryPage, error) {
+	if q.Cursor != "" {
+		if _, _, err := domain.DecodeHistoryCursor(q.Cursor); err != nil {
+			return nil, err
+		}
+	}
+	q.Limit = pageLimit(q.Limit)
+	if u.history == nil {
+		return &domain.HistoryPage{}, nil
+	}
+	return u.history.Find(ctx, q)
+}
+
+// transition validates a move from task's current status to the requested
+// one and applies it. Tasks carrying a legacy status outside the defined
+// set are treated as Pending so they can be brought back into the workflow.
+func (u *TaskUseCase) transition(task *domain.Task, requested string) error {
+	to, ok := domain.ParseTaskStatus(requested)
+	if !ok {
+		return ErrInvalidStatus
+	}
+	from, ok := domain.ParseTaskStatus(string(task.Status))
+	if !ok {
+		from = domain.StatusPending
+	}
+	if !u.transitions.Allows(from, to) {
+		return fmt.Errorf("%w: %s -> %s", ErrIllegalTransition, from, to)
+	}
+	u.applyStatus(task, to)
+	return nil
+}
+
+// checkRules enforces the subtask and dependency rules on a change from
+// before to after: a new parent must be valid, a task with unfinished
+// dependencies must stay Blocked (or be cancelled), and unless allowed a
+// task cannot be completed while it has open subtasks.
+func (u *TaskUseCase) checkRules(ctx context.Context, actor domain.Actor, id domain.ID, before, after domain.Task) error {
+	if err := u.checkPeople(ctx, actor, before, after); err != nil {
+		return err
+	}
+	if !sameID(before.ParentID, after.ParentID) {
+		if err := u.checkParent(ctx, actor, id, after.ParentID); err != nil {
+			return err
+		}
+	}
+	if !sameID(before.ProjectID, after.ProjectID) {
+		if err := u.authorize(ctx, actor, &before, domain.OwnerAccess); err != nil {
+			return err
+		}
+		if err := u.checkProject(ctx, actor, after.ProjectID); err != nil {
+			return err
+		}
+	}
+	if after.Status != before.Status && after.Status != domain.StatusBlocked && after.Status != domain.StatusCancelled {
+		waiting, err := u.waitingOnDependencies(ctx, after)
+		if err != nil {
+			return err
+		}
+		if waiting {
+			return ErrBlockedByDependencies
+		}
+	}
+	if u.allowOpenSubtasks || after.Status != domain.StatusCompleted || before.Status == domain.StatusCompleted {
+		return nil
+	}
+	var open []domain.TaskStatus
+	for _, s := range domain.TaskStatuses {
+		if s.IsOpen() {
+			open = append(open, s)
+		}
+	}
+	page, err := u.repo.Find(ctx, domain.TaskQuery{ParentID: &id, Statuses: open, Limit: 1})
+	if err != nil {
+		return err
+	}
+	if len(page.Tasks) > 0 {
+		return ErrOpenSubtasks
+	}
+	return nil
+}
+
+// checkParent verifies that the task id (empty for a new task) may be
+// nested under parentID: the parent must be a live task the actor can
+// access, and id must not be the parent or one of its ancestors.
+func (u *TaskUseCase) checkParent(ctx context.Context, actor domain.Actor, id domain.ID, parentID *domain.ID) error {
+	if parentID == nil {
+		return nil
+	}
+	parent, err := u.load(ctx, actor, *parentID)
+	if errors.Is(err, domain.ErrTaskNotFound) {
+		return ErrInvalidParent
+	}
+	if err != nil {
+		return err
+	}
+	seen := map[domain.ID]bool{}
+	for ancestor := parent; ; {
+		if ancestor.TaskID == id || seen[ancestor.TaskID] {
+			return ErrTaskCycle
+		}
+		seen[ancestor.TaskID] = true
+		if ancestor.ParentID == nil {
+			return nil
+		}
+		ancestor, err = u.repo.GetByID(ctx, *ancestor.ParentID)
+		if errors.Is(err, domain.ErrTaskNotFound) {
+			// the chain ends at a purged task
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func sameID(a, b *domain.ID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// applyStatus sets the status and keeps CompletedAt in step with it.
+func (u *TaskUseCase) applyStatus(task *domain.Task, status domain.TaskStatus) {
+	if status == domain.StatusCompleted && task.Status != domain.StatusCompleted {
+		now := u.now()
+		task.CompletedAt = &now
+	}
+	if status != domain.StatusCompleted {
+		task.CompletedAt = nil
+	}
+	task.Status = status
+}
+
+// load fetches a live task and hides it from callers that may not access
+// it: they get the same ErrTaskNotFound as for a missing task, so existence
+// is not leaked across users. Tasks in the trash are not found either.
+func (u *TaskUseCase) load(ctx context.Context, actor domain.Actor, id domain.ID) (*domain.Task, error) {
+	task, err := u.loadWithTrash(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if task.DeletedAt != nil {
+		return nil, domain.ErrTaskNotFound
+	}
+	return task, nil
+}
+
+// loadWithTrash is load for tasks that may be in the trash.
+func (u *TaskUseCase) loadWithTrash(ctx context.Context, actor domain.Actor, id domain.ID) (*domain.Task, error) {
+	task, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := u.canRead(ctx, actor, task)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, domain.ErrTaskNotFound
+	}
+	return task, nil
+}
+
+// canRead reports whether the actor may see task, directly or through its
+// project.
+func (u *TaskUseCase) canRead(ctx context.Context, actor domain.Actor, task *domain.Task) (bool, error) {
+	if actor.CanAccess(task) {
+		return true, nil
+	}
+	access, _, err := taskAccess(ctx, u.projects, actor, task)
+	if err != nil {
+		return false, err
+	}
+	return access >= domain.ReadAccess, nil
+}
+
+// loadVersion is load for a write that expects the task at version.
+func (u *TaskUseCase) loadVersion(ctx context.Context, actor domain.Actor, id domain.ID, version int64) (*domain.Task, error) {
+	task, err := u.load(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && task.Version != version {
+		return nil, domain.ErrVersionMismatch
+	}
+	return task, nil
+}
+
+// loadForEdit is loadVersion for a change the task's assignees and the
+// editors of its project may make too. Watchers and viewers get
+// ErrReadOnlyTask.
+func (u *TaskUseCase) loadForEdit(ctx context.Context, actor domain.Actor, id domain.ID, version int64) (*domain.Task, error) {
+	task, err := u.loadVersion(ctx, actor, id, version)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.authorize(ctx, actor, task, domain.EditAccess); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// save stores the changed copy of before and records the change. A
+// concurrent write that slips in after loading breaks the caller's
+// precondition just like a stale version. Completing a recurring task
+// creates its next occurrence.
+func (u *TaskUseCase) save(ctx context.Context, actor domain.Actor, id domain.ID, action domain.HistoryAction, version int64, before, task domain.Task) (*domain.Task, error) {
+	var next *domain.Task
+	if task.Recurrence != "" && task.Status == domain.StatusCompleted && before.Status != domain.StatusCompleted {
+		var err error
+		if next, err = u.nextOccurrence(&task); err != nil {
+			return nil, err
+		}
+	}
+	saved, err := u.repo.Update(ctx, id, task)
+	if version != 0 && errors.Is(err, domain.ErrVersionConflict) {
+		return nil, domain.ErrVersionMismatch
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := u.record(ctx, actor, id, action, before, *saved); err != nil {
+		return nil, err
+	}
+	if before.Finished() != saved.Finished() {
+		if err := u.updateDependents(ctx, actor, id); err != nil {
+			return nil, err
+		}
+	}
+	if next != nil {
+		created, err := u.repo.Create(ctx, *next)
+		if err != nil {
+			return nil, err
+		}
+		if err := u.record(ctx, actor, created.TaskID, domain.HistoryCreated, domain.Task{}, *created); err != nil {
+			return nil, err
+		}
+	}
+	return saved, nil
+}
+
+// record appends a history entry for a change that has already been
+// stored and notifies the users it concerns. The zero Task stands for the
+// missing side of a creation or a deletion.
+func (u *TaskUseCase) record(ctx context.Context, actor domain.Actor, id domain.ID, action domain.HistoryAction, before, after domain.Task) error {
+	if u.history != nil {
+		_, err := u.history.Append(ctx, domain.HistoryEntry{
+			OrgID:   taskOrg(before, after),
+			TaskID:  id,
+			ActorID: actor.UserID,
+			Action:  action,
+			At:      u.now(),
+			Changes: domain.DiffTasks(before, after),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return u.notify(ctx, actor, id, action, before, after)
+}
+
+// taskOrg is the organization of the task a change was made to. Records
+// of the change belong to it even when the change is made outside of a
+// request, such as by the trash purge.
+func taskOrg(before, after domain.Task) domain.ID {
+	if after.OrgID.IsZero() {
+		return before.OrgID
 	}
-	return u.repo.Delete(objID)
+	return after.OrgID
 }
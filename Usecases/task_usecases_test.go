@@ -1,14 +1,17 @@
 package Usecases
 
 import (
+	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	Domain "github.com/surafelbkassa/go-task-manager/Domain"
-	"go.mongodb.org/mongo-driver/bson/primitive"
+	Repositories "github.com/surafelbkassa/go-task-manager/Repositories"
 )
 
 // --- Mock TaskRepository ---
@@ -16,42 +19,92 @@ type MockTaskRepo struct {
 	mock.Mock
 }
 
-func (m *MockTaskRepo) GetAll() ([]Domain.Task, error) {
-	args := m.Called()
-	return args.Get(0).([]Domain.Task), args.Error(1)
+func (m *MockTaskRepo) Ping(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+func (m *MockTaskRepo) Find(ctx context.Context, q Domain.TaskQuery) (*Domain.TaskPage, error) {
+	args := m.Called(ctx, q)
+	return args.Get(0).(*Domain.TaskPage), args.Error(1)
+}
+
+func (m *MockTaskRepo) GetByID(ctx context.Context, id Domain.ID) (*Domain.Task, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*Domain.Task), args.Error(1)
 }
 
-func (m *MockTaskRepo) GetByID(id primitive.ObjectID) (*Domain.Task, error) {
-	args := m.Called(id)
+func (m *MockTaskRepo) Create(ctx context.Context, task Domain.Task) (*Domain.Task, error) {
+	args := m.Called(ctx, task)
 	return args.Get(0).(*Domain.Task), args.Error(1)
 }
 
-func (m *MockTaskRepo) Create(task Domain.Task) (*Domain.Task, error) {
-	args := m.Called(task)
+func (m *MockTaskRepo) Update(ctx context.Context, id Domain.ID, task Domain.Task) (*Domain.Task, error) {
+	args := m.Called(ctx, id, task)
 	return args.Get(0).(*Domain.Task), args.Error(1)
 }
 
-func (m *MockTaskRepo) Update(id primitive.ObjectID, task Domain.Task) (*Domain.Task, error) {
-	args := m.Called(id, task)
+func (m *MockTaskRepo) Delete(ctx context.Context, id Domain.ID, version int64) error {
+	return m.Called(ctx, id, version).Error(0)
+}
+
+func (m *MockTaskRepo) Restore(ctx context.Context, id Domain.ID) (*Domain.Task, error) {
+	args := m.Called(ctx, id)
 	return args.Get(0).(*Domain.Task), args.Error(1)
 }
 
-func (m *MockTaskRepo) Delete(id primitive.ObjectID) error {
-	return m.Called(id).Error(0)
+func (m *MockTaskRepo) Purge(ctx context.Context, id Domain.ID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockTaskRepo) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) ([]Domain.Task, error) {
+	args := m.Called(ctx, cutoff)
+	return args.Get(0).([]Domain.Task), args.Error(1)
 }
 
+func (m *MockTaskRepo) CountTags(ctx context.Context, owner *Domain.ID) ([]Domain.TagCount, error) {
+	args := m.Called(ctx, owner)
+	return args.Get(0).([]Domain.TagCount), args.Error(1)
+}
+
+// --- Mock HistoryRepository ---
+type MockHistoryRepo struct {
+	mock.Mock
+}
+
+func (m *MockHistoryRepo) Append(ctx context.Context, entry Domain.HistoryEntry) (*Domain.HistoryEntry, error) {
+	args := m.Called(ctx, entry)
+	return args.Get(0).(*Domain.HistoryEntry), args.Error(1)
+}
+
+func (m *MockHistoryRepo) Find(ctx context.Context, q Domain.HistoryQuery) (*Domain.HistoryPage, error) {
+	args := m.Called(ctx, q)
+	return args.Get(0).(*Domain.HistoryPage), args.Error(1)
+}
+
+var (
+	// ctx sees every organization, like the background jobs; tests of
+	// tenant scoping build their own with Domain.WithTenant
+	ctx   = Domain.WithoutTenant(context.Background())
+	owner = Domain.Actor{UserID: Domain.NewID(), Role: "user"}
+	admin = Domain.Actor{UserID: Domain.NewID(), Role: "admin"}
+)
+
 // ---------- TESTS ----------
 
 func TestGetTasks_Success(t *testing.T) {
 	mockRepo := new(MockTaskRepo)
 	uc := NewTaskUseCase(mockRepo)
 
-	expected := []Domain.Task{
+	expected := &Domain.TaskPage{Tasks: []Domain.Task{
 		{Title: "A", Description: "desc", CreatedAt: time.Now()},
-	}
-	mockRepo.On("GetAll").Return(expected, nil)
+	}}
+	mockRepo.On("Find", mock.Anything, Domain.TaskQuery{
+		OwnerID: &owner.UserID,
+		SortBy:  Domain.SortByCreatedAt,
+		Limit:   DefaultTaskLimit,
+	}).Return(expected, nil)
 
-	result, err := uc.GetTasks()
+	result, err := uc.GetTasks(ctx, owner, Domain.TaskQuery{})
 	assert.NoError(t, err)
 	assert.Equal(t, expected, result)
 	mockRepo.AssertExpectations(t)
@@ -61,22 +114,75 @@ func TestGetTasks_Error(t *testing.T) {
 	mockRepo := new(MockTaskRepo)
 	uc := NewTaskUseCase(mockRepo)
 
-	mockRepo.On("GetAll").Return([]Domain.Task(nil), errors.New("db fail"))
+	mockRepo.On("Find", mock.Anything, mock.Anything).Return((*Domain.TaskPage)(nil), errors.New("db fail"))
 
-	_, err := uc.GetTasks()
+	_, err := uc.GetTasks(ctx, owner, Domain.TaskQuery{})
 	assert.EqualError(t, err, "db fail")
 	mockRepo.AssertExpectations(t)
 }
 
+func TestGetTasks_OwnerFilterCannotBeWidened(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	other := Domain.NewID()
+	mockRepo.On("Find", mock.Anything, mock.MatchedBy(func(q Domain.TaskQuery) bool {
+		return q.OwnerID != nil && *q.OwnerID == owner.UserID
+	})).Return(&Domain.TaskPage{}, nil)
+
+	_, err := uc.GetTasks(ctx, owner, Domain.TaskQuery{OwnerID: &other})
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetTasks_LimitIsCapped(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	mockRepo.On("Find", mock.Anything, mock.MatchedBy(func(q Domain.TaskQuery) bool {
+		return q.Limit == MaxTaskLimit
+	})).Return(&Domain.TaskPage{}, nil)
+
+	_, err := uc.GetTasks(ctx, owner, Domain.TaskQuery{Limit: 10 * MaxTaskLimit})
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetTasks_InvalidSort(t *testing.T) {
+	uc := NewTaskUseCase(new(MockTaskRepo))
+	_, err := uc.GetTasks(ctx, owner, Domain.TaskQuery{SortBy: "password"})
+	assert.ErrorIs(t, err, ErrInvalidSortField)
+}
+
+func TestGetTasks_InvalidCursor(t *testing.T) {
+	uc := NewTaskUseCase(new(MockTaskRepo))
+	_, err := uc.GetTasks(ctx, owner, Domain.TaskQuery{Cursor: "not-a-cursor"})
+	assert.ErrorIs(t, err, Domain.ErrInvalidCursor)
+}
+
+func TestGetTasks_CursorOfAnotherOrder(t *testing.T) {
+	uc := NewTaskUseCase(new(MockTaskRepo))
+	cursor := Domain.EncodeTaskCursor(Domain.Task{TaskID: Domain.NewID(), Title: "b"}, Domain.SortByTitle, false)
+	for _, q := range []Domain.TaskQuery{
+		{Cursor: cursor},
+		{Cursor: cursor, SortBy: Domain.SortByDueDate},
+		{Cursor: cursor, SortBy: Domain.SortByTitle, SortDesc: true},
+		{Cursor: cursor, SortBy: Domain.SortByUrgency},
+	} {
+		_, err := uc.GetTasks(ctx, owner, q)
+		assert.ErrorIs(t, err, Domain.ErrInvalidCursor, "%+v", q)
+	}
+}
+
 func TestGetTaskByID_Success(t *testing.T) {
 	mockRepo := new(MockTaskRepo)
 	uc := NewTaskUseCase(mockRepo)
 
-	id := primitive.NewObjectID()
-	expected := &Domain.Task{Title: "A"}
-	mockRepo.On("GetByID", id).Return(expected, nil)
+	id := Domain.NewID()
+	expected := &Domain.Task{Title: "A", OwnerID: owner.UserID}
+	mockRepo.On("GetByID", mock.Anything, id).Return(expected, nil)
 
-	result, err := uc.GetTaskByID(id.Hex())
+	result, err := uc.GetTaskByID(ctx, owner, id.String())
 	assert.NoError(t, err)
 	assert.Equal(t, expected, result)
 	mockRepo.AssertExpectations(t)
@@ -84,8 +190,8 @@ func TestGetTaskByID_Success(t *testing.T) {
 
 func TestGetTaskByID_InvalidID(t *testing.T) {
 	uc := NewTaskUseCase(new(MockTaskRepo))
-	_, err := uc.GetTaskByID("badhex")
-	assert.EqualError(t, err, "the provided hex string is not a valid ObjectID")
+	_, err := uc.GetTaskByID(ctx, owner, "badhex")
+	assert.ErrorIs(t, err, Domain.ErrInvalidID)
 }
 
 func TestCreateTask_Success(t *testing.T) {
@@ -93,10 +199,10 @@ func TestCreateTask_Success(t *testing.T) {
 	uc := NewTaskUseCase(mockRepo)
 
 	task := Domain.Task{Title: "T1"}
-	created := &Domain.Task{Title: "T1"}
-	mockRepo.On("Create", task).Return(created, nil)
+	created := &Domain.Task{Title: "T1", OwnerID: owner.UserID}
+	mockRepo.On("Create", mock.Anything, Domain.Task{Title: "T1", OwnerID: owner.UserID, Status: Domain.StatusPending, Priority: Domain.PriorityMedium}).Return(created, nil)
 
-	res, err := uc.CreateTask(task)
+	res, err := uc.CreateTask(ctx, owner, task)
 	assert.NoError(t, err)
 	assert.Equal(t, created, res)
 	mockRepo.AssertExpectations(t)
@@ -107,9 +213,9 @@ func TestCreateTask_Error(t *testing.T) {
 	uc := NewTaskUseCase(mockRepo)
 
 	task := Domain.Task{Title: "Fail"}
-	mockRepo.On("Create", task).Return((*Domain.Task)(nil), errors.New("insert fail"))
+	mockRepo.On("Create", mock.Anything, Domain.Task{Title: "Fail", OwnerID: owner.UserID, Status: Domain.StatusPending, Priority: Domain.PriorityMedium}).Return((*Domain.Task)(nil), errors.New("insert fail"))
 
-	res, err := uc.CreateTask(task)
+	res, err := uc.CreateTask(ctx, owner, task)
 	assert.Nil(t, res)
 	assert.EqualError(t, err, "insert fail")
 }
@@ -118,12 +224,13 @@ func TestUpdateTask_Success(t *testing.T) {
 	mockRepo := new(MockTaskRepo)
 	uc := NewTaskUseCase(mockRepo)
 
-	id := primitive.NewObjectID()
+	id := Domain.NewID()
 	task := Domain.Task{Title: "Upd"}
 	updated := &Domain.Task{Title: "Upd"}
-	mockRepo.On("Update", id, task).Return(updated, nil)
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{OwnerID: owner.UserID}, nil)
+	mockRepo.On("Update", mock.Anything, id, Domain.Task{Title: "Upd", Priority: Domain.PriorityMedium}).Return(updated, nil)
 
-	res, err := uc.UpdateTask(id.Hex(), task)
+	res, err := uc.UpdateTask(ctx, owner, id.String(), 0, task)
 	assert.NoError(t, err)
 	assert.Equal(t, updated, res)
 	mockRepo.AssertExpectations(t)
@@ -133,18 +240,58 @@ func TestUpdateTask_DBError(t *testing.T) {
 	mockRepo := new(MockTaskRepo)
 	uc := NewTaskUseCase(mockRepo)
 
-	id := primitive.NewObjectID()
+	id := Domain.NewID()
 	task := Domain.Task{Title: "Fail"}
-	mockRepo.On("Update", id, task).Return((*Domain.Task)(nil), errors.New("db error"))
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{OwnerID: owner.UserID}, nil)
+	mockRepo.On("Update", mock.Anything, id, Domain.Task{Title: "Fail", Priority: Domain.PriorityMedium}).Return((*Domain.Task)(nil), errors.New("db error"))
 
-	res, err := uc.UpdateTask(id.Hex(), task)
+	res, err := uc.UpdateTask(ctx, owner, id.String(), 0, task)
 	assert.Nil(t, res)
 	assert.EqualError(t, err, "db error")
 }
 
+func TestCreateTask_ValidationErrors(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	uc.now = func() time.Time { return now }
+
+	_, err := uc.CreateTask(ctx, owner, Domain.Task{
+		Title:       "   ",
+		Description: strings.Repeat("x", Domain.MaxTaskDescriptionLength+1),
+		DueDate:     now.Add(-time.Hour),
+	})
+	var ve *Domain.ValidationError
+	require.ErrorAs(t, err, &ve)
+	assert.ErrorIs(t, err, Domain.ErrValidation)
+	fields := map[string]string{}
+	for _, f := range ve.Fields {
+		fields[f.Field] = f.Message
+	}
+	assert.Equal(t, map[string]string{
+		"title":       "is required",
+		"description": "must be at most 10000 characters",
+		"due_date":    "must not be before the task's creation",
+	}, fields)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestUpdateTask_DueDateBeforeCreation(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	id := Domain.NewID()
+	created := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{OwnerID: owner.UserID, CreatedAt: created}, nil)
+
+	_, err := uc.UpdateTask(ctx, owner, id.String(), 0, Domain.Task{Title: "T", DueDate: created.Add(-time.Minute)})
+	assert.ErrorIs(t, err, Domain.ErrValidation)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestUpdateTask_InvalidID(t *testing.T) {
 	uc := NewTaskUseCase(new(MockTaskRepo))
-	_, err := uc.UpdateTask("nope", Domain.Task{})
+	_, err := uc.UpdateTask(ctx, owner, "nope", 0, Domain.Task{})
 	assert.EqualError(t, err, "invalid ID format")
 }
 
@@ -152,17 +299,32 @@ func TestDeleteTask_Success(t *testing.T) {
 	mockRepo := new(MockTaskRepo)
 	uc := NewTaskUseCase(mockRepo)
 
-	id := primitive.NewObjectID()
-	mockRepo.On("Delete", id).Return(nil)
+	id := Domain.NewID()
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{OwnerID: owner.UserID}, nil)
+	mockRepo.On("Delete", mock.Anything, id, int64(0)).Return(nil)
+	mockRepo.On("Find", mock.Anything, Domain.TaskQuery{DependsOn: &id}).Return(&Domain.TaskPage{}, nil)
 
-	err := uc.DeleteTask(id.Hex())
+	err := uc.DeleteTask(ctx, owner, id.String(), 0)
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
 }
 
+func TestDeleteTask_ConcurrentChange(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	id := Domain.NewID()
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{OwnerID: owner.UserID, Version: 3}, nil)
+	mockRepo.On("Delete", mock.Anything, id, int64(3)).Return(Domain.ErrVersionConflict)
+
+	err := uc.DeleteTask(ctx, owner, id.String(), 3)
+	assert.ErrorIs(t, err, Domain.ErrVersionConflict)
+	mockRepo.AssertNotCalled(t, "Find", mock.Anything, mock.Anything)
+}
+
 func TestDeleteTask_InvalidID(t *testing.T) {
 	uc := NewTaskUseCase(new(MockTaskRepo))
-	err := uc.DeleteTask("xxx")
+	err := uc.DeleteTask(ctx, owner, "xxx", 0)
 	assert.EqualError(t, err, "invalid ID format")
 }
 
@@ -170,10 +332,792 @@ func TestGetTaskByID_DBError(t *testing.T) {
 	mockRepo := new(MockTaskRepo)
 	uc := NewTaskUseCase(mockRepo)
 
-	id := primitive.NewObjectID()
-	mockRepo.On("GetByID", id).Return((*Domain.Task)(nil), errors.New("not found"))
+	id := Domain.NewID()
+	mockRepo.On("GetByID", mock.Anything, id).Return((*Domain.Task)(nil), errors.New("not found"))
 
-	res, err := uc.GetTaskByID(id.Hex())
+	res, err := uc.GetTaskByID(ctx, owner, id.String())
 	assert.Nil(t, res)
 	assert.EqualError(t, err, "not found")
 }
+
+func TestGetTasks_AdminSeesAll(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	expected := &Domain.TaskPage{Tasks: []Domain.Task{{Title: "A"}, {Title: "B"}}}
+	mockRepo.On("Find", mock.Anything, mock.MatchedBy(func(q Domain.TaskQuery) bool {
+		return q.OwnerID == nil
+	})).Return(expected, nil)
+
+	result, err := uc.GetTasks(ctx, admin, Domain.TaskQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetTaskByID_NotOwner(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	id := Domain.NewID()
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{OwnerID: Domain.NewID()}, nil)
+
+	res, err := uc.GetTaskByID(ctx, owner, id.String())
+	assert.Nil(t, res)
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+}
+
+func TestGetTaskByID_AdminBypassesOwnership(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	id := Domain.NewID()
+	expected := &Domain.Task{OwnerID: Domain.NewID()}
+	mockRepo.On("GetByID", mock.Anything, id).Return(expected, nil)
+
+	res, err := uc.GetTaskByID(ctx, admin, id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, expected, res)
+}
+
+func TestUpdateTask_NotOwner(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	id := Domain.NewID()
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{OwnerID: Domain.NewID()}, nil)
+
+	_, err := uc.UpdateTask(ctx, owner, id.String(), 0, Domain.Task{Title: "x"})
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestDeleteTask_NotOwner(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	id := Domain.NewID()
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{OwnerID: Domain.NewID()}, nil)
+
+	err := uc.DeleteTask(ctx, owner, id.String(), 0)
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateTask_NormalizesStatus(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(task Domain.Task) bool {
+		return task.Status == Domain.StatusInProgress && task.CompletedAt == nil
+	})).Return(&Domain.Task{}, nil)
+
+	_, err := uc.CreateTask(ctx, owner, Domain.Task{Title: "T", Status: "in_progress"})
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateTask_InvalidStatus(t *testing.T) {
+	uc := NewTaskUseCase(new(MockTaskRepo))
+	_, err := uc.CreateTask(ctx, owner, Domain.Task{Title: "T", Status: "done"})
+	assert.ErrorIs(t, err, ErrInvalidStatus)
+}
+
+func TestUpdateTask_IllegalTransition(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	id := Domain.NewID()
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{OwnerID: owner.UserID, Status: Domain.StatusCancelled}, nil)
+
+	_, err := uc.UpdateTask(ctx, owner, id.String(), 0, Domain.Task{Title: "x", Status: Domain.StatusCompleted})
+	assert.ErrorIs(t, err, ErrIllegalTransition)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestTransitionTask_CompletionStampsCompletedAt(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	now := time.Date(2025, 7, 20, 12, 0, 0, 0, time.UTC)
+	uc := NewTaskUseCase(mockRepo)
+	uc.now = func() time.Time { return now }
+
+	id := Domain.NewID()
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{OwnerID: owner.UserID, Status: Domain.StatusInProgress}, nil)
+	mockRepo.On("Find", mock.Anything, mock.Anything).Return(&Domain.TaskPage{}, nil)
+	mockRepo.On("Update", mock.Anything, id, mock.MatchedBy(func(task Domain.Task) bool {
+		return task.Status == Domain.StatusCompleted && task.CompletedAt != nil && task.CompletedAt.Equal(now)
+	})).Return(&Domain.Task{Status: Domain.StatusCompleted}, nil)
+
+	_, err := uc.TransitionTask(ctx, owner, id.String(), "completed")
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTransitionTask_ReopenClearsCompletedAt(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	id := Domain.NewID()
+	done := time.Now()
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{OwnerID: owner.UserID, Status: Domain.StatusCompleted, CompletedAt: &done}, nil)
+	mockRepo.On("Find", mock.Anything, Domain.TaskQuery{DependsOn: &id}).Return(&Domain.TaskPage{}, nil)
+	mockRepo.On("Update", mock.Anything, id, mock.MatchedBy(func(task Domain.Task) bool {
+		return task.Status == Domain.StatusInProgress && task.CompletedAt == nil
+	})).Return(&Domain.Task{}, nil)
+
+	_, err := uc.TransitionTask(ctx, owner, id.String(), "In Progress")
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTransitionTask_CustomTable(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo, WithTransitions(Domain.TransitionTable{
+		Domain.StatusPending: {Domain.StatusCompleted},
+	}))
+
+	id := Domain.NewID()
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{OwnerID: owner.UserID, Status: Domain.StatusPending}, nil)
+
+	_, err := uc.TransitionTask(ctx, owner, id.String(), "In Progress")
+	assert.ErrorIs(t, err, ErrIllegalTransition)
+}
+
+func TestTransitionTask_UnknownStatus(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	id := Domain.NewID()
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{OwnerID: owner.UserID, Status: Domain.StatusPending}, nil)
+
+	_, err := uc.TransitionTask(ctx, owner, id.String(), "Done-ish")
+	assert.ErrorIs(t, err, ErrInvalidStatus)
+}
+
+func TestPatchTask_OnlyChangesProvidedFields(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	id := Domain.NewID()
+	due := time.Now().Add(24 * time.Hour)
+	existing := &Domain.Task{OwnerID: owner.UserID, Title: "Old", Description: "keep", DueDate: due, Status: Domain.StatusPending}
+	mockRepo.On("GetByID", mock.Anything, id).Return(existing, nil)
+	mockRepo.On("Update", mock.Anything, id, mock.MatchedBy(func(task Domain.Task) bool {
+		return task.Title == "New" && task.Description == "keep" && task.DueDate.Equal(due) && task.Status == Domain.StatusPending
+	})).Return(&Domain.Task{Title: "New"}, nil)
+
+	title := "New"
+	_, err := uc.PatchTask(ctx, owner, id.String(), 0, Domain.TaskPatch{Title: &title})
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPatchTask_ValidatesMergedTask(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	id := Domain.NewID()
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{OwnerID: owner.UserID, Title: "Old"}, nil)
+
+	empty := ""
+	_, err := uc.PatchTask(ctx, owner, id.String(), 0, Domain.TaskPatch{Title: &empty})
+	var ve *Domain.ValidationError
+	require.ErrorAs(t, err, &ve)
+	assert.Equal(t, "title", ve.Fields[0].Field)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPatchTask_StatusFollowsWorkflow(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	id := Domain.NewID()
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{OwnerID: owner.UserID, Title: "t", Status: Domain.StatusCancelled}, nil)
+
+	status := "Completed"
+	_, err := uc.PatchTask(ctx, owner, id.String(), 0, Domain.TaskPatch{Status: &status})
+	assert.ErrorIs(t, err, ErrIllegalTransition)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateTask_StaleVersion(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	id := Domain.NewID()
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{OwnerID: owner.UserID, Version: 3}, nil)
+
+	_, err := uc.UpdateTask(ctx, owner, id.String(), 2, Domain.Task{Title: "x"})
+	assert.ErrorIs(t, err, Domain.ErrVersionMismatch)
+	assert.ErrorIs(t, err, Domain.ErrPrecondition)
+	err = uc.DeleteTask(ctx, owner, id.String(), 2)
+	assert.ErrorIs(t, err, Domain.ErrVersionMismatch)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateTask_ConcurrentWrite(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	id := Domain.NewID()
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{OwnerID: owner.UserID, Version: 3}, nil)
+	mockRepo.On("Update", mock.Anything, id, mock.MatchedBy(func(task Domain.Task) bool {
+		return task.Version == 3
+	})).Return((*Domain.Task)(nil), Domain.ErrVersionConflict)
+
+	_, err := uc.UpdateTask(ctx, owner, id.String(), 3, Domain.Task{Title: "x"})
+	assert.ErrorIs(t, err, Domain.ErrVersionMismatch, "a conditional write reports the failed precondition")
+	_, err = uc.UpdateTask(ctx, owner, id.String(), 0, Domain.Task{Title: "x"})
+	assert.ErrorIs(t, err, Domain.ErrVersionConflict)
+}
+
+func TestTransitionTask_RecordsHistory(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	history := new(MockHistoryRepo)
+	now := time.Date(2025, 7, 20, 12, 0, 0, 0, time.UTC)
+	uc := NewTaskUseCase(mockRepo, WithHistory(history))
+	uc.now = func() time.Time { return now }
+
+	id := Domain.NewID()
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{TaskID: id, OwnerID: owner.UserID, Title: "t", Status: Domain.StatusInProgress}, nil)
+	mockRepo.On("Find", mock.Anything, mock.Anything).Return(&Domain.TaskPage{}, nil)
+	mockRepo.On("Update", mock.Anything, id, mock.Anything).Return(&Domain.Task{TaskID: id, Title: "t", Status: Domain.StatusCompleted, CompletedAt: &now}, nil)
+	history.On("Append", mock.Anything, Domain.HistoryEntry{
+		TaskID:  id,
+		ActorID: owner.UserID,
+		Action:  Domain.HistoryTransitioned,
+		At:      now,
+		Changes: []Domain.FieldChange{
+			{Field: "status", Before: "In Progress", After: "Completed"},
+			{Field: "completed_at", Before: "", After: "2025-07-20T12:00:00Z"},
+		},
+	}).Return(&Domain.HistoryEntry{}, nil)
+
+	_, err := uc.TransitionTask(ctx, owner, id.String(), "completed")
+	require.NoError(t, err)
+	history.AssertExpectations(t)
+}
+
+func TestDeleteTask_HistoryFailureIsReported(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	history := new(MockHistoryRepo)
+	uc := NewTaskUseCase(mockRepo, WithHistory(history))
+
+	id := Domain.NewID()
+	deleted := time.Date(2025, 7, 20, 12, 0, 0, 0, time.UTC)
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{OwnerID: owner.UserID, Title: "t"}, nil).Once()
+	mockRepo.On("Delete", mock.Anything, id, int64(0)).Return(nil)
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{OwnerID: owner.UserID, Title: "t", DeletedAt: &deleted}, nil).Once()
+	history.On("Append", mock.Anything, mock.MatchedBy(func(e Domain.HistoryEntry) bool {
+		return e.Action == Domain.HistoryDeleted && e.TaskID == id &&
+			len(e.Changes) == 1 && e.Changes[0] == Domain.FieldChange{Field: "deleted_at", Before: "", After: "2025-07-20T12:00:00Z"}
+	})).Return((*Domain.HistoryEntry)(nil), errors.New("db error"))
+
+	assert.EqualError(t, uc.DeleteTask(ctx, owner, id.String(), 0), "db error")
+}
+
+func TestAuditLog_AdminOnly(t *testing.T) {
+	history := new(MockHistoryRepo)
+	uc := NewTaskUseCase(new(MockTaskRepo), WithHistory(history))
+
+	_, err := uc.AuditLog(ctx, owner, Domain.HistoryQuery{})
+	assert.ErrorIs(t, err, Domain.ErrForbidden)
+
+	history.On("Find", mock.Anything, Domain.HistoryQuery{Limit: MaxTaskLimit}).Return(&Domain.HistoryPage{}, nil)
+	_, err = uc.AuditLog(ctx, admin, Domain.HistoryQuery{Limit: 1000})
+	assert.NoError(t, err)
+	history.AssertExpectations(t)
+}
+
+func TestDeletedTasksAreHidden(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	id := Domain.NewID()
+	deleted := time.Now()
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{OwnerID: owner.UserID, DeletedAt: &deleted}, nil)
+
+	_, err := uc.GetTaskByID(ctx, owner, id.String())
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	_, err = uc.UpdateTask(ctx, owner, id.String(), 0, Domain.Task{Title: "x"})
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	assert.ErrorIs(t, uc.DeleteTask(ctx, owner, id.String(), 0), Domain.ErrTaskNotFound)
+}
+
+func TestGetTrash_ScopedToOwner(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	mockRepo.On("Find", mock.Anything, mock.MatchedBy(func(q Domain.TaskQuery) bool {
+		return q.Trashed && q.OwnerID != nil && *q.OwnerID == owner.UserID
+	})).Return(&Domain.TaskPage{}, nil)
+
+	_, err := uc.GetTrash(ctx, owner, Domain.TaskQuery{})
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRestoreTask_NotOwner(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	id := Domain.NewID()
+	deleted := time.Now()
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{OwnerID: Domain.NewID(), DeletedAt: &deleted}, nil)
+
+	_, err := uc.RestoreTask(ctx, owner, id.String())
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	mockRepo.AssertNotCalled(t, "Restore", mock.Anything, mock.Anything)
+}
+
+func TestPurgeTask_AdminOnly(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	id := Domain.NewID()
+	assert.ErrorIs(t, uc.PurgeTask(ctx, owner, id.String()), Domain.ErrForbidden)
+
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{OwnerID: owner.UserID}, nil)
+	mockRepo.On("Purge", mock.Anything, id).Return(Domain.ErrTaskNotInTrash)
+	assert.ErrorIs(t, uc.PurgeTask(ctx, admin, id.String()), Domain.ErrTaskNotInTrash)
+}
+
+func TestPurgeExpiredTrash(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	history := new(MockHistoryRepo)
+	now := time.Date(2025, 7, 20, 12, 0, 0, 0, time.UTC)
+	uc := NewTaskUseCase(mockRepo, WithHistory(history))
+	uc.now = func() time.Time { return now }
+
+	acme, globex := Domain.NewID(), Domain.NewID()
+	purged := []Domain.Task{{TaskID: Domain.NewID(), OrgID: acme}, {TaskID: Domain.NewID(), OrgID: globex}}
+	mockRepo.On("PurgeDeletedBefore", mock.Anything, now.Add(-48*time.Hour)).Return(purged, nil)
+	for _, task := range purged {
+		history.On("Append", mock.Anything, mock.MatchedBy(func(e Domain.HistoryEntry) bool {
+			return e.Action == Domain.HistoryPurged && e.ActorID.IsZero() && e.TaskID == task.TaskID && e.OrgID == task.OrgID
+		})).Return(&Domain.HistoryEntry{}, nil).Once()
+	}
+
+	n, err := uc.PurgeExpiredTrash(ctx, 48*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	mockRepo.AssertExpectations(t)
+	history.AssertExpectations(t)
+}
+
+// childrenOf matches the repository query listing the subtasks of id.
+func childrenOf(id Domain.ID) interface{} {
+	return mock.MatchedBy(func(q Domain.TaskQuery) bool { return q.ParentID != nil && *q.ParentID == id })
+}
+
+func TestTransitionTask_OpenSubtasksBlockCompletion(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	id := Domain.NewID()
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{TaskID: id, OwnerID: owner.UserID, Status: Domain.StatusInProgress}, nil)
+	mockRepo.On("Find", mock.Anything, mock.MatchedBy(func(q Domain.TaskQuery) bool {
+		return *q.ParentID == id && len(q.Statuses) == 3 && q.Limit == 1
+	})).Return(&Domain.TaskPage{Tasks: []Domain.Task{{Status: Domain.StatusPending}}}, nil)
+
+	_, err := NewTaskUseCase(mockRepo).TransitionTask(ctx, owner, id.String(), "completed")
+	assert.ErrorIs(t, err, ErrOpenSubtasks)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+
+	mockRepo.On("Update", mock.Anything, id, mock.Anything).Return(&Domain.Task{Status: Domain.StatusCompleted}, nil)
+	_, err = NewTaskUseCase(mockRepo, WithOpenSubtasksAllowed(true)).TransitionTask(ctx, owner, id.String(), "completed")
+	assert.NoError(t, err)
+	mockRepo.AssertNumberOfCalls(t, "Find", 1)
+}
+
+func TestCreateTask_ParentMustBeAccessible(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	missing, foreign := Domain.NewID(), Domain.NewID()
+	mockRepo.On("GetByID", mock.Anything, missing).Return((*Domain.Task)(nil), Domain.ErrTaskNotFound)
+	mockRepo.On("GetByID", mock.Anything, foreign).Return(&Domain.Task{TaskID: foreign, OwnerID: Domain.NewID()}, nil)
+
+	for _, parent := range []Domain.ID{missing, foreign} {
+		_, err := uc.CreateTask(ctx, owner, Domain.Task{Title: "sub", ParentID: &parent})
+		assert.ErrorIs(t, err, ErrInvalidParent)
+	}
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestPatchTask_ParentCannotFormCycle(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	// a <- b <- c: moving a under c or under itself would close a loop
+	a, b, c := Domain.NewID(), Domain.NewID(), Domain.NewID()
+	mockRepo.On("GetByID", mock.Anything, a).Return(&Domain.Task{TaskID: a, OwnerID: owner.UserID, Title: "a"}, nil)
+	mockRepo.On("GetByID", mock.Anything, b).Return(&Domain.Task{TaskID: b, OwnerID: owner.UserID, Title: "b", ParentID: &a}, nil)
+	mockRepo.On("GetByID", mock.Anything, c).Return(&Domain.Task{TaskID: c, OwnerID: owner.UserID, Title: "c", ParentID: &b}, nil)
+
+	for _, parent := range []Domain.ID{c, a} {
+		_, err := uc.PatchTask(ctx, owner, a.String(), 0, Domain.TaskPatch{ParentID: &parent})
+		assert.ErrorIs(t, err, ErrTaskCycle)
+	}
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetTaskTree_RollsUpProgress(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	root, done, half, leafDone, leafOpen, dropped := Domain.NewID(), Domain.NewID(), Domain.NewID(), Domain.NewID(), Domain.NewID(), Domain.NewID()
+	task := func(id Domain.ID, s Domain.TaskStatus) Domain.Task {
+		return Domain.Task{TaskID: id, OwnerID: owner.UserID, Status: s}
+	}
+	mockRepo.On("GetByID", mock.Anything, root).Return(&Domain.Task{TaskID: root, OwnerID: owner.UserID, Status: Domain.StatusInProgress}, nil)
+	mockRepo.On("Find", mock.Anything, childrenOf(root)).Return(&Domain.TaskPage{Tasks: []Domain.Task{
+		task(done, Domain.StatusCompleted), task(half, Domain.StatusInProgress), task(dropped, Domain.StatusCancelled),
+	}}, nil)
+	mockRepo.On("Find", mock.Anything, childrenOf(half)).Return(&Domain.TaskPage{Tasks: []Domain.Task{
+		task(leafDone, Domain.StatusCompleted), task(leafOpen, Domain.StatusPending),
+	}}, nil)
+	mockRepo.On("Find", mock.Anything, mock.Anything).Return(&Domain.TaskPage{}, nil)
+
+	tree, err := uc.GetTaskTree(ctx, owner, root.String(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 75.0, tree.Progress, "cancelled subtasks are not counted")
+	require.Len(t, tree.Children, 3)
+	assert.Equal(t, 100.0, tree.Children[0].Progress)
+	assert.Equal(t, 50.0, tree.Children[1].Progress, "progress is rolled up below the returned depth")
+	assert.True(t, tree.Children[1].Truncated)
+	assert.Empty(t, tree.Children[1].Children)
+	assert.False(t, tree.Children[0].Truncated)
+}
+
+func TestAddDependency_BlocksTaskAndRejectsCycles(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	// b already waits for a
+	a, b, c := Domain.NewID(), Domain.NewID(), Domain.NewID()
+	mockRepo.On("GetByID", mock.Anything, a).Return(&Domain.Task{TaskID: a, OwnerID: owner.UserID, Status: Domain.StatusPending}, nil)
+	mockRepo.On("GetByID", mock.Anything, b).Return(&Domain.Task{TaskID: b, OwnerID: owner.UserID, Status: Domain.StatusBlocked, DependsOn: []Domain.ID{a}}, nil)
+	mockRepo.On("GetByID", mock.Anything, c).Return(&Domain.Task{TaskID: c, OwnerID: owner.UserID, Status: Domain.StatusInProgress}, nil)
+
+	for _, edge := range [][2]Domain.ID{{a, b}, {a, a}} {
+		_, err := uc.AddDependency(ctx, owner, edge[0].String(), edge[1].String())
+		assert.ErrorIs(t, err, Domain.ErrDependencyCycle)
+	}
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+
+	mockRepo.On("Update", mock.Anything, c, mock.MatchedBy(func(task Domain.Task) bool {
+		return task.Status == Domain.StatusBlocked && len(task.DependsOn) == 1 && task.DependsOn[0] == b
+	})).Return(&Domain.Task{TaskID: c, Status: Domain.StatusBlocked}, nil)
+	_, err := uc.AddDependency(ctx, owner, c.String(), b.String())
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTransitionTask_WaitsForDependencies(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	a, b := Domain.NewID(), Domain.NewID()
+	mockRepo.On("GetByID", mock.Anything, a).Return(&Domain.Task{TaskID: a, OwnerID: owner.UserID, Status: Domain.StatusInProgress}, nil)
+	mockRepo.On("GetByID", mock.Anything, b).Return(&Domain.Task{TaskID: b, OwnerID: owner.UserID, Status: Domain.StatusBlocked, DependsOn: []Domain.ID{a}}, nil)
+
+	_, err := uc.TransitionTask(ctx, owner, b.String(), "In Progress")
+	assert.ErrorIs(t, err, ErrBlockedByDependencies)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTransitionTask_CompletionUnblocksDependents(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	a, b := Domain.NewID(), Domain.NewID()
+	blocked := Domain.Task{TaskID: b, OwnerID: owner.UserID, Status: Domain.StatusBlocked, DependsOn: []Domain.ID{a}}
+	mockRepo.On("GetByID", mock.Anything, a).Return(&Domain.Task{TaskID: a, OwnerID: owner.UserID, Status: Domain.StatusInProgress}, nil).Once()
+	mockRepo.On("GetByID", mock.Anything, a).Return(&Domain.Task{TaskID: a, OwnerID: owner.UserID, Status: Domain.StatusCompleted}, nil)
+	mockRepo.On("Find", mock.Anything, childrenOf(a)).Return(&Domain.TaskPage{}, nil)
+	mockRepo.On("Update", mock.Anything, a, mock.Anything).Return(&Domain.Task{TaskID: a, Status: Domain.StatusCompleted}, nil)
+	mockRepo.On("Find", mock.Anything, Domain.TaskQuery{DependsOn: &a}).Return(&Domain.TaskPage{Tasks: []Domain.Task{blocked}}, nil)
+	mockRepo.On("Update", mock.Anything, b, mock.MatchedBy(func(task Domain.Task) bool {
+		return task.Status == Domain.StatusPending
+	})).Return(&Domain.Task{TaskID: b, Status: Domain.StatusPending}, nil)
+
+	_, err := uc.TransitionTask(ctx, owner, a.String(), "Completed")
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPlanTasks_PullsInUnfinishedDependencies(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	// c waits for b, which waits for a; done is finished and left out
+	a, b, c, done := Domain.NewID(), Domain.NewID(), Domain.NewID(), Domain.NewID()
+	created := time.Now()
+	for _, task := range []Domain.Task{
+		{TaskID: a, CreatedAt: created.Add(2 * time.Second)},
+		{TaskID: b, CreatedAt: created.Add(time.Second), DependsOn: []Domain.ID{a}},
+		{TaskID: c, CreatedAt: created, DependsOn: []Domain.ID{b, done}},
+		{TaskID: done, Status: Domain.StatusCompleted},
+	} {
+		task.OwnerID = owner.UserID
+		mockRepo.On("GetByID", mock.Anything, task.TaskID).Return(&task, nil)
+	}
+
+	plan, err := uc.PlanTasks(ctx, owner, []string{c.String(), a.String()})
+	require.NoError(t, err)
+	var order []Domain.ID
+	for _, task := range plan {
+		order = append(order, task.TaskID)
+	}
+	assert.Equal(t, []Domain.ID{a, b, c}, order)
+}
+
+func TestCreateTask_Recurrence(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+	due := time.Now().Add(time.Hour)
+
+	mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(task Domain.Task) bool {
+		return task.Recurrence == "FREQ=WEEKLY;BYDAY=MO,WE" && task.Occurrence == 1
+	})).Return(&Domain.Task{}, nil)
+	_, err := uc.CreateTask(ctx, owner, Domain.Task{Title: "T", DueDate: due, Recurrence: "rrule:freq=weekly;byday=mo,we"})
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+
+	for _, task := range []Domain.Task{
+		{Title: "T", DueDate: due, Recurrence: "FREQ=YEARLY"},
+		{Title: "T", DueDate: due, Recurrence: "FREQ=DAILY;COUNT=2;UNTIL=20300101"},
+		{Title: "T", Recurrence: "FREQ=DAILY"},
+	} {
+		_, err := uc.CreateTask(ctx, owner, task)
+		var ve *Domain.ValidationError
+		require.ErrorAs(t, err, &ve, task.Recurrence)
+		assert.Equal(t, "recurrence", ve.Fields[0].Field)
+	}
+}
+
+func TestPreviewOccurrences(t *testing.T) {
+	at := func(month time.Month, day int) time.Time {
+		return time.Date(2025, month, day, 9, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		rule       string
+		due        time.Time
+		occurrence int
+		want       []time.Time
+	}{
+		{"FREQ=DAILY;INTERVAL=2", at(7, 7), 1, []time.Time{at(7, 9), at(7, 11), at(7, 13)}},
+		{"FREQ=WEEKLY;BYDAY=MO,WE", at(7, 7), 1, []time.Time{at(7, 9), at(7, 14), at(7, 16)}},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=FR", at(7, 7), 1, []time.Time{at(7, 11), at(7, 25), at(8, 8)}},
+		{"FREQ=MONTHLY;BYDAY=-1FR", at(7, 25), 1, []time.Time{at(8, 29), at(9, 26), at(10, 31)}},
+		{"FREQ=MONTHLY", at(1, 31), 1, []time.Time{at(3, 31), at(5, 31), at(7, 31)}},
+		{"FREQ=DAILY;COUNT=4", at(7, 7), 2, []time.Time{at(7, 8), at(7, 9)}},
+		{"FREQ=DAILY;UNTIL=20250709", at(7, 7), 1, []time.Time{at(7, 8), at(7, 9)}},
+		{"", at(7, 7), 0, []time.Time{}},
+	}
+	for _, tt := range tests {
+		mockRepo := new(MockTaskRepo)
+		uc := NewTaskUseCase(mockRepo)
+		id := Domain.NewID()
+		mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{
+			TaskID: id, OwnerID: owner.UserID, DueDate: tt.due, Recurrence: tt.rule, Occurrence: tt.occurrence,
+		}, nil)
+
+		got, err := uc.PreviewOccurrences(ctx, owner, id.String(), 3)
+		require.NoError(t, err, tt.rule)
+		assert.Equal(t, tt.want, got, tt.rule)
+	}
+}
+
+func TestTransitionTask_CompletingRecurringTaskCreatesNext(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	id, parent := Domain.NewID(), Domain.NewID()
+	monday := time.Date(2025, 7, 7, 9, 0, 0, 0, time.UTC)
+	uc.now = func() time.Time { return monday }
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{
+		TaskID: id, OwnerID: owner.UserID, Title: "Standup", DueDate: monday, ParentID: &parent,
+		Status: Domain.StatusInProgress, Recurrence: "FREQ=WEEKLY;BYDAY=MO,WE", Occurrence: 1,
+	}, nil)
+	mockRepo.On("Find", mock.Anything, childrenOf(id)).Return(&Domain.TaskPage{}, nil)
+	mockRepo.On("Update", mock.Anything, id, mock.MatchedBy(func(task Domain.Task) bool {
+		return task.Status == Domain.StatusCompleted && task.Recurrence == ""
+	})).Return(&Domain.Task{TaskID: id, Status: Domain.StatusCompleted}, nil)
+	mockRepo.On("Find", mock.Anything, Domain.TaskQuery{DependsOn: &id}).Return(&Domain.TaskPage{}, nil)
+	mockRepo.On("Create", mock.Anything, Domain.Task{
+		OwnerID: owner.UserID, Title: "Standup", DueDate: monday.AddDate(0, 0, 2), ParentID: &parent,
+		Status: Domain.StatusPending, Recurrence: "FREQ=WEEKLY;BYDAY=MO,WE", Occurrence: 2,
+	}).Return(&Domain.Task{TaskID: Domain.NewID()}, nil)
+
+	_, err := uc.TransitionTask(ctx, owner, id.String(), "Completed")
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTransitionTask_OverdueRecurringTaskSkipsPastDates(t *testing.T) {
+	repo := Repositories.NewInMemoryTaskRepository()
+	uc := NewTaskUseCase(repo)
+
+	overdue := time.Now().Add(-72 * time.Hour)
+	task, err := repo.Create(ctx, Domain.Task{
+		OwnerID: owner.UserID, Title: "Water plants", DueDate: overdue, Status: Domain.StatusPending,
+		Recurrence: "FREQ=DAILY", Occurrence: 1,
+	})
+	require.NoError(t, err)
+
+	_, err = uc.TransitionTask(ctx, owner, task.TaskID.String(), "Completed")
+	require.NoError(t, err)
+	page, err := repo.Find(ctx, Domain.TaskQuery{Statuses: []Domain.TaskStatus{Domain.StatusPending}})
+	require.NoError(t, err)
+	require.Len(t, page.Tasks, 1)
+	next := page.Tasks[0]
+	assert.True(t, next.DueDate.After(next.CreatedAt))
+	assert.Equal(t, 5, next.Occurrence)
+
+	title := "Water the plants"
+	_, err = uc.PatchTask(ctx, owner, next.TaskID.String(), 0, Domain.TaskPatch{Title: &title})
+	require.NoError(t, err)
+}
+
+func TestTransitionTask_RecurrenceEndsAtCount(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	id := Domain.NewID()
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{
+		TaskID: id, OwnerID: owner.UserID, DueDate: time.Now(), Status: Domain.StatusPending,
+		Recurrence: "FREQ=DAILY;COUNT=3", Occurrence: 3,
+	}, nil)
+	mockRepo.On("Find", mock.Anything, mock.Anything).Return(&Domain.TaskPage{}, nil)
+	mockRepo.On("Update", mock.Anything, id, mock.Anything).Return(&Domain.Task{TaskID: id, Status: Domain.StatusCompleted}, nil)
+
+	_, err := uc.TransitionTask(ctx, owner, id.String(), "Completed")
+	require.NoError(t, err)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestAddTags_NormalizesAndValidates(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	id := Domain.NewID()
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{TaskID: id, OwnerID: owner.UserID, Title: "T", Tags: []string{"ops"}}, nil)
+	mockRepo.On("Update", mock.Anything, id, mock.MatchedBy(func(task Domain.Task) bool {
+		return assert.ObjectsAreEqual([]string{"bug-fix", "ops", "ui"}, task.Tags)
+	})).Return(&Domain.Task{TaskID: id}, nil).Once()
+
+	_, err := uc.AddTags(ctx, owner, id.String(), []string{" Bug  Fix", "UI", "ops", "ui"})
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+
+	_, err = uc.AddTags(ctx, owner, id.String(), []string{"no/slashes"})
+	var ve *Domain.ValidationError
+	require.ErrorAs(t, err, &ve)
+	assert.Equal(t, "tags", ve.Fields[0].Field)
+}
+
+func TestRemoveTag(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	id := Domain.NewID()
+	mockRepo.On("GetByID", mock.Anything, id).Return(&Domain.Task{TaskID: id, OwnerID: owner.UserID, Tags: []string{"bug", "ui"}}, nil)
+	mockRepo.On("Update", mock.Anything, id, mock.MatchedBy(func(task Domain.Task) bool {
+		return assert.ObjectsAreEqual([]string{"ui"}, task.Tags)
+	})).Return(&Domain.Task{TaskID: id}, nil)
+
+	_, err := uc.RemoveTag(ctx, owner, id.String(), "BUG")
+	require.NoError(t, err)
+	_, err = uc.RemoveTag(ctx, owner, id.String(), "ops")
+	assert.ErrorIs(t, err, ErrTagNotFound)
+}
+
+func TestGetTags_ScopedToActor(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	counts := []Domain.TagCount{{Name: "bug", Count: 2}}
+	mockRepo.On("CountTags", mock.Anything, &admin.UserID).Return(counts, nil)
+
+	got, err := uc.GetTags(ctx, admin)
+	require.NoError(t, err)
+	assert.Equal(t, counts, got)
+}
+
+func TestUrgencyScore(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo, WithUrgencyWeights(Domain.UrgencyWeights{Priority: 4, DueDate: 10, Age: 2, Blocked: -3}))
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	uc.now = func() time.Time { return now }
+
+	tests := []struct {
+		name string
+		task Domain.Task
+		want float64
+	}{
+		{"medium, no due date, new", Domain.Task{Priority: Domain.PriorityMedium, CreatedAt: now}, 2},
+		{"critical, overdue", Domain.Task{Priority: Domain.PriorityCritical, CreatedAt: now, DueDate: now.Add(-time.Hour)}, 14},
+		{"low, due in a week", Domain.Task{Priority: Domain.PriorityLow, CreatedAt: now, DueDate: now.AddDate(0, 0, 7)}, 7},
+		{"high, due far ahead", Domain.Task{Priority: Domain.PriorityHigh, CreatedAt: now, DueDate: now.AddDate(1, 0, 0)}, 5},
+		{"blocked, 45 days old", Domain.Task{Priority: Domain.PriorityLow, CreatedAt: now.AddDate(0, 0, -45), Status: Domain.StatusBlocked}, -1},
+		{"completed", Domain.Task{Priority: Domain.PriorityCritical, CreatedAt: now, DueDate: now, Status: Domain.StatusCompleted}, 0},
+	}
+	for _, tt := range tests {
+		id := Domain.NewID()
+		tt.task.TaskID, tt.task.OwnerID = id, owner.UserID
+		mockRepo.On("GetByID", mock.Anything, id).Return(&tt.task, nil)
+
+		got, err := uc.GetTaskByID(ctx, owner, id.String())
+		require.NoError(t, err, tt.name)
+		assert.Equal(t, tt.want, got.UrgencyScore, tt.name)
+	}
+}
+
+func TestGetTasks_SortByUrgency(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+	now := time.Now()
+
+	var tasks []Domain.Task
+	for _, p := range []Domain.Priority{Domain.PriorityLow, Domain.PriorityCritical, Domain.PriorityMedium, Domain.PriorityHigh} {
+		tasks = append(tasks, Domain.Task{TaskID: Domain.NewID(), OwnerID: owner.UserID, Priority: p, CreatedAt: now})
+	}
+	mockRepo.On("Find", mock.Anything, mock.MatchedBy(func(q Domain.TaskQuery) bool {
+		return q.SortBy == Domain.SortByCreatedAt && q.Limit == urgencyScanChunk && q.Cursor == ""
+	})).Return(&Domain.TaskPage{Tasks: tasks[:2], NextCursor: "more"}, nil)
+	mockRepo.On("Find", mock.Anything, mock.MatchedBy(func(q Domain.TaskQuery) bool {
+		return q.Cursor == "more"
+	})).Return(&Domain.TaskPage{Tasks: tasks[2:]}, nil)
+
+	var order []Domain.Priority
+	q := Domain.TaskQuery{SortBy: Domain.SortByUrgency, SortDesc: true, Limit: 3}
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 3)
+		page, err := uc.GetTasks(ctx, owner, q)
+		require.NoError(t, err)
+		for _, task := range page.Tasks {
+			order = append(order, task.Priority)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+	}
+	assert.Equal(t, []Domain.Priority{Domain.PriorityCritical, Domain.PriorityHigh, Domain.PriorityMedium, Domain.PriorityLow}, order)
+}
+
+func TestGetTasks_SortByUrgencyIsBounded(t *testing.T) {
+	mockRepo := new(MockTaskRepo)
+	uc := NewTaskUseCase(mockRepo)
+
+	chunk := make([]Domain.Task, urgencyScanChunk)
+	for i := range chunk {
+		chunk[i] = Domain.Task{TaskID: Domain.NewID(), OwnerID: owner.UserID}
+	}
+	mockRepo.On("Find", mock.Anything, mock.Anything).Return(&Domain.TaskPage{Tasks: chunk, NextCursor: "more"}, nil)
+
+	_, err := uc.GetTasks(ctx, owner, Domain.TaskQuery{SortBy: Domain.SortByUrgency})
+	assert.ErrorIs(t, err, ErrTooManyToRank)
+	mockRepo.AssertNumberOfCalls(t, "Find", MaxUrgencyScan/urgencyScanChunk+1)
+}
@@ -0,0 +1,118 @@
+package Usecases
+
+import (
+	"context"
+	"sort"
+
+	domain "github.com/surafelbkassa/go-task-manager/Domain"
+)
+
+// MaxUrgencyScan is the most tasks a query sorted by urgency may match.
+// They are read from the repository urgencyScanChunk at a time.
+const (
+	MaxUrgencyScan   = 5000
+	urgencyScanChunk = 500
+)
+
+var ErrTooManyToRank = domain.NewError(domain.ErrValidation, "too_many_tasks_to_rank", "too many matching tasks to sort by urgency; narrow the filters")
+
+// scoredRepository sets the urgency of every task read through it, so all
+// tasks the use case returns carry a current score.
+type scoredRepository struct {
+	domain.TaskRepository
+	u *TaskUseCase
+}
+
+func (r scoredRepository) score(t *domain.Task) {
+	t.UrgencyScore = t.Urgency(r.u.now(), r.u.urgency)
+}
+
+func (r scoredRepository) Find(ctx context.Context, q domain.TaskQuery) (*domain.TaskPage, error) {
+	page, err := r.TaskRepository.Find(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	for i := range page.Tasks {
+		r.score(&page.Tasks[i])
+	}
+	return page, nil
+}
+
+func (r scoredRepository) GetByID(ctx context.Context, id domain.ID) (*domain.Task, error) {
+	return r.scored(r.TaskRepository.GetByID(ctx, id))
+}
+
+func (r scoredRepository) Create(ctx context.Context, task domain.Task) (*domain.Task, error) {
+	return r.scored(r.TaskRepository.Create(ctx, task))
+}
+
+func (r scoredRepository) Update(ctx context.Context, id domain.ID, task domain.Task) (*domain.Task, error) {
+	return r.scored(r.TaskRepository.Update(ctx, id, task))
+}
+
+func (r scoredRepository) Restore(ctx context.Context, id domain.ID) (*domain.Task, error) {
+	return r.scored(r.TaskRepository.Restore(ctx, id))
+}
+
+func (r scoredRepository) scored(t *domain.Task, err error) (*domain.Task, error) {
+	if err != nil {
+		return nil, err
+	}
+	r.score(t)
+	return t, nil
+}
+
+// findByUrgency serves a query sorted by urgency. Scores change with time,
+// so every matching task is loaded, scored and sorted here, ties broken by
+// ID; a query matching more than MaxUrgencyScan tasks fails with
+// ErrTooManyToRank. A cursor resumes after the score and ID it holds; a
+// task whose score moved past the cursor since the previous page can be
+// skipped or repeated.
+func (u *TaskUseCase) findByUrgency(ctx context.Context, q domain.TaskQuery) (*domain.TaskPage, error) {
+	limit, cursor, desc := q.Limit, q.Cursor, q.SortDesc
+	q.SortBy, q.SortDesc, q.Cursor, q.Limit = domain.SortByCreatedAt, false, "", urgencyScanChunk
+	var tasks []domain.Task
+	for {
+		page, err := u.repo.Find(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, page.Tasks...)
+		if len(tasks) > MaxUrgencyScan {
+			return nil, ErrTooManyToRank
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+	}
+	// precedes reports whether t comes before the position (score, id)
+	precedes := func(t domain.Task, score float64, id domain.ID) bool {
+		if t.UrgencyScore != score {
+			return (t.UrgencyScore < score) != desc
+		}
+		return (t.TaskID < id) != desc
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return precedes(tasks[i], tasks[j].UrgencyScore, tasks[j].TaskID)
+	})
+	if cursor != "" {
+		c, err := domain.DecodeTaskCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		score, err := c.FloatValue()
+		if err != nil {
+			return nil, err
+		}
+		for len(tasks) > 0 && (precedes(tasks[0], score, c.ID) || tasks[0].TaskID == c.ID) {
+			tasks = tasks[1:]
+		}
+	}
+	out := &domain.TaskPage{Tasks: tasks}
+	if len(tasks) > limit {
+		out.Tasks = tasks[:limit]
+		out.NextCursor = domain.EncodeTaskCursor(out.Tasks[limit-1], domain.SortByUrgency, desc)
+	}
+	return out, nil
+}
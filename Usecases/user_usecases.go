@@ -1,62 +1,97 @@
 package Usecases
 
 import (
+	"context"
 	"errors"
 
 	domain "github.com/surafelbkassa/go-task-manager/Domain"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+var ErrInvalidCredentials = domain.NewError(domain.ErrUnauthorized, "invalid_credentials", "invalid credentials")
+
 type UserUseCaseInterface interface {
-	RegisterUser(name, email, password string) error
-	LoginUser(email, password string) (*domain.User, error)
-	PromoteUser(userID primitive.ObjectID) (*domain.User, error)
+	RegisterUser(ctx context.Context, name, email, password string) error
+	LoginUser(ctx context.Context, email, password string) (*domain.User, error)
+	PromoteUser(ctx context.Context, actor domain.Actor, userID domain.ID) (*domain.User, error)
 }
 
 // UserUseCase implements user business rules
 type UserUseCase struct {
 	repo   domain.UserRepository
+	orgs   domain.OrganizationRepository
 	hasher domain.PasswordHasher
 }
 
 // NewUserUseCase constructor
-func NewUserUseCase(r domain.UserRepository, h domain.PasswordHasher) *UserUseCase {
-	return &UserUseCase{repo: r, hasher: h}
+func NewUserUseCase(r domain.UserRepository, o domain.OrganizationRepository, h domain.PasswordHasher) *UserUseCase {
+	return &UserUseCase{repo: r, orgs: o, hasher: h}
 }
 
-func (uc *UserUseCase) RegisterUser(name, email, password string) error {
+// RegisterUser creates a user with their personal organization. Emails
+// are unique across organizations.
+func (uc *UserUseCase) RegisterUser(ctx context.Context, name, email, password string) error {
+	if err := domain.ValidateRegistration(name, email, password); err != nil {
+		return err
+	}
+	ctx = domain.WithoutTenant(ctx)
 	// check if user email already exists
-	existing, _ := uc.repo.GetByEmail(email)
+	existing, err := uc.repo.GetByEmail(ctx, email)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return err
+	}
 	if existing != nil {
-		return errors.New("email already registered")
+		return domain.ErrDuplicateEmail
 	}
 	// hash password
 	hashedPassword, err := uc.hasher.HashPassword(password)
 	if err != nil {
 		return err
 	}
+	// the user is a plain user everywhere until promoted in an organization
 	user := &domain.User{
 		Name:     name,
 		Email:    email,
 		Password: hashedPassword,
-		Role:     "user", // default role
 	}
 
-	_, err = uc.repo.Create(*user)
+	created, err := uc.repo.Create(ctx, *user)
+	if err != nil {
+		return err
+	}
+	_, err = uc.joinPersonalOrganization(ctx, created)
 	return err
 }
 
-func (uc *UserUseCase) LoginUser(email, password string) (*domain.User, error) {
-	user, err := uc.repo.GetByEmail(email)
-	if err != nil {
-		return nil, errors.New("invalid credentials")
+// LoginUser looks the user up in every organization, as the one to sign
+// in to is only chosen afterwards.
+func (uc *UserUseCase) LoginUser(ctx context.Context, email, password string) (*domain.User, error) {
+	ctx = domain.WithoutTenant(ctx)
+	user, err := uc.repo.GetByEmail(ctx, email)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
 	}
 	if user == nil || !uc.hasher.CheckPasswordHash(password, user.Password) {
-		return nil, errors.New("invalid credentials")
+		return nil, ErrInvalidCredentials
+	}
+	if len(user.OrgIDs) == 0 {
+		// registration stopped before the personal organization was set up
+		return uc.joinPersonalOrganization(ctx, user)
 	}
 	return user, nil
 }
 
-func (uc *UserUseCase) PromoteUser(userID primitive.ObjectID) (*domain.User, error) {
-	return uc.repo.PromoteUser(userID)
+// joinPersonalOrganization creates the user's personal organization,
+// unless it already exists, and makes them a member of it.
+func (uc *UserUseCase) joinPersonalOrganization(ctx context.Context, user *domain.User) (*domain.User, error) {
+	_, err := uc.orgs.Create(ctx, domain.Organization{ID: user.UserID, OwnerID: user.UserID, Name: user.Name})
+	if err != nil && !errors.Is(err, domain.ErrOrganizationExists) {
+		return nil, err
+	}
+	return uc.repo.AddOrganization(ctx, user.UserID, user.UserID)
+}
+
+// PromoteUser makes a member of the actor's organization an admin of it,
+// and of no other.
+func (uc *UserUseCase) PromoteUser(ctx context.Context, actor domain.Actor, userID domain.ID) (*domain.User, error) {
+	return uc.repo.PromoteUser(ctx, userID, actor.OrgID)
 }
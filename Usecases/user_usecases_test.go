@@ -1,13 +1,15 @@
 package Usecases
 
 import (
+	"context"
 	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	Domain "github.com/surafelbkassa/go-task-manager/Domain"
-	"go.mongodb.org/mongo-driver/bson/primitive"
+	"github.com/surafelbkassa/go-task-manager/Repositories"
 )
 
 // --- Mock UserRepository ---
@@ -15,8 +17,12 @@ type MockUserRepo struct {
 	mock.Mock
 }
 
-func (m *MockUserRepo) GetByID(id primitive.ObjectID) (*Domain.User, error) {
-	args := m.Called(id)
+func (m *MockUserRepo) Ping(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+func (m *MockUserRepo) GetByID(ctx context.Context, id Domain.ID) (*Domain.User, error) {
+	args := m.Called(ctx, id)
 	user := args.Get(0)
 	if user == nil {
 		return nil, args.Error(1)
@@ -24,8 +30,8 @@ func (m *MockUserRepo) GetByID(id primitive.ObjectID) (*Domain.User, error) {
 	return user.(*Domain.User), args.Error(1)
 }
 
-func (m *MockUserRepo) Create(u Domain.User) (*Domain.User, error) {
-	args := m.Called(u)
+func (m *MockUserRepo) Create(ctx context.Context, u Domain.User) (*Domain.User, error) {
+	args := m.Called(ctx, u)
 	user := args.Get(0)
 	if user == nil {
 		return nil, args.Error(1)
@@ -33,8 +39,8 @@ func (m *MockUserRepo) Create(u Domain.User) (*Domain.User, error) {
 	return user.(*Domain.User), args.Error(1)
 }
 
-func (m *MockUserRepo) GetByEmail(email string) (*Domain.User, error) {
-	args := m.Called(email)
+func (m *MockUserRepo) GetByEmail(ctx context.Context, email string) (*Domain.User, error) {
+	args := m.Called(ctx, email)
 	user := args.Get(0)
 	if user == nil {
 		return nil, args.Error(1)
@@ -42,8 +48,8 @@ func (m *MockUserRepo) GetByEmail(email string) (*Domain.User, error) {
 	return user.(*Domain.User), args.Error(1)
 }
 
-func (m *MockUserRepo) PromoteUser(id primitive.ObjectID) (*Domain.User, error) {
-	args := m.Called(id)
+func (m *MockUserRepo) PromoteUser(ctx context.Context, id, org Domain.ID) (*Domain.User, error) {
+	args := m.Called(ctx, id, org)
 	user := args.Get(0)
 	if user == nil {
 		return nil, args.Error(1)
@@ -51,8 +57,8 @@ func (m *MockUserRepo) PromoteUser(id primitive.ObjectID) (*Domain.User, error)
 	return user.(*Domain.User), args.Error(1)
 }
 
-func (m *MockUserRepo) GetAll() ([]*Domain.User, error) {
-	args := m.Called()
+func (m *MockUserRepo) GetAll(ctx context.Context) ([]*Domain.User, error) {
+	args := m.Called(ctx)
 	users := args.Get(0)
 	if users == nil {
 		return nil, args.Error(1)
@@ -60,6 +66,24 @@ func (m *MockUserRepo) GetAll() ([]*Domain.User, error) {
 	return users.([]*Domain.User), args.Error(1)
 }
 
+func (m *MockUserRepo) AddOrganization(ctx context.Context, id, org Domain.ID) (*Domain.User, error) {
+	args := m.Called(ctx, id, org)
+	user := args.Get(0)
+	if user == nil {
+		return nil, args.Error(1)
+	}
+	return user.(*Domain.User), args.Error(1)
+}
+
+func (m *MockUserRepo) RemoveOrganization(ctx context.Context, id, org Domain.ID) (*Domain.User, error) {
+	args := m.Called(ctx, id, org)
+	user := args.Get(0)
+	if user == nil {
+		return nil, args.Error(1)
+	}
+	return user.(*Domain.User), args.Error(1)
+}
+
 // --- Mock PasswordHasher ---
 type MockHasher struct {
 	mock.Mock
@@ -80,72 +104,140 @@ func (m *MockHasher) CheckPasswordHash(password, hash string) bool {
 func TestRegisterUser_Success(t *testing.T) {
 	mockRepo := new(MockUserRepo)
 	mockHash := new(MockHasher)
-	uc := NewUserUseCase(mockRepo, mockHash)
+	orgs := Repositories.NewInMemoryOrganizationRepository()
+	uc := NewUserUseCase(mockRepo, orgs, mockHash)
+	id := Domain.NewID()
 
-	mockRepo.On("GetByEmail", "a@b.com").Return(nil, nil)
-	mockHash.On("HashPassword", "pw").Return("hashed", nil)
-	mockRepo.On("Create", Domain.User{
+	mockRepo.On("GetByEmail", mock.Anything, "a@b.com").Return(nil, nil)
+	mockHash.On("HashPassword", "password1").Return("hashed", nil)
+	mockRepo.On("Create", mock.Anything, Domain.User{
 		Name:     "Alice",
 		Email:    "a@b.com",
 		Password: "hashed",
-		Role:     "user",
-	}).Return(&Domain.User{Email: "a@b.com"}, nil)
+	}).Return(&Domain.User{UserID: id, Email: "a@b.com"}, nil)
+	mockRepo.On("AddOrganization", mock.Anything, id, id).Return(&Domain.User{UserID: id, OrgIDs: []Domain.ID{id}}, nil)
 
-	err := uc.RegisterUser("Alice", "a@b.com", "pw")
+	err := uc.RegisterUser(ctx, "Alice", "a@b.com", "password1")
 	assert.NoError(t, err)
 
 	mockRepo.AssertExpectations(t)
 	mockHash.AssertExpectations(t)
+
+	// every user gets a personal organization sharing their ID
+	org, err := orgs.GetByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, id, org.OwnerID)
+	assert.True(t, org.Personal())
 }
 
 func TestRegisterUser_ExistingEmail(t *testing.T) {
 	mockRepo := new(MockUserRepo)
 	mockHash := new(MockHasher)
-	uc := NewUserUseCase(mockRepo, mockHash)
+	uc := NewUserUseCase(mockRepo, Repositories.NewInMemoryOrganizationRepository(), mockHash)
 
-	mockRepo.On("GetByEmail", "a@b.com").Return(&Domain.User{}, nil)
+	mockRepo.On("GetByEmail", mock.Anything, "a@b.com").Return(&Domain.User{}, nil)
 
-	err := uc.RegisterUser("Alice", "a@b.com", "pw")
-	assert.EqualError(t, err, "email already registered")
+	err := uc.RegisterUser(ctx, "Alice", "a@b.com", "password1")
+	assert.ErrorIs(t, err, Domain.ErrDuplicateEmail)
+	assert.ErrorIs(t, err, Domain.ErrConflict)
+}
+
+func TestRegisterUser_Invalid(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	uc := NewUserUseCase(mockRepo, Repositories.NewInMemoryOrganizationRepository(), new(MockHasher))
+
+	err := uc.RegisterUser(ctx, "", "Alice <a@b.com>", "short")
+	var ve *Domain.ValidationError
+	require.ErrorAs(t, err, &ve)
+	assert.Equal(t, []Domain.FieldError{
+		{Field: "name", Message: "is required"},
+		{Field: "email", Message: "must be a valid email address"},
+		{Field: "password", Message: "must be at least 8 characters"},
+	}, ve.Fields)
+	mockRepo.AssertNotCalled(t, "GetByEmail", mock.Anything, mock.Anything)
+}
+
+func TestRegisterUser_StorageError(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	mockHash := new(MockHasher)
+	uc := NewUserUseCase(mockRepo, Repositories.NewInMemoryOrganizationRepository(), mockHash)
+
+	mockRepo.On("GetByEmail", mock.Anything, "a@b.com").Return(nil, errors.New("connection refused"))
+
+	err := uc.RegisterUser(ctx, "Alice", "a@b.com", "password1")
+	assert.EqualError(t, err, "connection refused")
+	mockHash.AssertNotCalled(t, "HashPassword", "password1")
 }
 
 func TestLoginUser_Success(t *testing.T) {
 	mockRepo := new(MockUserRepo)
 	mockHash := new(MockHasher)
-	uc := NewUserUseCase(mockRepo, mockHash)
+	uc := NewUserUseCase(mockRepo, Repositories.NewInMemoryOrganizationRepository(), mockHash)
 
-	stored := &Domain.User{Password: "hash"}
-	mockRepo.On("GetByEmail", "e@x.com").Return(stored, nil)
+	stored := &Domain.User{Password: "hash", OrgIDs: []Domain.ID{Domain.NewID()}}
+	mockRepo.On("GetByEmail", mock.Anything, "e@x.com").Return(stored, nil)
 	mockHash.On("CheckPasswordHash", "pw", "hash").Return(true)
 
-	user, err := uc.LoginUser("e@x.com", "pw")
+	user, err := uc.LoginUser(ctx, "e@x.com", "pw")
 	assert.NoError(t, err)
 	assert.Equal(t, stored, user)
 }
 
+func TestLoginUser_JoinsPersonalOrganization(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	mockHash := new(MockHasher)
+	orgs := Repositories.NewInMemoryOrganizationRepository()
+	uc := NewUserUseCase(mockRepo, orgs, mockHash)
+
+	id := Domain.NewID()
+	joined := &Domain.User{UserID: id, OrgIDs: []Domain.ID{id}}
+	mockRepo.On("GetByEmail", mock.Anything, "e@x.com").Return(&Domain.User{UserID: id, Name: "Eve", Password: "hash"}, nil)
+	mockHash.On("CheckPasswordHash", "pw", "hash").Return(true)
+	mockRepo.On("AddOrganization", mock.Anything, id, id).Return(joined, nil)
+
+	user, err := uc.LoginUser(ctx, "e@x.com", "pw")
+	require.NoError(t, err)
+	assert.Equal(t, joined, user)
+	org, err := orgs.GetByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "Eve", org.Name)
+}
+
 func TestLoginUser_Fail(t *testing.T) {
 	mockRepo := new(MockUserRepo)
 	mockHash := new(MockHasher)
-	uc := NewUserUseCase(mockRepo, mockHash)
+	uc := NewUserUseCase(mockRepo, Repositories.NewInMemoryOrganizationRepository(), mockHash)
 
-	mockRepo.On("GetByEmail", "e@x.com").Return(nil, errors.New("not found"))
+	mockRepo.On("GetByEmail", mock.Anything, "e@x.com").Return(nil, Domain.ErrUserNotFound)
 
-	user, err := uc.LoginUser("e@x.com", "pw")
+	user, err := uc.LoginUser(ctx, "e@x.com", "pw")
 	assert.Nil(t, user)
 	assert.EqualError(t, err, "invalid credentials")
 }
 
+func TestLoginUser_StorageError(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	mockHash := new(MockHasher)
+	uc := NewUserUseCase(mockRepo, Repositories.NewInMemoryOrganizationRepository(), mockHash)
+
+	mockRepo.On("GetByEmail", mock.Anything, "e@x.com").Return(nil, errors.New("connection refused"))
+
+	_, err := uc.LoginUser(ctx, "e@x.com", "pw")
+	assert.EqualError(t, err, "connection refused")
+}
+
 func TestPromoteUser_Success(t *testing.T) {
 	mockRepo := new(MockUserRepo)
 	mockHash := new(MockHasher)
-	uc := NewUserUseCase(mockRepo, mockHash)
+	uc := NewUserUseCase(mockRepo, Repositories.NewInMemoryOrganizationRepository(), mockHash)
 
-	id := primitive.NewObjectID()
+	id := Domain.NewID()
 	expected := &Domain.User{Email: "z@z.com"}
+	actor := Domain.Actor{UserID: Domain.NewID(), Role: "admin", OrgID: Domain.NewID()}
 
-	mockRepo.On("PromoteUser", id).Return(expected, nil)
+	mockRepo.On("PromoteUser", mock.Anything, id, actor.OrgID).Return(expected, nil)
 
-	user, err := uc.PromoteUser(id)
+	user, err := uc.PromoteUser(ctx, actor, id)
 	assert.NoError(t, err)
 	assert.Equal(t, expected, user)
 }
@@ -153,23 +245,23 @@ func TestPromoteUser_Success(t *testing.T) {
 func TestPromoteUser_Error(t *testing.T) {
 	mockRepo := new(MockUserRepo)
 	mockHash := new(MockHasher)
-	uc := NewUserUseCase(mockRepo, mockHash)
+	uc := NewUserUseCase(mockRepo, Repositories.NewInMemoryOrganizationRepository(), mockHash)
 
-	id := primitive.NewObjectID()
-	mockRepo.On("PromoteUser", id).Return(nil, errors.New("oops"))
+	id := Domain.NewID()
+	mockRepo.On("PromoteUser", mock.Anything, id, mock.Anything).Return(nil, errors.New("oops"))
 
-	_, err := uc.PromoteUser(id)
+	_, err := uc.PromoteUser(ctx, admin, id)
 	assert.EqualError(t, err, "oops")
 }
 func TestRegisterUser_HashError(t *testing.T) {
 	mockRepo := new(MockUserRepo)
 	mockHash := new(MockHasher)
-	uc := NewUserUseCase(mockRepo, mockHash)
+	uc := NewUserUseCase(mockRepo, Repositories.NewInMemoryOrganizationRepository(), mockHash)
 
-	mockRepo.On("GetByEmail", "a@b.com").Return(nil, nil)
-	mockHash.On("HashPassword", "pw").Return("", errors.New("hash failed"))
+	mockRepo.On("GetByEmail", mock.Anything, "a@b.com").Return(nil, nil)
+	mockHash.On("HashPassword", "password1").Return("", errors.New("hash failed"))
 
-	err := uc.RegisterUser("Alice", "a@b.com", "pw")
+	err := uc.RegisterUser(ctx, "Alice", "a@b.com", "password1")
 	assert.EqualError(t, err, "hash failed")
 
 	mockRepo.AssertExpectations(t)
@@ -179,13 +271,13 @@ func TestRegisterUser_HashError(t *testing.T) {
 func TestLoginUser_InvalidPassword(t *testing.T) {
 	mockRepo := new(MockUserRepo)
 	mockHash := new(MockHasher)
-	uc := NewUserUseCase(mockRepo, mockHash)
+	uc := NewUserUseCase(mockRepo, Repositories.NewInMemoryOrganizationRepository(), mockHash)
 
 	stored := &Domain.User{Password: "hash"}
-	mockRepo.On("GetByEmail", "e@x.com").Return(stored, nil)
+	mockRepo.On("GetByEmail", mock.Anything, "e@x.com").Return(stored, nil)
 	mockHash.On("CheckPasswordHash", "pw", "hash").Return(false)
 
-	user, err := uc.LoginUser("e@x.com", "pw")
+	user, err := uc.LoginUser(ctx, "e@x.com", "pw")
 	assert.Nil(t, user)
 	assert.EqualError(t, err, "invalid credentials")
 }
@@ -193,11 +285,11 @@ func TestLoginUser_InvalidPassword(t *testing.T) {
 func TestLoginUser_UserNilButNoError(t *testing.T) {
 	mockRepo := new(MockUserRepo)
 	mockHash := new(MockHasher)
-	uc := NewUserUseCase(mockRepo, mockHash)
+	uc := NewUserUseCase(mockRepo, Repositories.NewInMemoryOrganizationRepository(), mockHash)
 
-	mockRepo.On("GetByEmail", "e@x.com").Return(nil, nil)
+	mockRepo.On("GetByEmail", mock.Anything, "e@x.com").Return(nil, nil)
 
-	user, err := uc.LoginUser("e@x.com", "pw")
+	user, err := uc.LoginUser(ctx, "e@x.com", "pw")
 	assert.Nil(t, user)
 	assert.EqualError(t, err, "invalid credentials")
 }